@@ -67,9 +67,14 @@ const api = {
         return await this.request('/ping');
     },
 
-    async search(phrase, limit, useIndex = false) {
-        const endpoint = useIndex ? '/isearch' : '/search';
-        return await this.request(`${endpoint}?phrase=${encodeURIComponent(phrase)}&limit=${limit}`);
+    async search(phrase, limit, searchType = 'normal') {
+        const endpoint = searchType === 'index' ? '/isearch' : '/search';
+        const mode = searchType === 'fts' ? '&mode=fts' : '';
+        return await this.request(`${endpoint}?phrase=${encodeURIComponent(phrase)}&limit=${limit}${mode}`);
+    },
+
+    async suggest(prefix, limit = 10) {
+        return await this.request(`/suggest?prefix=${encodeURIComponent(prefix)}&limit=${limit}`);
     },
 
     async getStats() {
@@ -147,10 +152,37 @@ document.querySelectorAll('.tab-btn').forEach(btn => {
     });
 });
 
+// автодополнение последнего слова фразы
+let suggestTimer = null;
+document.getElementById('search-phrase').addEventListener('input', (e) => {
+    clearTimeout(suggestTimer);
+    const phrase = e.target.value;
+    const words = phrase.split(/\s+/);
+    const prefix = words.pop();
+    const list = document.getElementById('search-suggestions');
+    if (!prefix) {
+        list.replaceChildren();
+        return;
+    }
+    suggestTimer = setTimeout(async () => {
+        try {
+            const result = await api.suggest(prefix, 10);
+            const head = words.length > 0 ? words.join(' ') + ' ' : '';
+            list.replaceChildren(...(result.completions || []).map(c => {
+                const option = document.createElement('option');
+                option.value = c.kind === 'title' ? c.text : head + c.text;
+                return option;
+            }));
+        } catch (error) {
+            console.error('Suggest error:', error);
+        }
+    }, 200);
+});
+
 document.getElementById('search-btn').addEventListener('click', async () => {
     const phrase = document.getElementById('search-phrase').value;
     const limit = parseInt(document.getElementById('search-limit').value) || 10;
-    const useIndex = document.querySelector('input[name="search-type"]:checked').value === 'index';
+    const searchType = document.querySelector('input[name="search-type"]:checked').value;
     const resultsDiv = document.getElementById('search-results');
 
     if (!phrase.trim()) {
@@ -161,7 +193,7 @@ document.getElementById('search-btn').addEventListener('click', async () => {
     resultsDiv.innerHTML = '<div class="loading">Поиск</div>';
 
     try {
-        const result = await api.search(phrase, limit, useIndex);
+        const result = await api.search(phrase, limit, searchType);
         console.log('Search result:', result);
         console.log('Result type:', typeof result);
         console.log('Result.comics:', result?.comics);
@@ -169,12 +201,17 @@ document.getElementById('search-btn').addEventListener('click', async () => {
         console.log('resultsDiv element:', resultsDiv);
         
         if (result && result.comics && Array.isArray(result.comics) && result.comics.length > 0) {
-            let html = '<h3>Найдено комиксов: ' + (result.total || result.comics.length) + '</h3>';
+            let html = '<h3>Найдено комиксов: ' + (result.total || result.comics.length) +
+                ', показано: ' + result.comics.length + '</h3>';
             
             result.comics.forEach(comic => {
                 html += '<div class="comic-card">';
                 html += '<h3>Комикс #' + comic.id + '</h3>';
                 html += '<a href="' + comic.url + '" target="_blank">' + comic.url + '</a>';
+                // highlighted приходит экранированным, найденные слова размечены <mark>
+                (comic.snippets || []).forEach(snippet => {
+                    html += '<p class="snippet">' + snippet.highlighted + '</p>';
+                });
                 html += '</div>';
             });
             
@@ -189,6 +226,13 @@ document.getElementById('search-btn').addEventListener('click', async () => {
         } else {
             console.log('No comics found or empty result');
             resultsDiv.innerHTML = '<div class="message">Комиксы не найдены</div>';
+            if (result && Array.isArray(result.suggestions) && result.suggestions.length > 0) {
+                // варианты содержат слова пользователя, поэтому выводятся как текст
+                const hint = document.createElement('div');
+                hint.className = 'message';
+                hint.textContent = 'Возможно, вы имели в виду: ' + result.suggestions.join(', ');
+                resultsDiv.appendChild(hint);
+            }
         }
     } catch (error) {
         console.error('Search error:', error);
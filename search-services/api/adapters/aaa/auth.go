@@ -13,6 +13,11 @@ import (
 const secretKey = "something secret here" // token sign key
 const adminRole = "superuser"             // token subject
 
+type claims struct {
+	jwt.RegisteredClaims
+	Name string `json:"name"`
+}
+
 // Authentication, Authorization, Accounting
 type AAA struct {
 	users    map[string]string
@@ -46,10 +51,13 @@ func (a AAA) Login(name, password string) (string, error) {
 	}
 
 	now := time.Now()
-	claims := jwt.RegisteredClaims{
-		Subject:   adminRole,
-		IssuedAt:  jwt.NewNumericDate(now),
-		ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenTTL)),
+	claims := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   adminRole,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenTTL)),
+		},
+		Name: name,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -62,8 +70,10 @@ func (a AAA) Login(name, password string) (string, error) {
 	return tokenString, nil
 }
 
-func (a AAA) Verify(tokenString string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// Verify checks the token and returns name of the user it was issued to.
+func (a AAA) Verify(tokenString string) (string, error) {
+	var claims claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
@@ -71,22 +81,16 @@ func (a AAA) Verify(tokenString string) error {
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to parse token: %w", err)
+		return "", fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !token.Valid {
-		return errors.New("invalid token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return errors.New("invalid token claims")
+		return "", errors.New("invalid token")
 	}
 
-	subject, ok := claims["sub"].(string)
-	if !ok || subject != adminRole {
-		return errors.New("invalid token subject")
+	if claims.Subject != adminRole {
+		return "", errors.New("invalid token subject")
 	}
 
-	return nil
+	return claims.Name, nil
 }
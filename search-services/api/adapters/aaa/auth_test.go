@@ -33,9 +33,13 @@ func TestAAA_Login_SuccessAndVerify(t *testing.T) {
 		t.Fatalf("expected non-empty token")
 	}
 
-	if err := a.Verify(token); err != nil {
+	name, err := a.Verify(token)
+	if err != nil {
 		t.Fatalf("Verify returned error for valid token: %v", err)
 	}
+	if name != "admin" {
+		t.Fatalf("expected name admin, got %q", name)
+	}
 }
 
 func TestAAA_Login_InvalidCredentials(t *testing.T) {
@@ -52,7 +56,7 @@ func TestAAA_Login_InvalidCredentials(t *testing.T) {
 func TestAAA_Verify_InvalidToken(t *testing.T) {
 	a := newTestAAA(t)
 
-	if err := a.Verify("not_a_jwt"); err == nil {
+	if _, err := a.Verify("not_a_jwt"); err == nil {
 		t.Fatalf("expected error for invalid token")
 	}
 }
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"yadro.com/course/api/core"
 )
@@ -60,7 +63,8 @@ func NewLoginHandler(log *slog.Logger, auther core.Authenticator) http.HandlerFu
 // "POST /api/update"
 func NewUpdateHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := updater.Update(r.Context()); err != nil {
+		job, err := updater.Update(r.Context(), core.UserFromContext(r.Context()))
+		if err != nil {
 			log.Error("error while updating", "error", err)
 			if errors.Is(err, core.ErrAlreadyExists) {
 				http.Error(w, err.Error(), http.StatusAccepted)
@@ -69,9 +73,79 @@ func NewUpdateHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+
+		if err := encodeReply(w, toJob(job)); err != nil {
+			log.Error("cannot encode reply", "error", err)
+		}
+	}
+}
+
+// "GET /api/db/jobs/{id}"
+func NewJobHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			log.Error("wrong job id", "value", r.PathValue("id"))
+			http.Error(w, "bad job id", http.StatusBadRequest)
+			return
+		}
+
+		job, err := updater.Job(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				http.Error(w, "job not found", http.StatusNotFound)
+				return
+			}
+			log.Error("error while getting job", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := encodeReply(w, toJob(job)); err != nil {
+			log.Error("cannot encode reply", "error", err)
+		}
+	}
+}
+
+// "DELETE /api/db/jobs/{id}"
+func NewCancelJobHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			log.Error("wrong job id", "value", r.PathValue("id"))
+			http.Error(w, "bad job id", http.StatusBadRequest)
+			return
+		}
+
+		if err := updater.CancelJob(r.Context(), id); err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				http.Error(w, "job not found", http.StatusNotFound)
+				return
+			}
+			log.Error("error while canceling job", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
+func toJob(job core.UpdateJob) UpdateJob {
+	reply := UpdateJob{
+		ID:          job.ID,
+		Status:      string(job.Status),
+		TriggeredBy: job.TriggeredBy,
+		StartedAt:   job.StartedAt,
+		Fetched:     job.Fetched,
+		Failed:      job.Failed,
+		Stored:      job.Stored,
+		Error:       job.Error,
+	}
+	if !job.FinishedAt.IsZero() {
+		reply.FinishedAt = &job.FinishedAt
+	}
+	return reply
+}
+
 // "GET /api/update/stats"
 func NewUpdateStatsHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -115,6 +189,52 @@ func NewUpdateStatusHandler(log *slog.Logger, updater core.Updater) http.Handler
 	}
 }
 
+// "GET /api/db/update/events"
+func NewUpdateEventsHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Error("streaming is not supported")
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		updates, err := updater.WatchUpdate(r.Context())
+		if err != nil {
+			log.Error("error while watching update", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for progress := range updates {
+			data, err := json.Marshal(UpdateProgress{
+				Status:     string(progress.Status),
+				Total:      progress.Total,
+				Fetched:    progress.Fetched,
+				Failed:     progress.Failed,
+				Normalized: progress.Normalized,
+				Stored:     progress.Stored,
+				ETASeconds: progress.ETA.Seconds(),
+			})
+			if err != nil {
+				log.Error("cannot encode progress", "error", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data); err != nil {
+				log.Error("cannot write event", "error", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
 // "DELETE /api/db"
 func NewDropHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -128,47 +248,63 @@ func NewDropHandler(log *slog.Logger, updater core.Updater) http.HandlerFunc {
 
 // "GET /api/search"
 func NewSearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc {
+	return newSearchHandler(log, map[string]searchFunc{
+		"":      searcher.Search,
+		"db":    searcher.Search,
+		"index": searcher.SearchIndex,
+		"fts":   searcher.FullTextSearch,
+	})
+}
+
+// "GET /api/isearch", also serves "GET /api/search?mode=index"
+func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc {
+	return newSearchHandler(log, map[string]searchFunc{
+		"":      searcher.SearchIndex,
+		"index": searcher.SearchIndex,
+	})
+}
+
+type searchFunc func(context.Context, core.SearchRequest) (core.SearchResult, error)
+
+// newSearchHandler searches with the function of the mode parameter
+func newSearchHandler(log *slog.Logger, modes map[string]searchFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var limit int
-		var err error
-		limitStr := r.URL.Query().Get("limit")
-		if limitStr != "" {
-			limit, err = strconv.Atoi(limitStr)
-			if err != nil {
-				log.Error("wrong limit", "value", limitStr)
-				http.Error(w, "bad limit", http.StatusBadRequest)
-				return
-			}
-			if limit < 0 {
-				log.Error("wrong limit", "value", limit)
-				http.Error(w, "bad limit", http.StatusBadRequest)
-				return
-			}
+		search, ok := modes[r.URL.Query().Get("mode")]
+		if !ok {
+			log.Error("wrong search mode", "mode", r.URL.Query().Get("mode"))
+			http.Error(w, "bad mode", http.StatusBadRequest)
+			return
 		}
-		phrase := r.URL.Query().Get("phrase")
-		if phrase == "" {
-			log.Error("no phrase")
-			http.Error(w, "no phrase", http.StatusBadRequest)
+		req, err := searchRequest(r)
+		if err != nil {
+			log.Error("wrong search request", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		comics, err := searcher.Search(r.Context(), phrase, limit)
+		result, err := search(r.Context(), req)
 		if err != nil {
 			if errors.Is(err, core.ErrNotFound) {
 				http.Error(w, "no comics found", http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, core.ErrBadArguments) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			log.Error("error while seaching", "error", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		reply := ComicsReply{
-			Comics: make([]Comics, 0, len(comics)),
-			Total:  len(comics),
+			Comics:        make([]Comics, 0, len(result.Comics)),
+			Total:         result.Total,
+			NextPageToken: result.NextPageToken,
+			Suggestions:   result.Suggestions,
 		}
-		for _, c := range comics {
-			reply.Comics = append(reply.Comics, Comics{ID: c.ID, URL: c.URL})
+		for _, c := range result.Comics {
+			reply.Comics = append(reply.Comics, toComics(c))
 		}
 
 		if err := encodeReply(w, reply); err != nil {
@@ -177,49 +313,146 @@ func NewSearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc
 	}
 }
 
-// "GET /api/isearch"
-func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc {
+// searchRequest parses phrase, filters, limit, offset, page_token and fuzzy parameters.
+// Dates are days, to is inclusive.
+func searchRequest(r *http.Request) (core.SearchRequest, error) {
+	params := r.URL.Query()
+	req := core.SearchRequest{Phrase: params.Get("phrase"), PageToken: params.Get("page_token")}
+	var err error
+	if limitStr := params.Get("limit"); limitStr != "" {
+		req.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || req.Limit < 0 {
+			return core.SearchRequest{}, errors.New("bad limit")
+		}
+	}
+	if offsetStr := params.Get("offset"); offsetStr != "" {
+		req.Offset, err = strconv.Atoi(offsetStr)
+		if err != nil || req.Offset < 0 {
+			return core.SearchRequest{}, errors.New("bad offset")
+		}
+		if req.PageToken != "" {
+			return core.SearchRequest{}, errors.New("offset and page_token are mutually exclusive")
+		}
+	}
+	if fuzzyStr := params.Get("fuzzy"); fuzzyStr != "" {
+		req.Fuzzy, err = strconv.ParseBool(fuzzyStr)
+		if err != nil {
+			return core.SearchRequest{}, errors.New("bad fuzzy")
+		}
+	}
+	if fromStr := params.Get("from"); fromStr != "" {
+		req.From, err = time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			return core.SearchRequest{}, errors.New("bad from")
+		}
+	}
+	if toStr := params.Get("to"); toStr != "" {
+		to, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			return core.SearchRequest{}, errors.New("bad to")
+		}
+		req.To = to.AddDate(0, 0, 1)
+	}
+	if minStr := params.Get("min_id"); minStr != "" {
+		req.MinID, err = strconv.Atoi(minStr)
+		if err != nil || req.MinID < 1 {
+			return core.SearchRequest{}, errors.New("bad min_id")
+		}
+	}
+	if maxStr := params.Get("max_id"); maxStr != "" {
+		req.MaxID, err = strconv.Atoi(maxStr)
+		if err != nil || req.MaxID < 1 {
+			return core.SearchRequest{}, errors.New("bad max_id")
+		}
+	}
+	if req.Phrase == "" {
+		return core.SearchRequest{}, errors.New("no phrase")
+	}
+	return req, nil
+}
+
+// maxSuggestLimit caps the number of completions of one request
+const maxSuggestLimit = 50
+
+// "GET /api/suggest"
+func NewSuggestHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var limit int
 		var err error
-		limitStr := r.URL.Query().Get("limit")
-		if limitStr != "" {
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 			limit, err = strconv.Atoi(limitStr)
-			if err != nil {
+			if err != nil || limit < 0 {
 				log.Error("wrong limit", "value", limitStr)
 				http.Error(w, "bad limit", http.StatusBadRequest)
 				return
 			}
-			if limit < 0 {
-				log.Error("wrong limit", "value", limit)
-				http.Error(w, "bad limit", http.StatusBadRequest)
-				return
-			}
+			limit = min(limit, maxSuggestLimit)
 		}
-		phrase := r.URL.Query().Get("phrase")
-		if phrase == "" {
-			log.Error("no phrase")
-			http.Error(w, "no phrase", http.StatusBadRequest)
+		prefix := r.URL.Query().Get("prefix")
+		if strings.TrimSpace(prefix) == "" {
+			log.Error("no prefix")
+			http.Error(w, "no prefix", http.StatusBadRequest)
 			return
 		}
 
-		comics, err := searcher.SearchIndex(r.Context(), phrase, limit)
+		completions, err := searcher.Suggest(r.Context(), prefix, limit)
 		if err != nil {
-			if errors.Is(err, core.ErrNotFound) {
-				http.Error(w, "no comics found", http.StatusNotFound)
+			if errors.Is(err, core.ErrBadArguments) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
-			log.Error("error while seaching", "error", err)
+			log.Error("error while suggesting", "error", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		reply := ComicsReply{
-			Comics: make([]Comics, 0, len(comics)),
-			Total:  len(comics),
+		reply := SuggestReply{Completions: make([]Completion, 0, len(completions))}
+		for _, c := range completions {
+			reply.Completions = append(reply.Completions, Completion{Text: c.Text, Kind: c.Kind, Frequency: c.Frequency})
 		}
-		for _, c := range comics {
-			reply.Comics = append(reply.Comics, Comics{ID: c.ID, URL: c.URL})
+		if err := encodeReply(w, reply); err != nil {
+			log.Error("cannot encode reply", "error", err)
+		}
+	}
+}
+
+// "GET /api/comics/{id}"
+func NewComicHandler(log *slog.Logger, searcher core.Searcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			log.Error("wrong comics id", "value", r.PathValue("id"))
+			http.Error(w, "bad comics id", http.StatusBadRequest)
+			return
+		}
+
+		comic, err := searcher.Comic(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, core.ErrNotFound):
+				http.Error(w, "comics not found", http.StatusNotFound)
+			case errors.Is(err, core.ErrBadArguments):
+				http.Error(w, "bad comics id", http.StatusBadRequest)
+			default:
+				log.Error("error while getting comics", "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		reply := ComicInfo{
+			ID:         comic.ID,
+			URL:        comic.URL,
+			Title:      comic.Title,
+			SafeTitle:  comic.SafeTitle,
+			Alt:        comic.Alt,
+			Transcript: comic.Transcript,
+			Link:       comic.Link,
+			News:       comic.News,
+			Words:      comic.Words,
+		}
+		if !comic.Published.IsZero() {
+			reply.Published = comic.Published.Format(time.DateOnly)
 		}
 
 		if err := encodeReply(w, reply); err != nil {
@@ -228,6 +461,31 @@ func NewIndexSearchHandler(log *slog.Logger, searcher core.Searcher) http.Handle
 	}
 }
 
+func toComics(c core.Comics) Comics {
+	comics := Comics{ID: c.ID, URL: c.URL, Score: c.Score}
+	for _, s := range c.Snippets {
+		comics.Snippets = append(comics.Snippets, Snippet{Field: s.Field, Text: s.Text, Highlighted: highlight(s)})
+	}
+	return comics
+}
+
+// highlight escapes the snippet text for HTML and wraps highlighted words in <mark>,
+// ranges out of order or out of the text are skipped
+func highlight(s core.Snippet) string {
+	var b strings.Builder
+	pos := 0
+	for _, h := range s.Highlights {
+		if h.Start < pos || h.End <= h.Start || h.End > len(s.Text) {
+			continue
+		}
+		b.WriteString(html.EscapeString(s.Text[pos:h.Start]))
+		b.WriteString("<mark>" + html.EscapeString(s.Text[h.Start:h.End]) + "</mark>")
+		pos = h.End
+	}
+	b.WriteString(html.EscapeString(s.Text[pos:]))
+	return b.String()
+}
+
 func encodeReply(w io.Writer, reply any) error {
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
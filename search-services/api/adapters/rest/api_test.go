@@ -2,14 +2,18 @@ package rest
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"yadro.com/course/api/core"
 )
@@ -36,26 +40,90 @@ type fakeUpdater struct {
 	status    core.UpdateStatus
 	statusErr error
 	dropErr   error
+	progress  []core.UpdateProgress
+	watchErr  error
+	job       core.UpdateJob
+	jobErr    error
 }
 
-func (f fakeUpdater) Update(ctx context.Context) error                    { return f.updateErr }
+func (f fakeUpdater) Update(ctx context.Context, user string) (core.UpdateJob, error) {
+	if f.updateErr != nil {
+		return core.UpdateJob{}, f.updateErr
+	}
+	return core.UpdateJob{ID: 1, Status: core.JobStatusRunning, TriggeredBy: user}, nil
+}
+func (f fakeUpdater) Job(ctx context.Context, id int64) (core.UpdateJob, error) {
+	return f.job, f.jobErr
+}
+func (f fakeUpdater) CancelJob(ctx context.Context, id int64) error       { return f.jobErr }
 func (f fakeUpdater) Stats(ctx context.Context) (core.UpdateStats, error) { return f.stats, f.statsErr }
 func (f fakeUpdater) Status(ctx context.Context) (core.UpdateStatus, error) {
 	return f.status, f.statusErr
 }
 func (f fakeUpdater) Drop(ctx context.Context) error { return f.dropErr }
+func (f fakeUpdater) WatchUpdate(ctx context.Context) (<-chan core.UpdateProgress, error) {
+	if f.watchErr != nil {
+		return nil, f.watchErr
+	}
+	ch := make(chan core.UpdateProgress, len(f.progress))
+	for _, p := range f.progress {
+		ch <- p
+	}
+	close(ch)
+	return ch, nil
+}
 
 type fakeSearcher struct {
-	comics []core.Comics
-	err    error
+	comics        []core.Comics
+	suggestions   []string
+	err           error
+	comic         core.ComicInfo
+	completions   []core.Completion
+	total         int
+	nextPageToken string
+	req           *core.SearchRequest
+	// mode is the name of the called search
+	mode *string
+	// limit is the limit passed to Suggest
+	limit *int
 }
 
-func (f fakeSearcher) Search(ctx context.Context, phrase string, limit int) ([]core.Comics, error) {
-	return f.comics, f.err
+func (f fakeSearcher) Comic(ctx context.Context, id int) (core.ComicInfo, error) {
+	return f.comic, f.err
 }
 
-func (f fakeSearcher) SearchIndex(ctx context.Context, phrase string, limit int) ([]core.Comics, error) {
-	return f.comics, f.err
+func (f fakeSearcher) Suggest(ctx context.Context, prefix string, limit int) ([]core.Completion, error) {
+	if f.limit != nil {
+		*f.limit = limit
+	}
+	return f.completions, f.err
+}
+
+func (f fakeSearcher) Search(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	return f.search("db", req)
+}
+
+func (f fakeSearcher) SearchIndex(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	return f.search("index", req)
+}
+
+func (f fakeSearcher) FullTextSearch(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	return f.search("fts", req)
+}
+
+func (f fakeSearcher) search(mode string, req core.SearchRequest) (core.SearchResult, error) {
+	if f.req != nil {
+		*f.req = req
+	}
+	if f.mode != nil {
+		*f.mode = mode
+	}
+	return core.SearchResult{
+		Comics:        f.comics,
+		Total:         cmp.Or(f.total, len(f.comics)),
+		NextPageToken: f.nextPageToken,
+		Suggestions:   f.suggestions,
+	}, f.err
 }
 
 func newTestLogger() *slog.Logger {
@@ -157,11 +225,80 @@ func TestNewUpdateHandler_Success(t *testing.T) {
 	h := NewUpdateHandler(log, fakeUpdater{})
 
 	rr := httptest.NewRecorder()
-	h(rr, httptest.NewRequest(http.MethodPost, "/api/update", nil))
+	req := httptest.NewRequest(http.MethodPost, "/api/update", nil)
+	h(rr, req.WithContext(core.WithUser(req.Context(), "admin")))
 
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rr.Code)
 	}
+	var job UpdateJob
+	if err := json.NewDecoder(rr.Body).Decode(&job); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if job.ID != 1 || job.Status != "running" || job.TriggeredBy != "admin" || job.FinishedAt != nil {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+}
+
+func TestNewJobHandler(t *testing.T) {
+	log := newTestLogger()
+	finished := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewJobHandler(log, fakeUpdater{job: core.UpdateJob{
+		ID: 5, Status: core.JobStatusDone, FinishedAt: finished, Stored: 3,
+	}})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/db/jobs/5", nil)
+	req.SetPathValue("id", "5")
+	h(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var job UpdateJob
+	if err := json.NewDecoder(rr.Body).Decode(&job); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if job.ID != 5 || job.Status != "done" || job.Stored != 3 || job.FinishedAt == nil || !job.FinishedAt.Equal(finished) {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+}
+
+func TestNewJobHandlers_Errors(t *testing.T) {
+	log := newTestLogger()
+	handlers := map[string]http.HandlerFunc{
+		"get":    NewJobHandler(log, fakeUpdater{jobErr: core.ErrNotFound}),
+		"cancel": NewCancelJobHandler(log, fakeUpdater{jobErr: core.ErrNotFound}),
+	}
+	for name, h := range handlers {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/db/jobs/abc", nil)
+		req.SetPathValue("id", "abc")
+		h(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", name, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/api/db/jobs/7", nil)
+		req.SetPathValue("id", "7")
+		h(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", name, rr.Code)
+		}
+	}
+}
+
+func TestNewCancelJobHandler_Success(t *testing.T) {
+	log := newTestLogger()
+	h := NewCancelJobHandler(log, fakeUpdater{})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/api/db/jobs/7", nil)
+	req.SetPathValue("id", "7")
+	h(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
 }
 
 func TestNewUpdateStatsHandler(t *testing.T) {
@@ -202,6 +339,51 @@ func TestNewUpdateStatusHandler(t *testing.T) {
 	}
 }
 
+func TestNewUpdateEventsHandler(t *testing.T) {
+	log := newTestLogger()
+	h := NewUpdateEventsHandler(log, fakeUpdater{progress: []core.UpdateProgress{
+		{Status: core.StatusUpdateRunning, Total: 10, Stored: 5, ETA: 2 * time.Second},
+		{Status: core.StatusUpdateIdle, Total: 10, Stored: 10},
+	}})
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest(http.MethodGet, "/api/db/update/events", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected event stream, got %q", ct)
+	}
+
+	events := strings.Split(strings.TrimSpace(rr.Body.String()), "\n\n")
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %q", len(events), rr.Body.String())
+	}
+	data, ok := strings.CutPrefix(events[0], "event: progress\ndata: ")
+	if !ok {
+		t.Fatalf("unexpected event format: %q", events[0])
+	}
+	var progress UpdateProgress
+	if err := json.Unmarshal([]byte(data), &progress); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	want := UpdateProgress{Status: "running", Total: 10, Stored: 5, ETASeconds: 2}
+	if progress != want {
+		t.Fatalf("expected %#v, got %#v", want, progress)
+	}
+}
+
+func TestNewUpdateEventsHandler_Error(t *testing.T) {
+	log := newTestLogger()
+	h := NewUpdateEventsHandler(log, fakeUpdater{watchErr: errors.New("err")})
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest(http.MethodGet, "/api/db/update/events", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
 func TestNewDropHandler(t *testing.T) {
 	log := newTestLogger()
 	h := NewDropHandler(log, fakeUpdater{})
@@ -261,6 +443,58 @@ func TestNewSearchHandler_NotFoundAndSuccess(t *testing.T) {
 	}
 }
 
+func TestSearchHandlers_Suggestions(t *testing.T) {
+	log := newTestLogger()
+	searcher := fakeSearcher{suggestions: []string{"recursion"}}
+
+	for _, h := range []http.HandlerFunc{NewSearchHandler(log, searcher), NewIndexSearchHandler(log, searcher)} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest(http.MethodGet, "/api/search?phrase=recursoin", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		var resp ComicsReply
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if resp.Total != 0 || len(resp.Suggestions) != 1 || resp.Suggestions[0] != "recursion" {
+			t.Fatalf("unexpected resp: %#v", resp)
+		}
+	}
+}
+
+func TestSearchHandlers_BadFuzzy(t *testing.T) {
+	log := newTestLogger()
+
+	for _, h := range []http.HandlerFunc{NewSearchHandler(log, fakeSearcher{}), NewIndexSearchHandler(log, fakeSearcher{})} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest(http.MethodGet, "/api/search?phrase=linux&fuzzy=maybe", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		h(rr, httptest.NewRequest(http.MethodGet, "/api/search?phrase=linux&fuzzy=1", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+	}
+}
+
+func TestSearchHandlers_BadQuery(t *testing.T) {
+	log := newTestLogger()
+	searcher := fakeSearcher{err: fmt.Errorf("%w: unclosed quote", core.ErrBadArguments)}
+
+	for _, h := range []http.HandlerFunc{NewSearchHandler(log, searcher), NewIndexSearchHandler(log, searcher)} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, `/api/search?phrase=%22linux`, nil)
+		h(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", rr.Code)
+		}
+	}
+}
+
 func TestNewIndexSearchHandler_Success(t *testing.T) {
 	log := newTestLogger()
 	h := NewIndexSearchHandler(log, fakeSearcher{
@@ -281,3 +515,221 @@ func TestNewIndexSearchHandler_Success(t *testing.T) {
 		t.Fatalf("unexpected resp: %#v", resp)
 	}
 }
+
+func TestNewComicHandler(t *testing.T) {
+	log := newTestLogger()
+	h := NewComicHandler(log, fakeSearcher{comic: core.ComicInfo{
+		ID:        1,
+		URL:       "u1",
+		Title:     "Barrel - Part 1",
+		Published: time.Date(2006, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/comics/1", nil)
+	req.SetPathValue("id", "1")
+	h(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp ComicInfo
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.ID != 1 || resp.Title != "Barrel - Part 1" || resp.Published != "2006-01-01" {
+		t.Fatalf("unexpected resp: %#v", resp)
+	}
+}
+
+func TestNewComicHandler_Errors(t *testing.T) {
+	log := newTestLogger()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/comics/x", nil)
+	req.SetPathValue("id", "x")
+	NewComicHandler(log, fakeSearcher{})(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/comics/5", nil)
+	req.SetPathValue("id", "5")
+	NewComicHandler(log, fakeSearcher{err: core.ErrNotFound})(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestSuggestHandler(t *testing.T) {
+	log := newTestLogger()
+	h := NewSuggestHandler(log, fakeSearcher{completions: []core.Completion{{Text: "linux", Kind: "word", Frequency: 4}}})
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest(http.MethodGet, "/api/suggest?prefix=lin&limit=5", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp SuggestReply
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(resp.Completions) != 1 || resp.Completions[0] != (Completion{Text: "linux", Kind: "word", Frequency: 4}) {
+		t.Fatalf("unexpected resp: %#v", resp)
+	}
+
+	var limit int
+	h = NewSuggestHandler(log, fakeSearcher{limit: &limit})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/suggest?prefix=lin&limit=1000000", nil))
+	if limit != maxSuggestLimit {
+		t.Fatalf("expected limit to be capped to %d, got %d", maxSuggestLimit, limit)
+	}
+
+	for _, target := range []string{"/api/suggest", "/api/suggest?prefix=%20", "/api/suggest?prefix=lin&limit=-1", "/api/suggest?prefix=lin&limit=x"} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	cases := []struct {
+		snippet core.Snippet
+		want    string
+	}{
+		{core.Snippet{Text: "linux <3 kernel", Highlights: []core.Highlight{{Start: 0, End: 5}, {Start: 9, End: 15}}}, "<mark>linux</mark> &lt;3 <mark>kernel</mark>"},
+		{core.Snippet{Text: "a&b"}, "a&amp;b"},
+		// ranges from a broken reply are skipped
+		{core.Snippet{Text: "linux", Highlights: []core.Highlight{{Start: 2, End: 10}, {Start: 3, End: 1}, {Start: 0, End: 2}, {Start: 1, End: 3}}}, "<mark>li</mark>nux"},
+	}
+	for _, c := range cases {
+		if got := highlight(c.snippet); got != c.want {
+			t.Fatalf("highlight(%q): expected %q, got %q", c.snippet.Text, c.want, got)
+		}
+	}
+}
+
+func TestSearchHandler_Snippets(t *testing.T) {
+	log := newTestLogger()
+	searcher := fakeSearcher{comics: []core.Comics{{ID: 1, Snippets: []core.Snippet{
+		{Field: "title", Text: "linux user", Highlights: []core.Highlight{{Start: 0, End: 5}}},
+	}}}}
+
+	rr := httptest.NewRecorder()
+	NewSearchHandler(log, searcher)(rr, httptest.NewRequest(http.MethodGet, "/api/search?phrase=linux", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp ComicsReply
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	want := Snippet{Field: "title", Text: "linux user", Highlighted: "<mark>linux</mark> user"}
+	if len(resp.Comics) != 1 || len(resp.Comics[0].Snippets) != 1 || resp.Comics[0].Snippets[0] != want {
+		t.Fatalf("unexpected resp: %#v", resp)
+	}
+}
+
+func TestSearchHandler_Pages(t *testing.T) {
+	log := newTestLogger()
+	var req core.SearchRequest
+	searcher := fakeSearcher{comics: []core.Comics{{ID: 1}}, total: 42, nextPageToken: "next", req: &req}
+
+	rr := httptest.NewRecorder()
+	NewSearchHandler(log, searcher)(rr, httptest.NewRequest(http.MethodGet, "/api/search?phrase=linux&limit=1&offset=40&fuzzy=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if want := (core.SearchRequest{Phrase: "linux", Limit: 1, Offset: 40, Fuzzy: true}); req != want {
+		t.Fatalf("expected request %+v, got %+v", want, req)
+	}
+	var resp ComicsReply
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if resp.Total != 42 || resp.NextPageToken != "next" || len(resp.Comics) != 1 {
+		t.Fatalf("unexpected resp: %#v", resp)
+	}
+
+	rr = httptest.NewRecorder()
+	NewIndexSearchHandler(log, searcher)(rr, httptest.NewRequest(http.MethodGet, "/api/isearch?phrase=linux&page_token=next", nil))
+	if rr.Code != http.StatusOK || req.PageToken != "next" {
+		t.Fatalf("expected page token to be passed, got %d, %+v", rr.Code, req)
+	}
+}
+
+func TestSearchHandlers_BadPage(t *testing.T) {
+	log := newTestLogger()
+	for _, target := range []string{
+		"/api/search?phrase=linux&offset=-1",
+		"/api/search?phrase=linux&offset=x",
+		"/api/search?phrase=linux&offset=10&page_token=abc",
+	} {
+		rr := httptest.NewRecorder()
+		NewSearchHandler(log, fakeSearcher{})(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestSearchHandler_Filter(t *testing.T) {
+	var req core.SearchRequest
+	rr := httptest.NewRecorder()
+	target := "/api/search?phrase=linux&from=2006-01-01&to=2009-12-31&min_id=10&max_id=500"
+	NewSearchHandler(newTestLogger(), fakeSearcher{req: &req})(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	want := core.SearchRequest{
+		Phrase: "linux",
+		From:   time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+		MinID:  10,
+		MaxID:  500,
+	}
+	if req != want {
+		t.Fatalf("expected request %+v, got %+v", want, req)
+	}
+
+	for _, target := range []string{
+		"/api/isearch?phrase=linux&from=2006",
+		"/api/isearch?phrase=linux&to=31.12.2009",
+		"/api/isearch?phrase=linux&min_id=0",
+		"/api/isearch?phrase=linux&max_id=x",
+	} {
+		rr := httptest.NewRecorder()
+		NewIndexSearchHandler(newTestLogger(), fakeSearcher{})(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rr.Code)
+		}
+	}
+}
+
+func TestSearchHandlers_Mode(t *testing.T) {
+	cases := []struct {
+		handler func(*slog.Logger, core.Searcher) http.HandlerFunc
+		target  string
+		code    int
+		mode    string
+	}{
+		{NewSearchHandler, "/api/search?phrase=linux", http.StatusOK, "db"},
+		{NewSearchHandler, "/api/search?phrase=linux&mode=db", http.StatusOK, "db"},
+		{NewSearchHandler, "/api/search?phrase=linux&mode=index", http.StatusOK, "index"},
+		{NewSearchHandler, "/api/search?phrase=linux&mode=fts", http.StatusOK, "fts"},
+		{NewSearchHandler, "/api/search?phrase=linux&mode=FTS", http.StatusBadRequest, ""},
+		{NewIndexSearchHandler, "/api/isearch?phrase=linux", http.StatusOK, "index"},
+		{NewIndexSearchHandler, "/api/search?phrase=linux&mode=index", http.StatusOK, "index"},
+		{NewIndexSearchHandler, "/api/isearch?phrase=linux&mode=fts", http.StatusBadRequest, ""},
+	}
+	for _, c := range cases {
+		var mode string
+		rr := httptest.NewRecorder()
+		c.handler(newTestLogger(), fakeSearcher{mode: &mode})(rr, httptest.NewRequest(http.MethodGet, c.target, nil))
+		if rr.Code != c.code || mode != c.mode {
+			t.Fatalf("%s: expected %d from %q search, got %d from %q", c.target, c.code, c.mode, rr.Code, mode)
+		}
+	}
+}
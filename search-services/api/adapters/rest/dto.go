@@ -1,17 +1,54 @@
 package rest
 
+import "time"
+
 type PingResponse struct {
 	Replies map[string]string `json:"replies"`
 }
 
 type ComicsReply struct {
-	Comics []Comics `json:"comics"`
-	Total  int      `json:"total"`
+	Comics        []Comics `json:"comics"`
+	Total         int      `json:"total"`
+	NextPageToken string   `json:"next_page_token,omitempty"`
+	Suggestions   []string `json:"suggestions,omitempty"`
 }
 
 type Comics struct {
-	ID  int    `json:"id"`
-	URL string `json:"url"`
+	ID       int       `json:"id"`
+	URL      string    `json:"url"`
+	Score    float64   `json:"score"`
+	Snippets []Snippet `json:"snippets,omitempty"`
+}
+
+// Snippet is a fragment of a comics field, Highlighted is the HTML escaped
+// text with the matched words wrapped in <mark>
+type Snippet struct {
+	Field       string `json:"field"`
+	Text        string `json:"text"`
+	Highlighted string `json:"highlighted"`
+}
+
+type SuggestReply struct {
+	Completions []Completion `json:"completions"`
+}
+
+type Completion struct {
+	Text      string `json:"text"`
+	Kind      string `json:"kind"`
+	Frequency int    `json:"frequency"`
+}
+
+type ComicInfo struct {
+	ID         int      `json:"id"`
+	URL        string   `json:"url"`
+	Title      string   `json:"title"`
+	SafeTitle  string   `json:"safe_title"`
+	Alt        string   `json:"alt"`
+	Transcript string   `json:"transcript"`
+	Link       string   `json:"link"`
+	News       string   `json:"news"`
+	Published  string   `json:"published,omitempty"`
+	Words      []string `json:"words"`
 }
 
 type UpdateStats struct {
@@ -25,6 +62,28 @@ type UpdateStatus struct {
 	Status string `json:"status"`
 }
 
+type UpdateProgress struct {
+	Status     string  `json:"status"`
+	Total      int     `json:"total"`
+	Fetched    int     `json:"fetched"`
+	Failed     int     `json:"failed"`
+	Normalized int     `json:"normalized"`
+	Stored     int     `json:"stored"`
+	ETASeconds float64 `json:"eta_seconds"`
+}
+
+type UpdateJob struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	TriggeredBy string     `json:"triggered_by"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Fetched     int        `json:"fetched"`
+	Failed      int        `json:"failed"`
+	Stored      int        `json:"stored"`
+	Error       string     `json:"error,omitempty"`
+}
+
 type LoginRequest struct {
 	Name     string `json:"name"`
 	Password string `json:"password"`
@@ -3,10 +3,12 @@ package middleware
 import (
 	"net/http"
 	"strings"
+
+	"yadro.com/course/api/core"
 )
 
 type TokenVerifier interface {
-	Verify(token string) error
+	Verify(token string) (string, error)
 }
 
 func Auth(next http.HandlerFunc, verifier TokenVerifier) http.HandlerFunc {
@@ -25,11 +27,12 @@ func Auth(next http.HandlerFunc, verifier TokenVerifier) http.HandlerFunc {
 			return
 		}
 
-		if err := verifier.Verify(token); err != nil {
+		user, err := verifier.Verify(token)
+		if err != nil {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		next(w, r)
+		next(w, r.WithContext(core.WithUser(r.Context(), user)))
 	}
 }
@@ -4,14 +4,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"yadro.com/course/api/core"
 )
 
 type fakeVerifier struct {
-	err error
+	user string
+	err  error
 }
 
-func (f fakeVerifier) Verify(token string) error {
-	return f.err
+func (f fakeVerifier) Verify(token string) (string, error) {
+	return f.user, f.err
 }
 
 func TestAuth_NoHeader(t *testing.T) {
@@ -101,8 +104,11 @@ func TestAuth_Success(t *testing.T) {
 	called := false
 	h := Auth(func(w http.ResponseWriter, r *http.Request) {
 		called = true
+		if user := core.UserFromContext(r.Context()); user != "admin" {
+			t.Errorf("expected user admin in context, got %q", user)
+		}
 		w.WriteHeader(http.StatusOK)
-	}, fakeVerifier{})
+	}, fakeVerifier{user: "admin"})
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	req.Header.Set("Authorization", "Token token")
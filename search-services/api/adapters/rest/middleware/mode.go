@@ -0,0 +1,15 @@
+package middleware
+
+import "net/http"
+
+// Mode passes requests with the mode query parameter listed in modes
+// to the handler of the mode, and all other requests to next.
+func Mode(next http.HandlerFunc, modes map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := modes[r.URL.Query().Get("mode")]; ok {
+			handler(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMode(t *testing.T) {
+	var called string
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			called = name
+		}
+	}
+	h := Mode(handler("next"), map[string]http.HandlerFunc{"index": handler("index")})
+
+	cases := map[string]string{
+		"/api/search?phrase=linux":            "next",
+		"/api/search?phrase=linux&mode=db":    "next",
+		"/api/search?phrase=linux&mode=index": "index",
+	}
+	for target, want := range cases {
+		called = ""
+		h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		if called != want {
+			t.Fatalf("%s: expected %q handler, got %q", target, want, called)
+		}
+	}
+}
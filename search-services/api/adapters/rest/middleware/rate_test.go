@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestRate_NonPositiveRPSReturnsSameHandler(t *testing.T) {
@@ -34,4 +36,24 @@ func TestRate_AllowedRequest(t *testing.T) {
 	}
 }
 
+func TestRate_HandlersDoNotShareLimit(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	first, second := Rate(ok, 1), Rate(ok, 1)
 
+	// the only token of the first limiter is spent, the next one comes in a second
+	first(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	rr := httptest.NewRecorder()
+	second(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	first(rr, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected first handler to be limited, got %d", rr.Code)
+	}
+}
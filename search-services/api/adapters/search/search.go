@@ -2,6 +2,7 @@ package search
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"google.golang.org/grpc"
@@ -9,6 +10,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yadro.com/course/api/core"
 	searchpb "yadro.com/course/proto/search"
 )
@@ -40,47 +42,117 @@ func (c Client) Ping(ctx context.Context) error {
 	return err
 }
 
-func (c *Client) Search(ctx context.Context, phrase string, limit int) ([]core.Comics, error) {
-	reply, err := c.client.Search(ctx, &searchpb.SearchRequest{
-		Phrase: phrase, Limit: int64(limit),
+func (c Client) Search(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	return c.search(ctx, req, func(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+		return c.client.Search(ctx, req)
 	})
-	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return nil, core.ErrNotFound
-		}
-		return nil, err
-	}
-	comics := make([]core.Comics, 0, len(reply.Comics))
-	for _, c := range reply.Comics {
-		comics = append(comics, core.Comics{ID: int(c.Id), URL: c.Url})
-	}
-	return comics, nil
 }
 
-func (c Client) SearchIndex(ctx context.Context, phrase string, limit int) ([]core.Comics, error) {
-	return c.search(ctx, phrase, limit, func(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+func (c Client) SearchIndex(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	return c.search(ctx, req, func(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
 		return c.client.IndexSearch(ctx, req)
 	})
 }
 
-func (c Client) search(ctx context.Context, phrase string, limit int, call func(context.Context, *searchpb.SearchRequest) (*searchpb.SearchReply, error)) ([]core.Comics, error) {
+func (c Client) FullTextSearch(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	return c.search(ctx, req, func(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+		return c.client.FullTextSearch(ctx, req)
+	})
+}
+
+func (c Client) search(ctx context.Context, req core.SearchRequest, call func(context.Context, *searchpb.SearchRequest) (*searchpb.SearchReply, error)) (core.SearchResult, error) {
 	request := &searchpb.SearchRequest{
-		Phrase: phrase,
-		Limit:  int64(limit),
+		Phrase:    req.Phrase,
+		MinId:     int64(req.MinID),
+		MaxId:     int64(req.MaxID),
+		Limit:     int64(req.Limit),
+		Offset:    int64(req.Offset),
+		PageToken: req.PageToken,
+		Fuzzy:     req.Fuzzy,
+	}
+	if !req.From.IsZero() {
+		request.From = timestamppb.New(req.From)
+	}
+	if !req.To.IsZero() {
+		request.To = timestamppb.New(req.To)
 	}
 
 	reply, err := call(ctx, request)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return nil, core.ErrNotFound
+		switch status.Code(err) {
+		case codes.NotFound:
+			return core.SearchResult{}, core.ErrNotFound
+		case codes.InvalidArgument:
+			return core.SearchResult{}, fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
 		}
-		return nil, err
+		return core.SearchResult{}, err
 	}
 
 	comics := make([]core.Comics, 0, len(reply.Comics))
 	for _, comic := range reply.Comics {
-		comics = append(comics, core.Comics{ID: int(comic.Id), URL: comic.Url})
+		comics = append(comics, toComics(comic))
+	}
+
+	return core.SearchResult{
+		Comics:        comics,
+		Total:         int(reply.Total),
+		NextPageToken: reply.NextPageToken,
+		Suggestions:   reply.Suggestions,
+	}, nil
+}
+
+func toComics(c *searchpb.Comics) core.Comics {
+	comics := core.Comics{ID: int(c.Id), URL: c.Url, Score: c.Score}
+	for _, s := range c.Snippets {
+		snippet := core.Snippet{Field: s.Field, Text: s.Text}
+		for _, h := range s.Highlights {
+			snippet.Highlights = append(snippet.Highlights, core.Highlight{Start: int(h.Start), End: int(h.End)})
+		}
+		comics.Snippets = append(comics.Snippets, snippet)
+	}
+	return comics
+}
+
+func (c Client) Comic(ctx context.Context, id int) (core.ComicInfo, error) {
+	reply, err := c.client.GetComic(ctx, &searchpb.ComicRequest{Id: int64(id)})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			return core.ComicInfo{}, core.ErrNotFound
+		case codes.InvalidArgument:
+			return core.ComicInfo{}, core.ErrBadArguments
+		}
+		return core.ComicInfo{}, err
+	}
+
+	comic := core.ComicInfo{
+		ID:         int(reply.Id),
+		URL:        reply.Url,
+		Title:      reply.Title,
+		SafeTitle:  reply.SafeTitle,
+		Alt:        reply.Alt,
+		Transcript: reply.Transcript,
+		Link:       reply.Link,
+		News:       reply.News,
+		Words:      reply.Words,
+	}
+	if reply.Published != nil {
+		comic.Published = reply.Published.AsTime()
 	}
+	return comic, nil
+}
 
-	return comics, nil
+func (c Client) Suggest(ctx context.Context, prefix string, limit int) ([]core.Completion, error) {
+	reply, err := c.client.Suggest(ctx, &searchpb.SuggestRequest{Prefix: prefix, Limit: int64(limit)})
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return nil, fmt.Errorf("%w: %s", core.ErrBadArguments, status.Convert(err).Message())
+		}
+		return nil, err
+	}
+	completions := make([]core.Completion, 0, len(reply.Completions))
+	for _, c := range reply.Completions {
+		completions = append(completions, core.Completion{Text: c.Text, Kind: c.Kind, Frequency: int(c.Frequency)})
+	}
+	return completions, nil
 }
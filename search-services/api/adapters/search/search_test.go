@@ -5,11 +5,13 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yadro.com/course/api/core"
 	searchpb "yadro.com/course/proto/search"
 )
@@ -20,6 +22,16 @@ type fakeSearchClient struct {
 	searchErr      error
 	indexSearchRep *searchpb.SearchReply
 	indexSearchErr error
+	comicReply     *searchpb.ComicReply
+	comicErr       error
+	suggestReply   *searchpb.SuggestReply
+	suggestErr     error
+	onSearch       func(*searchpb.SearchRequest)
+	ftsReply       *searchpb.SearchReply
+}
+
+func (f fakeSearchClient) GetComic(ctx context.Context, in *searchpb.ComicRequest, opts ...grpc.CallOption) (*searchpb.ComicReply, error) {
+	return f.comicReply, f.comicErr
 }
 
 func (f fakeSearchClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
@@ -27,6 +39,9 @@ func (f fakeSearchClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...g
 }
 
 func (f fakeSearchClient) Search(ctx context.Context, in *searchpb.SearchRequest, opts ...grpc.CallOption) (*searchpb.SearchReply, error) {
+	if f.onSearch != nil {
+		f.onSearch(in)
+	}
 	if f.searchReply == nil {
 		return nil, f.searchErr
 	}
@@ -40,6 +55,17 @@ func (f fakeSearchClient) IndexSearch(ctx context.Context, in *searchpb.SearchRe
 	return f.indexSearchRep, f.indexSearchErr
 }
 
+func (f fakeSearchClient) FullTextSearch(ctx context.Context, in *searchpb.SearchRequest, opts ...grpc.CallOption) (*searchpb.SearchReply, error) {
+	if f.ftsReply == nil {
+		return nil, status.Error(codes.InvalidArgument, "fuzzy full-text search")
+	}
+	return f.ftsReply, nil
+}
+
+func (f fakeSearchClient) Suggest(ctx context.Context, in *searchpb.SuggestRequest, opts ...grpc.CallOption) (*searchpb.SuggestReply, error) {
+	return f.suggestReply, f.suggestErr
+}
+
 var _ searchpb.SearchClient = fakeSearchClient{}
 
 func newTestClient(f fakeSearchClient) *Client {
@@ -58,11 +84,11 @@ func TestClient_Search_Success(t *testing.T) {
 	}
 	c := newTestClient(fakeSearchClient{searchReply: reply})
 
-	res, err := c.Search(context.Background(), "linux", 1)
+	res, err := c.Search(context.Background(), core.SearchRequest{Phrase: "linux", Limit: 1})
 	if err != nil {
 		t.Fatalf("Search returned error: %v", err)
 	}
-	if len(res) != 1 || res[0].ID != 1 || res[0].URL != "u1" {
+	if len(res.Comics) != 1 || res.Comics[0].ID != 1 || res.Comics[0].URL != "u1" {
 		t.Fatalf("unexpected result: %#v", res)
 	}
 }
@@ -72,12 +98,26 @@ func TestClient_Search_NotFound(t *testing.T) {
 		searchErr: status.Error(codes.NotFound, "not found"),
 	})
 
-	_, err := c.Search(context.Background(), "linux", 1)
+	_, err := c.Search(context.Background(), core.SearchRequest{Phrase: "linux", Limit: 1})
 	if !errors.Is(err, core.ErrNotFound) {
 		t.Fatalf("expected ErrNotFound, got %v", err)
 	}
 }
 
+func TestClient_Search_BadQuery(t *testing.T) {
+	c := newTestClient(fakeSearchClient{
+		searchErr:      status.Error(codes.InvalidArgument, "unclosed quote"),
+		indexSearchErr: status.Error(codes.InvalidArgument, "unclosed quote"),
+	})
+
+	if _, err := c.Search(context.Background(), core.SearchRequest{Phrase: `"linux`, Limit: 1}); !errors.Is(err, core.ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments, got %v", err)
+	}
+	if _, err := c.SearchIndex(context.Background(), core.SearchRequest{Phrase: `"linux`, Limit: 1}); !errors.Is(err, core.ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments, got %v", err)
+	}
+}
+
 func TestClient_SearchIndex(t *testing.T) {
 	reply := &searchpb.SearchReply{
 		Comics: []*searchpb.Comics{
@@ -86,11 +126,138 @@ func TestClient_SearchIndex(t *testing.T) {
 	}
 	c := newTestClient(fakeSearchClient{indexSearchRep: reply})
 
-	res, err := c.SearchIndex(context.Background(), "linux", 1)
+	res, err := c.SearchIndex(context.Background(), core.SearchRequest{Phrase: "linux", Limit: 1})
 	if err != nil {
 		t.Fatalf("SearchIndex returned error: %v", err)
 	}
-	if len(res) != 1 || res[0].ID != 2 {
+	if len(res.Comics) != 1 || res.Comics[0].ID != 2 {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+}
+
+func TestClient_Search_Suggestions(t *testing.T) {
+	c := newTestClient(fakeSearchClient{searchReply: &searchpb.SearchReply{Suggestions: []string{"linux"}}})
+
+	res, err := c.Search(context.Background(), core.SearchRequest{Phrase: "linx", Limit: 1})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(res.Comics) != 0 || len(res.Suggestions) != 1 || res.Suggestions[0] != "linux" {
 		t.Fatalf("unexpected result: %#v", res)
 	}
 }
+
+func TestClient_Comic(t *testing.T) {
+	published := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	c := newTestClient(fakeSearchClient{comicReply: &searchpb.ComicReply{
+		Id: 1, Url: "u1", Title: "t", Published: timestamppb.New(published),
+	}})
+
+	comic, err := c.Comic(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Comic returned error: %v", err)
+	}
+	if comic.ID != 1 || comic.Title != "t" || !comic.Published.Equal(published) {
+		t.Fatalf("unexpected comic: %#v", comic)
+	}
+}
+
+func TestClient_Comic_Errors(t *testing.T) {
+	c := newTestClient(fakeSearchClient{comicErr: status.Error(codes.NotFound, "nope")})
+	if _, err := c.Comic(context.Background(), 1); !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	c = newTestClient(fakeSearchClient{comicErr: status.Error(codes.InvalidArgument, "bad")})
+	if _, err := c.Comic(context.Background(), 0); !errors.Is(err, core.ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments, got %v", err)
+	}
+}
+
+func TestClient_Suggest(t *testing.T) {
+	reply := &searchpb.SuggestReply{Completions: []*searchpb.Completion{
+		{Text: "linux", Kind: "word", Frequency: 4},
+	}}
+	c := newTestClient(fakeSearchClient{suggestReply: reply})
+
+	res, err := c.Suggest(context.Background(), "lin", 5)
+	if err != nil {
+		t.Fatalf("Suggest returned error: %v", err)
+	}
+	if len(res) != 1 || res[0] != (core.Completion{Text: "linux", Kind: "word", Frequency: 4}) {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+
+	c = newTestClient(fakeSearchClient{suggestErr: status.Error(codes.InvalidArgument, "empty prefix")})
+	if _, err := c.Suggest(context.Background(), " ", 5); !errors.Is(err, core.ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments, got %v", err)
+	}
+}
+
+func TestClient_SearchIndex_Snippets(t *testing.T) {
+	reply := &searchpb.SearchReply{Comics: []*searchpb.Comics{{
+		Id: 1,
+		Snippets: []*searchpb.Snippet{{
+			Field: "title", Text: "linux user", Highlights: []*searchpb.Highlight{{Start: 0, End: 5}},
+		}},
+	}}}
+	c := newTestClient(fakeSearchClient{indexSearchRep: reply})
+
+	res, err := c.SearchIndex(context.Background(), core.SearchRequest{Phrase: "linux", Limit: 1})
+	if err != nil {
+		t.Fatalf("SearchIndex returned error: %v", err)
+	}
+	snippets := res.Comics[0].Snippets
+	if len(snippets) != 1 || snippets[0].Field != "title" || snippets[0].Text != "linux user" ||
+		len(snippets[0].Highlights) != 1 || snippets[0].Highlights[0] != (core.Highlight{Start: 0, End: 5}) {
+		t.Fatalf("unexpected snippets: %#v", snippets)
+	}
+}
+
+func TestClient_Search_Pages(t *testing.T) {
+	reply := &searchpb.SearchReply{Comics: []*searchpb.Comics{{Id: 3}}, Total: 7, NextPageToken: "next"}
+	c := newTestClient(fakeSearchClient{searchReply: reply})
+
+	res, err := c.Search(context.Background(), core.SearchRequest{Phrase: "linux", Limit: 1, PageToken: "page"})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if res.Total != 7 || res.NextPageToken != "next" || len(res.Comics) != 1 {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+}
+
+func TestClient_Search_Filter(t *testing.T) {
+	var got *searchpb.SearchRequest
+	c := newTestClient(fakeSearchClient{
+		searchReply: &searchpb.SearchReply{},
+		onSearch:    func(in *searchpb.SearchRequest) { got = in },
+	})
+
+	from := time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := c.Search(context.Background(), core.SearchRequest{Phrase: "linux", From: from, MinID: 10, MaxID: 20})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if !got.From.AsTime().Equal(from) || got.To != nil || got.MinId != 10 || got.MaxId != 20 {
+		t.Fatalf("unexpected request: %v", got)
+	}
+}
+
+func TestClient_FullTextSearch(t *testing.T) {
+	reply := &searchpb.SearchReply{Comics: []*searchpb.Comics{{Id: 5, Score: 0.2}}, Total: 1}
+	c := newTestClient(fakeSearchClient{ftsReply: reply})
+
+	res, err := c.FullTextSearch(context.Background(), core.SearchRequest{Phrase: "linux", Limit: 10})
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	if res.Total != 1 || len(res.Comics) != 1 || res.Comics[0].ID != 5 {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+
+	c = newTestClient(fakeSearchClient{})
+	if _, err := c.FullTextSearch(context.Background(), core.SearchRequest{Phrase: "linux", Fuzzy: true}); !errors.Is(err, core.ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package update
 
 import (
 	"context"
+	"io"
 	"log/slog"
 
 	"google.golang.org/grpc"
@@ -45,14 +46,53 @@ func (c Client) Status(ctx context.Context) (core.UpdateStatus, error) {
 	if err != nil {
 		return core.StatusUpdateUnknown, err
 	}
+	return toStatus(reply.Status), nil
+}
 
-	switch reply.Status {
+func toStatus(status updatepb.Status) core.UpdateStatus {
+	switch status {
 	case updatepb.Status_STATUS_IDLE:
-		return core.StatusUpdateIdle, nil
+		return core.StatusUpdateIdle
 	case updatepb.Status_STATUS_RUNNING:
-		return core.StatusUpdateRunning, nil
+		return core.StatusUpdateRunning
 	}
-	return core.StatusUpdateUnknown, nil
+	return core.StatusUpdateUnknown
+}
+
+// WatchUpdate streams update progress until ctx is done or the stream breaks.
+func (c Client) WatchUpdate(ctx context.Context) (<-chan core.UpdateProgress, error) {
+	stream, err := c.client.WatchUpdate(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan core.UpdateProgress)
+	go func() {
+		defer close(out)
+		for {
+			reply, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && status.Code(err) != codes.Canceled {
+					c.log.Error("update progress stream failed", "error", err)
+				}
+				return
+			}
+			progress := core.UpdateProgress{
+				Status:     toStatus(reply.Status),
+				Total:      int(reply.Total),
+				Fetched:    int(reply.Fetched),
+				Failed:     int(reply.Failed),
+				Normalized: int(reply.Normalized),
+				Stored:     int(reply.Stored),
+				ETA:        reply.Eta.AsDuration(),
+			}
+			select {
+			case out <- progress:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
 }
 
 func (c Client) Stats(ctx context.Context) (core.UpdateStats, error) {
@@ -69,14 +109,63 @@ func (c Client) Stats(ctx context.Context) (core.UpdateStats, error) {
 	}, nil
 }
 
-func (c Client) Update(ctx context.Context) error {
-	_, err := c.client.Update(ctx, nil)
-	if status.Code(err) == codes.AlreadyExists {
-		return core.ErrAlreadyExists
+func (c Client) Update(ctx context.Context, user string) (core.UpdateJob, error) {
+	reply, err := c.client.Update(ctx, &updatepb.UpdateRequest{TriggeredBy: user})
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return core.UpdateJob{}, core.ErrAlreadyExists
+		}
+		return core.UpdateJob{}, err
+	}
+	return toJob(reply), nil
+}
+
+func (c Client) Job(ctx context.Context, id int64) (core.UpdateJob, error) {
+	reply, err := c.client.GetJob(ctx, &updatepb.JobRequest{Id: id})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return core.UpdateJob{}, core.ErrNotFound
+		}
+		return core.UpdateJob{}, err
+	}
+	return toJob(reply), nil
+}
+
+func (c Client) CancelJob(ctx context.Context, id int64) error {
+	_, err := c.client.CancelJob(ctx, &updatepb.JobRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		return core.ErrNotFound
 	}
 	return err
 }
 
+func toJob(reply *updatepb.Job) core.UpdateJob {
+	job := core.UpdateJob{
+		ID:          reply.Id,
+		Status:      core.JobStatusUnknown,
+		TriggeredBy: reply.TriggeredBy,
+		StartedAt:   reply.StartedAt.AsTime(),
+		Fetched:     int(reply.Fetched),
+		Failed:      int(reply.Failed),
+		Stored:      int(reply.Stored),
+		Error:       reply.Error,
+	}
+	if reply.FinishedAt != nil {
+		job.FinishedAt = reply.FinishedAt.AsTime()
+	}
+	switch reply.Status {
+	case updatepb.JobStatus_JOB_STATUS_RUNNING:
+		job.Status = core.JobStatusRunning
+	case updatepb.JobStatus_JOB_STATUS_DONE:
+		job.Status = core.JobStatusDone
+	case updatepb.JobStatus_JOB_STATUS_FAILED:
+		job.Status = core.JobStatusFailed
+	case updatepb.JobStatus_JOB_STATUS_CANCELED:
+		job.Status = core.JobStatusCanceled
+	}
+	return job
+}
+
 func (c Client) Drop(ctx context.Context) error {
 	_, err := c.client.Drop(ctx, nil)
 	return err
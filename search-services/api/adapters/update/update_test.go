@@ -3,13 +3,17 @@ package update
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yadro.com/course/api/core"
 	updatepb "yadro.com/course/proto/update"
 )
@@ -22,6 +26,10 @@ type fakeUpdateClient struct {
 	statsErr  error
 	updateErr error
 	dropErr   error
+	progress  []*updatepb.UpdateProgress
+	watchErr  error
+	jobRep    *updatepb.Job
+	jobErr    error
 }
 
 func (f fakeUpdateClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
@@ -36,8 +44,45 @@ func (f fakeUpdateClient) Stats(ctx context.Context, in *emptypb.Empty, opts ...
 	return f.statsRep, f.statsErr
 }
 
-func (f fakeUpdateClient) Update(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	return &emptypb.Empty{}, f.updateErr
+func (f fakeUpdateClient) Update(ctx context.Context, in *updatepb.UpdateRequest, opts ...grpc.CallOption) (*updatepb.Job, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	return &updatepb.Job{
+		Id:          1,
+		Status:      updatepb.JobStatus_JOB_STATUS_RUNNING,
+		TriggeredBy: in.TriggeredBy,
+		StartedAt:   timestamppb.Now(),
+	}, nil
+}
+
+func (f fakeUpdateClient) GetJob(ctx context.Context, in *updatepb.JobRequest, opts ...grpc.CallOption) (*updatepb.Job, error) {
+	return f.jobRep, f.jobErr
+}
+
+func (f fakeUpdateClient) CancelJob(ctx context.Context, in *updatepb.JobRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, f.jobErr
+}
+
+func (f fakeUpdateClient) WatchUpdate(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[updatepb.UpdateProgress], error) {
+	if f.watchErr != nil {
+		return nil, f.watchErr
+	}
+	return &fakeProgressStream{progress: f.progress}, nil
+}
+
+type fakeProgressStream struct {
+	grpc.ServerStreamingClient[updatepb.UpdateProgress]
+	progress []*updatepb.UpdateProgress
+}
+
+func (f *fakeProgressStream) Recv() (*updatepb.UpdateProgress, error) {
+	if len(f.progress) == 0 {
+		return nil, io.EOF
+	}
+	p := f.progress[0]
+	f.progress = f.progress[1:]
+	return p, nil
 }
 
 func (f fakeUpdateClient) Drop(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
@@ -103,7 +148,7 @@ func TestClient_Update_AlreadyExists(t *testing.T) {
 		updateErr: status.Error(codes.AlreadyExists, "already"),
 	})
 
-	err := c.Update(context.Background())
+	_, err := c.Update(context.Background(), "admin")
 	if !errors.Is(err, core.ErrAlreadyExists) {
 		t.Fatalf("expected ErrAlreadyExists, got %v", err)
 	}
@@ -114,7 +159,7 @@ func TestClient_Update_OtherError(t *testing.T) {
 		updateErr: errors.New("err"),
 	})
 
-	err := c.Update(context.Background())
+	_, err := c.Update(context.Background(), "admin")
 	if err == nil || errors.Is(err, core.ErrAlreadyExists) {
 		t.Fatalf("expected passthrough error, got %v", err)
 	}
@@ -131,3 +176,85 @@ func TestClient_PingAndDrop(t *testing.T) {
 	}
 }
 
+func TestClient_Update_Job(t *testing.T) {
+	c := newUpdateTestClient(fakeUpdateClient{})
+
+	job, err := c.Update(context.Background(), "admin")
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if job.ID != 1 || job.Status != core.JobStatusRunning || job.TriggeredBy != "admin" {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+	if !job.FinishedAt.IsZero() {
+		t.Fatalf("expected zero finish time, got %v", job.FinishedAt)
+	}
+}
+
+func TestClient_Job_Mapping(t *testing.T) {
+	c := newUpdateTestClient(fakeUpdateClient{
+		jobRep: &updatepb.Job{
+			Id:         2,
+			Status:     updatepb.JobStatus_JOB_STATUS_FAILED,
+			StartedAt:  timestamppb.Now(),
+			FinishedAt: timestamppb.Now(),
+			Failed:     3,
+			Error:      "boom",
+		},
+	})
+
+	job, err := c.Job(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Job returned error: %v", err)
+	}
+	if job.Status != core.JobStatusFailed || job.Failed != 3 || job.Error != "boom" || job.FinishedAt.IsZero() {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+}
+
+func TestClient_Jobs_NotFound(t *testing.T) {
+	c := newUpdateTestClient(fakeUpdateClient{
+		jobErr: status.Error(codes.NotFound, "no job"),
+	})
+
+	if _, err := c.Job(context.Background(), 2); !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := c.CancelJob(context.Background(), 2); !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_WatchUpdate(t *testing.T) {
+	c := newUpdateTestClient(fakeUpdateClient{
+		progress: []*updatepb.UpdateProgress{
+			{Status: updatepb.Status_STATUS_RUNNING, Total: 10, Stored: 4, Eta: durationpb.New(time.Minute)},
+			{Status: updatepb.Status_STATUS_IDLE, Total: 10, Stored: 10},
+		},
+	})
+
+	updates, err := c.WatchUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("WatchUpdate returned error: %v", err)
+	}
+	var got []core.UpdateProgress
+	for p := range updates {
+		got = append(got, p)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(got))
+	}
+	if got[0].Status != core.StatusUpdateRunning || got[0].Stored != 4 || got[0].ETA != time.Minute {
+		t.Fatalf("unexpected progress: %#v", got[0])
+	}
+	if got[1].Status != core.StatusUpdateIdle {
+		t.Fatalf("expected idle, got %v", got[1].Status)
+	}
+}
+
+func TestClient_WatchUpdate_Error(t *testing.T) {
+	c := newUpdateTestClient(fakeUpdateClient{watchErr: errors.New("err")})
+	if _, err := c.WatchUpdate(context.Background()); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
@@ -28,6 +28,10 @@ func (f fakeWordsClient) Norm(ctx context.Context, in *wordspb.WordsRequest, opt
 	return f.normRep, f.normErr
 }
 
+func (f fakeWordsClient) NormDetailed(ctx context.Context, in *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.TokensReply, error) {
+	return nil, nil
+}
+
 func newWordsTestClient(f fakeWordsClient) *Client {
 	logger := slog.Default()
 	return &Client{
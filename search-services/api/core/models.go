@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 type UpdateStatus string
 
 const (
@@ -15,8 +17,98 @@ type UpdateStats struct {
 	ComicsTotal   int
 }
 
+type UpdateProgress struct {
+	Status     UpdateStatus
+	Total      int
+	Fetched    int
+	Failed     int
+	Normalized int
+	Stored     int
+	ETA        time.Duration
+}
+
+type JobStatus string
+
+const (
+	JobStatusUnknown  JobStatus = "unknown"
+	JobStatusRunning  JobStatus = "running"
+	JobStatusDone     JobStatus = "done"
+	JobStatusFailed   JobStatus = "failed"
+	JobStatusCanceled JobStatus = "canceled"
+)
+
+type UpdateJob struct {
+	ID          int64
+	Status      JobStatus
+	TriggeredBy string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Fetched     int
+	Failed      int
+	Stored      int
+	Error       string
+}
+
+// Highlight is a byte range of a matched word in the snippet text
+type Highlight struct {
+	Start int
+	End   int
+}
+
+// Snippet is a fragment of the title, alt text or transcript with matched words
+type Snippet struct {
+	Field      string
+	Text       string
+	Highlights []Highlight
+}
+
 type Comics struct {
-	ID    int
-	URL   string
-	Score int
+	ID       int
+	URL      string
+	Score    float64
+	Snippets []Snippet
+}
+
+// SearchRequest is a search phrase, filters and the page of results.
+// Comics are published in [From, To) and have IDs in [MinID, MaxID], zero values
+// do not restrict. PageToken is NextPageToken of the previous page.
+type SearchRequest struct {
+	Phrase    string
+	From      time.Time
+	To        time.Time
+	MinID     int
+	MaxID     int
+	Limit     int
+	Offset    int
+	PageToken string
+	Fuzzy     bool
+}
+
+// SearchResult holds a page of found comics and the number of all matches
+// or, when nothing is found, corrected phrases
+type SearchResult struct {
+	Comics        []Comics
+	Total         int
+	NextPageToken string
+	Suggestions   []string
+}
+
+// Completion is an indexed word or comics title, Kind is "word" or "title"
+type Completion struct {
+	Text      string
+	Kind      string
+	Frequency int
+}
+
+type ComicInfo struct {
+	ID         int
+	URL        string
+	Title      string
+	SafeTitle  string
+	Alt        string
+	Transcript string
+	Link       string
+	News       string
+	Published  time.Time
+	Words      []string
 }
@@ -15,13 +15,19 @@ type Authenticator interface {
 }
 
 type Updater interface {
-	Update(context.Context) error
+	Update(ctx context.Context, user string) (UpdateJob, error)
+	Job(ctx context.Context, id int64) (UpdateJob, error)
+	CancelJob(ctx context.Context, id int64) error
 	Stats(context.Context) (UpdateStats, error)
 	Status(context.Context) (UpdateStatus, error)
+	WatchUpdate(context.Context) (<-chan UpdateProgress, error)
 	Drop(context.Context) error
 }
 
 type Searcher interface {
-	Search(context.Context, string, int) ([]Comics, error)
-	SearchIndex(context.Context, string, int) ([]Comics, error)
+	Search(ctx context.Context, req SearchRequest) (SearchResult, error)
+	SearchIndex(ctx context.Context, req SearchRequest) (SearchResult, error)
+	FullTextSearch(ctx context.Context, req SearchRequest) (SearchResult, error)
+	Comic(context.Context, int) (ComicInfo, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]Completion, error)
 }
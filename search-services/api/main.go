@@ -94,15 +94,32 @@ func mustSetupHttpServer(log *slog.Logger, cfg config.Config, updateClient *upda
 	mux.Handle("POST /api/login",
 		rest.NewLoginHandler(log, aaaService))
 	// search client
+	// index search has the same rate limit in both endpoints
+	indexSearch := middleware.Rate(rest.NewIndexSearchHandler(log, searchClient), cfg.SearchRate)
 	mux.Handle("GET /api/search",
-		middleware.Concurrency(rest.NewSearchHandler(log, searchClient), cfg.SearchConcurrency))
-	mux.Handle("GET /api/isearch",
-		middleware.Rate(rest.NewIndexSearchHandler(log, searchClient), cfg.SearchRate),
+		middleware.Mode(
+			middleware.Concurrency(rest.NewSearchHandler(log, searchClient), cfg.SearchConcurrency),
+			map[string]http.HandlerFunc{"index": indexSearch},
+		),
 	)
+	mux.Handle("GET /api/isearch", indexSearch)
+	mux.Handle("GET /api/suggest",
+		middleware.Rate(rest.NewSuggestHandler(log, searchClient), cfg.SuggestRate),
+	)
+	mux.Handle("GET /api/comics/{id}",
+		rest.NewComicHandler(log, searchClient))
 	// update client
 	mux.Handle("POST /api/db/update",
 		middleware.Auth(rest.NewUpdateHandler(log, updateClient), aaaService),
 	)
+	mux.Handle("GET /api/db/jobs/{id}",
+		middleware.Auth(rest.NewJobHandler(log, updateClient), aaaService),
+	)
+	mux.Handle("DELETE /api/db/jobs/{id}",
+		middleware.Auth(rest.NewCancelJobHandler(log, updateClient), aaaService),
+	)
+	mux.Handle("GET /api/db/update/events",
+		rest.NewUpdateEventsHandler(log, updateClient))
 	mux.Handle("GET /api/db/stats",
 		rest.NewUpdateStatsHandler(log, updateClient))
 	mux.Handle("GET /api/db/status",
@@ -0,0 +1,59 @@
+// Package events описывает формат сообщений об изменениях базы комиксов,
+// которые update публикует в NATS, а search применяет к индексу.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// SchemaVersion - текущая версия формата сообщения
+const SchemaVersion = 1
+
+// streamMaxAge - сколько поток хранит события для отставших подписчиков
+const streamMaxAge = 7 * 24 * time.Hour
+
+var ErrUnsupportedVersion = errors.New("unsupported event schema version")
+
+// Message - событие об изменении базы.
+// Sequence - номер события в outbox update сервиса, он только возрастает.
+type Message struct {
+	Version  int    `json:"version"`
+	Sequence int64  `json:"sequence"`
+	Type     string `json:"type"`
+	JobID    int64  `json:"job_id,omitempty"`
+	Added    []int  `json:"added,omitempty"`
+	Changed  []int  `json:"changed,omitempty"`
+	Removed  []int  `json:"removed,omitempty"`
+}
+
+func Marshal(msg Message) ([]byte, error) {
+	msg.Version = SchemaVersion
+	return json.Marshal(msg)
+}
+
+func Unmarshal(data []byte) (Message, error) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Message{}, fmt.Errorf("failed to decode event: %w", err)
+	}
+	if msg.Version != SchemaVersion {
+		return Message{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, msg.Version)
+	}
+	return msg, nil
+}
+
+// StreamConfig описывает поток JetStream, в котором хранятся события.
+// Поток создают и update, и search, поэтому порядок их запуска не важен.
+func StreamConfig(name, subject string) jetstream.StreamConfig {
+	return jetstream.StreamConfig{
+		Name:     name,
+		Subjects: []string{subject},
+		Storage:  jetstream.FileStorage,
+		MaxAge:   streamMaxAge,
+	}
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	data, err := Marshal(Message{Sequence: 7, Type: "update", JobID: 3, Added: []int{1, 2}, Removed: []int{5}})
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	msg, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if msg.Version != SchemaVersion || msg.Sequence != 7 || msg.Type != "update" || msg.JobID != 3 {
+		t.Fatalf("unexpected message: %#v", msg)
+	}
+	if !slices.Equal(msg.Added, []int{1, 2}) || len(msg.Changed) != 0 || !slices.Equal(msg.Removed, []int{5}) {
+		t.Fatalf("unexpected ids: %#v", msg)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	if _, err := Unmarshal([]byte("update")); err == nil {
+		t.Fatalf("expected error for legacy payload")
+	}
+	if _, err := Unmarshal([]byte(`{"version":2,"type":"update"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
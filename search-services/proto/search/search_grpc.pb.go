@@ -20,9 +20,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Search_Ping_FullMethodName        = "/search.Search/Ping"
-	Search_Search_FullMethodName      = "/search.Search/Search"
-	Search_IndexSearch_FullMethodName = "/search.Search/IndexSearch"
+	Search_Ping_FullMethodName           = "/search.Search/Ping"
+	Search_Search_FullMethodName         = "/search.Search/Search"
+	Search_IndexSearch_FullMethodName    = "/search.Search/IndexSearch"
+	Search_FullTextSearch_FullMethodName = "/search.Search/FullTextSearch"
+	Search_GetComic_FullMethodName       = "/search.Search/GetComic"
+	Search_Suggest_FullMethodName        = "/search.Search/Suggest"
 )
 
 // SearchClient is the client API for Search service.
@@ -32,6 +35,10 @@ type SearchClient interface {
 	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
 	IndexSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	// ranking of the Postgres full-text search, a baseline for the other searches
+	FullTextSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error)
+	GetComic(ctx context.Context, in *ComicRequest, opts ...grpc.CallOption) (*ComicReply, error)
+	Suggest(ctx context.Context, in *SuggestRequest, opts ...grpc.CallOption) (*SuggestReply, error)
 }
 
 type searchClient struct {
@@ -72,6 +79,36 @@ func (c *searchClient) IndexSearch(ctx context.Context, in *SearchRequest, opts
 	return out, nil
 }
 
+func (c *searchClient) FullTextSearch(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReply)
+	err := c.cc.Invoke(ctx, Search_FullTextSearch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) GetComic(ctx context.Context, in *ComicRequest, opts ...grpc.CallOption) (*ComicReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComicReply)
+	err := c.cc.Invoke(ctx, Search_GetComic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchClient) Suggest(ctx context.Context, in *SuggestRequest, opts ...grpc.CallOption) (*SuggestReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuggestReply)
+	err := c.cc.Invoke(ctx, Search_Suggest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SearchServer is the server API for Search service.
 // All implementations must embed UnimplementedSearchServer
 // for forward compatibility.
@@ -79,6 +116,10 @@ type SearchServer interface {
 	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	Search(context.Context, *SearchRequest) (*SearchReply, error)
 	IndexSearch(context.Context, *SearchRequest) (*SearchReply, error)
+	// ranking of the Postgres full-text search, a baseline for the other searches
+	FullTextSearch(context.Context, *SearchRequest) (*SearchReply, error)
+	GetComic(context.Context, *ComicRequest) (*ComicReply, error)
+	Suggest(context.Context, *SuggestRequest) (*SuggestReply, error)
 	mustEmbedUnimplementedSearchServer()
 }
 
@@ -98,6 +139,15 @@ func (UnimplementedSearchServer) Search(context.Context, *SearchRequest) (*Searc
 func (UnimplementedSearchServer) IndexSearch(context.Context, *SearchRequest) (*SearchReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IndexSearch not implemented")
 }
+func (UnimplementedSearchServer) FullTextSearch(context.Context, *SearchRequest) (*SearchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FullTextSearch not implemented")
+}
+func (UnimplementedSearchServer) GetComic(context.Context, *ComicRequest) (*ComicReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetComic not implemented")
+}
+func (UnimplementedSearchServer) Suggest(context.Context, *SuggestRequest) (*SuggestReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Suggest not implemented")
+}
 func (UnimplementedSearchServer) mustEmbedUnimplementedSearchServer() {}
 func (UnimplementedSearchServer) testEmbeddedByValue()                {}
 
@@ -173,6 +223,60 @@ func _Search_IndexSearch_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Search_FullTextSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).FullTextSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_FullTextSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).FullTextSearch(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_GetComic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).GetComic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_GetComic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).GetComic(ctx, req.(*ComicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Search_Suggest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchServer).Suggest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Search_Suggest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchServer).Suggest(ctx, req.(*SuggestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Search_ServiceDesc is the grpc.ServiceDesc for Search service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -192,6 +296,18 @@ var Search_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "IndexSearch",
 			Handler:    _Search_IndexSearch_Handler,
 		},
+		{
+			MethodName: "FullTextSearch",
+			Handler:    _Search_FullTextSearch_Handler,
+		},
+		{
+			MethodName: "GetComic",
+			Handler:    _Search_GetComic_Handler,
+		},
+		{
+			MethodName: "Suggest",
+			Handler:    _Search_Suggest_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/search/search.proto",
@@ -0,0 +1,359 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"yadro.com/course/search/core"
+	updatedb "yadro.com/course/update/adapters/db"
+	updatecore "yadro.com/course/update/core"
+)
+
+// testID - первый номер тестовых комиксов, они удаляются до и после теста
+const testID = 9_000_000
+
+// newPostgres подключается к локальному Postgres по адресу из TEST_DB_ADDRESS,
+// применяет миграции и сохраняет комиксы через адаптер сервиса обновления
+func newPostgres(t *testing.T, comics ...updatecore.Comics) *DB {
+	t.Helper()
+	address := os.Getenv("TEST_DB_ADDRESS")
+	if address == "" {
+		t.Skip("TEST_DB_ADDRESS is not set")
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	updater, err := updatedb.New(log, address)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = updater.Close() })
+	if err := updater.Migrate(); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+
+	db, err := New(log, address)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	// события о тестовых комиксах удаляются из outbox, чтобы их не опубликовал relay
+	deleteEvents := func() {
+		if _, err := db.conn.ExecContext(ctx, testEventsQuery, testID); err != nil {
+			t.Fatalf("failed to delete test events: %v", err)
+		}
+	}
+	cleanup := func() {
+		if _, err := db.conn.ExecContext(ctx, `DELETE FROM comics WHERE id >= $1`, testID); err != nil {
+			t.Fatalf("failed to delete test comics: %v", err)
+		}
+		deleteEvents()
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	for _, c := range comics {
+		event := updatecore.Event{Type: updatecore.EventTypeUpdating, Added: []int{c.ID}}
+		if err := updater.Add(ctx, c, event); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+	deleteEvents()
+	return db
+}
+
+// testEventsQuery удаляет из outbox события, в которых есть тестовые комиксы
+const testEventsQuery = `DELETE FROM outbox WHERE EXISTS (
+	SELECT 1 FROM jsonb_array_elements_text(
+		COALESCE(payload->'added', '[]') || COALESCE(payload->'changed', '[]') || COALESCE(payload->'removed', '[]')
+	) AS id
+	WHERE id::int >= $1)`
+
+func TestPostgres_Search(t *testing.T) {
+	term := func(field, stem string, tf int) updatecore.Term {
+		positions := make([]int, tf)
+		for i := range positions {
+			positions[i] = i
+		}
+		return updatecore.Term{Field: field, Term: stem, Surface: stem, TF: tf, Positions: positions}
+	}
+	db := newPostgres(t,
+		updatecore.Comics{ID: testID, URL: "u1", Words: []string{"pgtux", "pgkernel"},
+			Terms: []updatecore.Term{term("title", "pgtux", 1), term("alt", "pgkernel", 3)}},
+		updatecore.Comics{ID: testID + 1, URL: "u2", Words: []string{"pgtux"},
+			Terms: []updatecore.Term{term("title", "pgtux", 2), term("alt", "pgtux", 1)}},
+		updatecore.Comics{ID: testID + 2, URL: "u3", Words: []string{"pgkernel", "pgfan"},
+			Terms: []updatecore.Term{term("transcript", "pgkernel", 1), term("transcript", "pgfan", 5)}},
+		// комикс, сохранённый без терминов, ранжируется по списку слов
+		updatecore.Comics{ID: testID + 3, URL: "u4", Words: []string{"pgkernel"}},
+	)
+	ctx := context.Background()
+	scorer := core.BM25{K1: 1.2, B: 0.75}
+	query := core.Query{Words: []string{"pgtux", "pgkernel"}, Weights: map[string]float64{"pgkernel": 0.5}}
+
+	ranked, err := db.Search(ctx, query, scorer, core.CorpusStats{})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(ranked) != 4 {
+		t.Fatalf("expected 4 comics, got %v", ranked)
+	}
+
+	// ранжирование совпадает с core.BM25 по статистике найденных комиксов
+	ids := make([]int, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.ID
+	}
+	comics, err := db.GetComicsByIDs(ctx, ids...)
+	if err != nil {
+		t.Fatalf("GetComicsByIDs returned error: %v", err)
+	}
+	stats := core.NewCorpusStats()
+	for _, c := range comics {
+		tf, _ := c.Frequencies()
+		stats.Add(tf)
+	}
+	for i, c := range comics {
+		if c.ID != ranked[i].ID {
+			t.Fatalf("expected comics in the order of ids %v, got %d at %d", ids, c.ID, i)
+		}
+		want := query.Score(scorer, stats, core.NewDocument(c))
+		if math.Abs(want-ranked[i].Score) > 1e-9 {
+			t.Fatalf("comics %d: expected score %f, got %f", c.ID, want, ranked[i].Score)
+		}
+	}
+	// комиксы с точными совпадениями идут выше найденных только по нечётким
+	exact := make(map[int]bool, len(comics))
+	for _, c := range comics {
+		exact[c.ID] = query.Exact(core.NewDocument(c))
+	}
+	if !slices.IsSortedFunc(ranked, func(a, b core.Ranked) int {
+		if exact[a.ID] != exact[b.ID] {
+			if exact[a.ID] {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Score, a.Score)
+	}) {
+		t.Fatalf("expected exact comics first and then sorted by score, got %v", ranked)
+	}
+
+	query = core.Query{Words: []string{"pgkernel"}, Filter: core.Filter{MinID: testID + 2}}
+	filtered, err := db.Search(ctx, query, scorer, core.CorpusStats{})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(filtered) != 2 || filtered[0].ID < testID+2 || filtered[1].ID < testID+2 {
+		t.Fatalf("expected comics filtered by id, got %v", filtered)
+	}
+}
+
+func TestPostgres_GetComicsByIDs_Published(t *testing.T) {
+	published := time.Date(2008, 3, 5, 0, 0, 0, 0, time.UTC)
+	db := newPostgres(t, updatecore.Comics{ID: testID, URL: "u1", Words: []string{"pgdate"}, Published: published})
+
+	// индекс обновляется по событиям через GetComicsByIDs и фильтрует по дате
+	comics, err := db.GetComicsByIDs(context.Background(), testID)
+	if err != nil {
+		t.Fatalf("GetComicsByIDs returned error: %v", err)
+	}
+	if len(comics) != 1 || !comics[0].Published.Equal(published) {
+		t.Fatalf("expected comics published %v, got %+v", published, comics)
+	}
+}
+
+func TestPostgres_AddReplacesStaleComics(t *testing.T) {
+	published := time.Date(2008, 3, 5, 0, 0, 0, 0, time.UTC)
+	db := newPostgres(t,
+		updatecore.Comics{ID: testID, URL: "u1", Words: []string{"pgold"}},
+		updatecore.Comics{ID: testID + 1, URL: "u2", Words: []string{"pgold"}, Published: published},
+	)
+	ctx := context.Background()
+
+	updater, err := updatedb.New(slog.New(slog.NewTextHandler(io.Discard, nil)), os.Getenv("TEST_DB_ADDRESS"))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() { _ = updater.Close() }()
+
+	// комиксы без даты публикации или без терминов сохранены прежними версиями
+	stale, err := updater.StaleIDs(ctx)
+	if err != nil || !slices.Contains(stale, testID) || !slices.Contains(stale, testID+1) {
+		t.Fatalf("expected comics %d and %d to be stale, got %v, %v", testID, testID+1, stale, err)
+	}
+
+	comics := updatecore.Comics{ID: testID, URL: "u1", Words: []string{"pgnew"}, Published: published}
+	event := updatecore.Event{Type: updatecore.EventTypeUpdating, Changed: []int{testID}}
+	if err := updater.Add(ctx, comics, event); err != nil {
+		t.Fatalf("Add of stale comics returned error: %v", err)
+	}
+	found, err := db.GetComicsByIDs(ctx, testID)
+	if err != nil {
+		t.Fatalf("GetComicsByIDs returned error: %v", err)
+	}
+	if len(found) != 1 || !found[0].Published.Equal(published) || !slices.Equal(found[0].Words, []string{"pgnew"}) {
+		t.Fatalf("expected comics to be replaced, got %+v", found)
+	}
+}
+
+func TestPostgres_GetComicsTerms(t *testing.T) {
+	db := newPostgres(t,
+		updatecore.Comics{ID: testID, URL: "u1", Words: []string{"pgcat", "pgdog", "pgfox"}, Terms: []updatecore.Term{
+			{Field: "title", Term: "pgcat", Surface: "pgcat", TF: 1, Positions: []int{0}},
+			{Field: "alt", Term: "pgdog", Surface: "pgdog", TF: 2, Positions: []int{3, 1}},
+			{Field: "alt", Term: "pgfox", Surface: "pgfox", TF: 1, Positions: []int{2}},
+		}},
+		updatecore.Comics{ID: testID + 1, URL: "u2", Words: []string{"pgcat"}},
+	)
+
+	comics, err := db.GetComicsTerms(context.Background(), []string{"pgcat", "pgdog"}, testID, testID+1)
+	if err != nil {
+		t.Fatalf("GetComicsTerms returned error: %v", err)
+	}
+	slices.SortFunc(comics, func(a, b core.Comics) int { return a.ID - b.ID })
+	if len(comics) != 2 || !slices.Equal(comics[0].Words, []string{"pgcat", "pgdog"}) ||
+		!slices.Equal(comics[1].Words, []string{"pgcat"}) {
+		t.Fatalf("expected only words of the stems, got %+v", comics)
+	}
+	positions := core.NewPositions(comics[0].Terms)
+	if len(comics[0].Terms) != 2 || !slices.Equal(positions["pgdog"]["alt"], []int{1, 3}) || comics[1].Terms != nil {
+		t.Fatalf("expected only terms of the stems, got %+v", comics)
+	}
+}
+
+func TestPostgres_Search_UsesWordsIndex(t *testing.T) {
+	db := newPostgres(t)
+	ctx := context.Background()
+
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	// на маленькой таблице планировщик предпочитает полный просмотр
+	if _, err := tx.ExecContext(ctx, `SET LOCAL enable_seqscan = off`); err != nil {
+		t.Fatalf("failed to disable sequential scan: %v", err)
+	}
+
+	query, args := searchQuery(core.Query{Words: []string{"linux"}}, core.BM25{K1: 1.2, B: 0.75}, core.CorpusStats{})
+	var plan []string
+	if err := tx.SelectContext(ctx, &plan, "EXPLAIN "+query, args...); err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	if text := strings.Join(plan, "\n"); !strings.Contains(text, "comics_words_idx") {
+		t.Fatalf("expected search to use comics_words_idx, got plan:\n%s", text)
+	}
+}
+
+func TestPostgres_FullTextSearch(t *testing.T) {
+	db := newPostgres(t,
+		updatecore.Comics{ID: testID, URL: "u1", Title: "Pgzebra Kernels", Alt: "the pgzebra kernel panics"},
+		updatecore.Comics{ID: testID + 1, URL: "u2", Title: "Nothing", Transcript: "a pgzebra\nruns"},
+		updatecore.Comics{ID: testID + 2, URL: "u3", Title: "Other", Alt: "no match here"},
+	)
+	ctx := context.Background()
+
+	comics, total, err := db.FullTextSearch(ctx, "pgzebra kernel", core.Filter{}, 10, 0)
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	if total != 1 || len(comics) != 1 || comics[0].ID != testID {
+		t.Fatalf("expected comics with both words, got %+v of %d", comics, total)
+	}
+	want := []core.Snippet{
+		{Field: core.FieldTitle, Text: "Pgzebra Kernels", Highlights: []core.Highlight{{Start: 0, End: 7}, {Start: 8, End: 15}}},
+		{Field: core.FieldAlt, Text: "the pgzebra kernel panics", Highlights: []core.Highlight{{Start: 4, End: 11}, {Start: 12, End: 18}}},
+	}
+	if !slices.EqualFunc(comics[0].Snippets, want, func(a, b core.Snippet) bool {
+		return a.Field == b.Field && a.Text == b.Text && slices.Equal(a.Highlights, b.Highlights)
+	}) {
+		t.Fatalf("expected snippets %+v, got %+v", want, comics[0].Snippets)
+	}
+
+	comics, total, err = db.FullTextSearch(ctx, "pgzebra", core.Filter{}, 1, 0)
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	// совпадения в заголовке весят больше, чем в транскрипте
+	if total != 2 || len(comics) != 1 || comics[0].ID != testID || comics[0].Score <= 0 {
+		t.Fatalf("expected the best of 2 comics, got %+v of %d", comics, total)
+	}
+
+	comics, total, err = db.FullTextSearch(ctx, "pgzebra", core.Filter{MinID: testID + 1}, 10, 5)
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	if total != 1 || len(comics) != 0 {
+		t.Fatalf("expected empty page past the end with total, got %+v of %d", comics, total)
+	}
+}
+
+func TestPostgres_FullTextSearch_UsesIndex(t *testing.T) {
+	db := newPostgres(t)
+	ctx := context.Background()
+
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `SET LOCAL enable_seqscan = off`); err != nil {
+		t.Fatalf("failed to disable sequential scan: %v", err)
+	}
+
+	query := fmt.Sprintf(ftsQuery, "c.fts @@ q.query")
+	var plan []string
+	if err := tx.SelectContext(ctx, &plan, "EXPLAIN "+query, "linux", headlineOptions, 10, 0); err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	if text := strings.Join(plan, "\n"); !strings.Contains(text, "comics_fts_idx") {
+		t.Fatalf("expected full-text search to use comics_fts_idx, got plan:\n%s", text)
+	}
+}
+
+func TestPostgres_WordsRoundTrip(t *testing.T) {
+	seed := time.Now().UnixNano()
+	r := rand.New(rand.NewSource(seed))
+	comics := make([]updatecore.Comics, 50)
+	for i := range comics {
+		w := words{}.Generate(r, 10).Interface().(words)
+		comics[i] = updatecore.Comics{ID: testID + i, URL: fmt.Sprint("u", i), Words: w}
+	}
+	db := newPostgres(t, comics...)
+	ctx := context.Background()
+
+	ids := make([]int, len(comics))
+	for i, c := range comics {
+		ids[i] = c.ID
+		got, err := db.Get(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		if !slices.Equal(got.Words, c.Words) {
+			t.Fatalf("seed %d: expected words %q, got %q", seed, c.Words, got.Words)
+		}
+	}
+	got, err := db.GetComicsByIDs(ctx, ids...)
+	if err != nil {
+		t.Fatalf("GetComicsByIDs returned error: %v", err)
+	}
+	if len(got) != len(comics) {
+		t.Fatalf("expected %d comics, got %d", len(comics), len(got))
+	}
+	for i, c := range got {
+		if !slices.Equal(c.Words, comics[i].Words) {
+			t.Fatalf("seed %d: expected words %q, got %q", seed, comics[i].Words, c.Words)
+		}
+	}
+}
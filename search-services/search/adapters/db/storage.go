@@ -2,10 +2,15 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	"yadro.com/course/search/core"
@@ -32,6 +37,11 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// arrays разбирает текстовое представление массивов Postgres
+// с кавычками, экранированием и NULL
+var arrays = pgtype.NewMap()
+
+// StringArray scans postgres text[] in text representation
 type StringArray []string
 
 func (a *StringArray) Scan(value interface{}) error {
@@ -39,109 +49,419 @@ func (a *StringArray) Scan(value interface{}) error {
 		*a = []string{}
 		return nil
 	}
-	str, ok := value.(string)
-	if !ok {
-		return nil
+	if err := scanArray(value, (*[]string)(a)); err != nil {
+		return fmt.Errorf("bad string array: %w", err)
 	}
-
-	str = strings.Trim(str, "{}")
-	if str == "" {
+	if *a == nil {
 		*a = []string{}
-		return nil
-	}
-	parts := strings.Split(str, ",")
-	*a = make([]string, len(parts))
-	for i, part := range parts {
-		(*a)[i] = strings.Trim(part, `"`)
 	}
 	return nil
 }
 
 func (a StringArray) Value() (driver.Value, error) {
-	if len(a) == 0 {
-		return "{}", nil
-	}
 	var b strings.Builder
 	b.WriteString("{")
 	for i, s := range a {
 		if i > 0 {
 			b.WriteString(",")
 		}
+		// в кавычках элемент может содержать запятые, скобки и пробелы,
+		// а NULL остаётся строкой
 		b.WriteString(`"`)
-		b.WriteString(s)
+		for _, r := range s {
+			if r == '"' || r == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
 		b.WriteString(`"`)
 	}
 	b.WriteString("}")
 	return b.String(), nil
 }
 
+// IntArray scans postgres int[] in text representation
+type IntArray []int
+
+func (a *IntArray) Scan(value interface{}) error {
+	if value == nil {
+		*a = []int{}
+		return nil
+	}
+	if err := scanArray(value, (*[]int)(a)); err != nil {
+		return fmt.Errorf("bad int array: %w", err)
+	}
+	if *a == nil {
+		*a = []int{}
+	}
+	return nil
+}
+
+func scanArray(value any, dst any) error {
+	switch value.(type) {
+	case string, []byte:
+		return arrays.SQLScanner(dst).Scan(value)
+	default:
+		return fmt.Errorf("unsupported array type %T", value)
+	}
+}
+
+type Term struct {
+	ComicID   int      `db:"comic_id"`
+	Field     string   `db:"field"`
+	Term      string   `db:"term"`
+	Surface   string   `db:"surface"`
+	TF        int      `db:"tf"`
+	Positions IntArray `db:"positions"`
+}
+
 type Comics struct {
-	ID    int         `db:"id"`
-	URL   string      `db:"url"`
-	Words StringArray `db:"words"`
-}
-
-func (db *DB) Search(ctx context.Context, keyword string) ([]int, error) {
-	db.log.Info("Search called", "keyword", keyword)
-	var IDs []int
-	err := db.conn.SelectContext(
-		ctx, &IDs,
-		"SELECT id FROM comics WHERE $1 = ANY(words)",
-		keyword,
-	)
-	if err != nil {
-		db.log.Error("Search query failed", "error", err, "keyword", keyword)
+	ID         int          `db:"id"`
+	URL        string       `db:"url"`
+	Words      StringArray  `db:"words"`
+	Title      string       `db:"title"`
+	SafeTitle  string       `db:"safe_title"`
+	Alt        string       `db:"alt"`
+	Transcript string       `db:"transcript"`
+	Link       string       `db:"link"`
+	News       string       `db:"news"`
+	Published  sql.NullTime `db:"published"`
+}
+
+func (c Comics) toCore() core.Comics {
+	return core.Comics{
+		ID:         c.ID,
+		URL:        c.URL,
+		Words:      []string(c.Words),
+		Title:      c.Title,
+		SafeTitle:  c.SafeTitle,
+		Alt:        c.Alt,
+		Transcript: c.Transcript,
+		Link:       c.Link,
+		News:       c.News,
+		Published:  c.Published.Time,
+	}
+}
+
+// Ranked - номер найденного комикса и его релевантность
+type Ranked struct {
+	ID    int     `db:"id"`
+	Score float64 `db:"score"`
+}
+
+func (db *DB) Search(ctx context.Context, query core.Query, scorer core.BM25, stats core.CorpusStats) ([]core.Ranked, error) {
+	db.log.Info("Search called", "words", query.Words, "filter", query.Filter)
+	sqlQuery, args := searchQuery(query, scorer, stats)
+	var rows []Ranked
+	if err := db.conn.SelectContext(ctx, &rows, sqlQuery, args...); err != nil {
+		db.log.Error("Search query failed", "error", err, "words", query.Words)
 		return nil, err
 	}
-	db.log.Info("Search results", "count", len(IDs), "keyword", keyword)
-	return IDs, err
+	db.log.Info("Search results", "count", len(rows), "words", query.Words)
+
+	ranked := make([]core.Ranked, len(rows))
+	for i, r := range rows {
+		ranked[i] = core.Ranked{ID: r.ID, Score: r.Score}
+	}
+	return ranked, nil
+}
+
+// rankQuery находит комиксы с любым из слов запроса и ранжирует их по BM25 так же,
+// как core.BM25. Комиксы с точными совпадениями (вес 1) идут выше найденных только
+// по нечётким совпадениям, как в core.Query.Exact. Частоты слов и длины комиксов берутся из comic_terms, а для комиксов,
+// сохранённых без них, - из списка уникальных слов. Вместо %s подставляются условия поиска,
+// первое из них - пересечение со словами запроса, для которого есть GIN индекс по words.
+const rankQuery = `WITH query (term, weight) AS (
+	SELECT * FROM unnest($1::text[], $2::float8[])
+), matches AS (
+	SELECT c.id, q.term, q.weight
+	FROM comics c JOIN query q ON q.term = ANY(c.words)
+	WHERE %s
+), lengths AS (
+	SELECT c.id, coalesce(
+		(SELECT sum(t.tf) FROM comic_terms t WHERE t.comic_id = c.id), cardinality(c.words), 0
+	)::float8 AS length
+	FROM comics c WHERE c.id IN (SELECT id FROM matches)
+), frequencies AS (
+	SELECT m.id, m.term, m.weight, coalesce(
+		(SELECT sum(t.tf) FROM comic_terms t WHERE t.comic_id = m.id AND t.term = m.term), 1
+	)::float8 AS tf
+	FROM matches m
+), doc_freq AS (
+	SELECT term, count(*)::float8 AS df FROM matches GROUP BY term
+), corpus AS (
+	-- пока индекс не построен, коллекция оценивается по найденным комиксам
+	SELECT
+		CASE WHEN $5::bigint < count(*) THEN count(*) ELSE $5::bigint END::float8 AS docs,
+		CASE WHEN $5::bigint < count(*) THEN sum(length) ELSE $6::float8 END AS total_length
+	FROM lengths
+)
+SELECT f.id, sum(
+	f.weight * ln(1 + (corpus.docs - d.df + 0.5) / (d.df + 0.5))
+	* f.tf * ($3::float8 + 1) / (f.tf + $3::float8 * CASE
+		WHEN corpus.total_length > 0 THEN 1 - $4::float8 + $4::float8 * l.length * corpus.docs / corpus.total_length
+		ELSE 1
+	END)
+) AS score
+FROM frequencies f
+JOIN doc_freq d USING (term)
+JOIN lengths l USING (id)
+CROSS JOIN corpus
+GROUP BY f.id
+ORDER BY bool_or(f.weight >= 1) DESC, score DESC, f.id`
+
+// searchQuery строит запрос ранжирования
+func searchQuery(query core.Query, scorer core.BM25, stats core.CorpusStats) (string, []any) {
+	weights := make([]float64, len(query.Words))
+	for i, word := range query.Words {
+		weights[i] = query.Weight(word)
+	}
+	args := []any{query.Words, weights, scorer.K1, scorer.B, stats.Docs, float64(stats.TotalLength)}
+	conditions, args := filterConditions(query.Filter, []string{"c.words && $1::text[]"}, args)
+	return fmt.Sprintf(rankQuery, strings.Join(conditions, " AND ")), args
+}
+
+// filterConditions добавляет к условиям поиска только заданные условия фильтра,
+// их параметры нумеруются после args
+func filterConditions(filter core.Filter, conditions []string, args []any) ([]string, []any) {
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !filter.From.IsZero() {
+		add("c.published >= $%d::date", filter.From.Format(time.DateOnly))
+	}
+	if !filter.To.IsZero() {
+		add("c.published < $%d::date", filter.To.Format(time.DateOnly))
+	}
+	if filter.MinID > 0 {
+		add("c.id >= $%d", filter.MinID)
+	}
+	if filter.MaxID > 0 {
+		add("c.id <= $%d", filter.MaxID)
+	}
+	return conditions, args
+}
+
+const (
+	// границы выделения в ts_headline, в тексте комиксов их нет
+	startSel = "\x02"
+	stopSel  = "\x03"
+	// headlineOptions ограничивает фрагменты длинных полей примерно двумя десятками слов
+	headlineOptions = "StartSel=" + startSel + ", StopSel=" + stopSel + ", MaxWords=24, MinWords=12"
+)
+
+// ftsQuery ранжирует комиксы по ts_rank_cd полнотекстового поиска Postgres по заголовку,
+// пояснению и транскрипту и выделяет совпадения через ts_headline только на странице.
+// Вместо %s подставляются условия поиска.
+const ftsQuery = `SELECT c.id, c.url,
+	ts_rank_cd(c.fts, q.query) AS score,
+	count(*) OVER () AS total,
+	ts_headline('english', c.title, q.query, $2) AS title,
+	ts_headline('english', c.alt, q.query, $2) AS alt,
+	ts_headline('english', c.transcript, q.query, $2) AS transcript
+FROM comics c, websearch_to_tsquery('english', $1) AS q(query)
+WHERE %s
+ORDER BY score DESC, c.id
+LIMIT $3 OFFSET $4`
+
+// ftsCountQuery считает совпадения, когда страница за последней
+const ftsCountQuery = `SELECT count(*)
+FROM comics c, websearch_to_tsquery('english', $1) AS q(query)
+WHERE %s`
+
+// Headline - комикс, найденный полнотекстовым поиском, с выделенными фрагментами полей
+type Headline struct {
+	ID         int     `db:"id"`
+	URL        string  `db:"url"`
+	Score      float64 `db:"score"`
+	Total      int     `db:"total"`
+	Title      string  `db:"title"`
+	Alt        string  `db:"alt"`
+	Transcript string  `db:"transcript"`
+}
+
+func (db *DB) FullTextSearch(ctx context.Context, phrase string, filter core.Filter, limit, offset int) ([]core.Comics, int, error) {
+	db.log.Info("FullTextSearch called", "phrase", phrase, "filter", filter, "limit", limit, "offset", offset)
+	conditions, args := filterConditions(filter, []string{"c.fts @@ q.query"}, []any{phrase, headlineOptions, limit, offset})
+	var rows []Headline
+	err := db.conn.SelectContext(ctx, &rows, fmt.Sprintf(ftsQuery, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		db.log.Error("FullTextSearch query failed", "error", err, "phrase", phrase)
+		return nil, 0, err
+	}
+
+	comics := make([]core.Comics, len(rows))
+	for i, row := range rows {
+		comics[i] = core.Comics{ID: row.ID, URL: row.URL, Score: row.Score}
+		for _, field := range []struct{ name, headline string }{
+			{core.FieldTitle, row.Title},
+			{core.FieldAlt, row.Alt},
+			{core.FieldTranscript, row.Transcript},
+		} {
+			if snippet, ok := headlineSnippet(field.name, field.headline); ok {
+				comics[i].Snippets = append(comics[i].Snippets, snippet)
+			}
+		}
+	}
+	if len(rows) > 0 {
+		return comics, rows[0].Total, nil
+	}
+	if offset == 0 {
+		return comics, 0, nil
+	}
+
+	var total int
+	conditions, args = filterConditions(filter, []string{"c.fts @@ q.query"}, []any{phrase})
+	err = db.conn.GetContext(ctx, &total, fmt.Sprintf(ftsCountQuery, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		db.log.Error("FullTextSearch count failed", "error", err, "phrase", phrase)
+		return nil, 0, err
+	}
+	return comics, total, nil
+}
+
+// headlineSnippet переводит выделения ts_headline в смещения фрагмента, пробелы
+// сжимаются как во фрагментах других видов поиска. Поле без совпадений пропускается.
+func headlineSnippet(field, headline string) (core.Snippet, bool) {
+	headline = strings.Join(strings.Fields(headline), " ")
+	snippet := core.Snippet{Field: field}
+	var b strings.Builder
+	start := -1
+	for _, r := range headline {
+		switch string(r) {
+		case startSel:
+			start = b.Len()
+		case stopSel:
+			if start >= 0 && b.Len() > start {
+				snippet.Highlights = append(snippet.Highlights, core.Highlight{Start: start, End: b.Len()})
+			}
+			start = -1
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if len(snippet.Highlights) == 0 {
+		return core.Snippet{}, false
+	}
+	snippet.Text = b.String()
+	return snippet, true
 }
 
 func (db *DB) Get(ctx context.Context, id int) (core.Comics, error) {
 	var comics Comics
 	err := db.conn.GetContext(
 		ctx, &comics,
-		"SELECT id, url FROM comics WHERE id = $1",
+		`SELECT id, url, words, title, safe_title, alt, transcript, link, news, published
+		FROM comics WHERE id = $1`,
 		id,
 	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Comics{}, core.ErrNotFound
+		}
+		return core.Comics{}, err
+	}
 
-	return core.Comics{ID: comics.ID, URL: comics.URL}, err
+	return comics.toCore(), nil
 }
 
 func (db *DB) GetAllComics(ctx context.Context) ([]core.Comics, error) {
 	var comics []Comics
-	query := `SELECT id, url, words FROM comics`
+	query := `SELECT id, url, words, title, published FROM comics`
 	err := db.conn.SelectContext(ctx, &comics, query)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]core.Comics, len(comics))
-	for i, c := range comics {
-		result[i] = core.Comics{
-			ID:    c.ID,
-			URL:   c.URL,
-			Words: []string(c.Words),
-		}
+	terms, err := db.terms(ctx, `SELECT comic_id, field, term, surface, tf, positions FROM comic_terms`)
+	if err != nil {
+		return nil, err
 	}
-	return result, nil
+	return withTerms(comics, terms), nil
 }
 
 func (db *DB) GetComicsByIDs(ctx context.Context, ids ...int) ([]core.Comics, error) {
 	var comics []Comics
-	query := `SELECT id, url, words FROM comics WHERE id = ANY($1::int[])`
+	// комиксы возвращаются в порядке ids, чтобы не терять ранжирование
+	query := `SELECT id, url, words, title, alt, transcript, published FROM comics WHERE id = ANY($1::int[])
+		ORDER BY array_position($1::int[], id)`
 	err := db.conn.SelectContext(ctx, &comics, query, ids)
 	if err != nil {
 		return nil, err
 	}
 
+	terms, err := db.terms(ctx,
+		`SELECT comic_id, field, term, surface, tf, positions FROM comic_terms WHERE comic_id = ANY($1::int[])`,
+		ids)
+	if err != nil {
+		return nil, err
+	}
+	return withTerms(comics, terms), nil
+}
+
+// GetComicsTerms читает только слова и термины комиксов из stems, чтобы проверить
+// поля и позиции запроса, не загружая тексты и остальные термины найденных комиксов
+func (db *DB) GetComicsTerms(ctx context.Context, stems []string, ids ...int) ([]core.Comics, error) {
+	var comics []Comics
+	query := `SELECT id, ARRAY(SELECT w FROM unnest(words) w WHERE w = ANY($2::text[])) AS words
+		FROM comics WHERE id = ANY($1::int[])`
+	if err := db.conn.SelectContext(ctx, &comics, query, ids, stems); err != nil {
+		return nil, err
+	}
+
+	terms, err := db.terms(ctx,
+		`SELECT comic_id, field, term, surface, tf, positions FROM comic_terms
+		WHERE comic_id = ANY($1::int[]) AND term = ANY($2::text[])`,
+		ids, stems)
+	if err != nil {
+		return nil, err
+	}
+	return withTerms(comics, terms), nil
+}
+
+func (db *DB) IDs(ctx context.Context) ([]int, error) {
+	var ids []int
+	err := db.conn.SelectContext(ctx, &ids, `SELECT id FROM comics`)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// terms loads per field term statistics grouped by comics ID
+func (db *DB) terms(ctx context.Context, query string, args ...any) (map[int][]core.Term, error) {
+	var rows []Term
+	if err := db.conn.SelectContext(ctx, &rows, query, args...); err != nil {
+		db.log.Error("failed to load terms", "error", err)
+		return nil, err
+	}
+	terms := make(map[int][]core.Term)
+	for _, t := range rows {
+		terms[t.ComicID] = append(terms[t.ComicID], core.Term{
+			Field:     t.Field,
+			Term:      t.Term,
+			Surface:   t.Surface,
+			TF:        t.TF,
+			Positions: []int(t.Positions),
+		})
+	}
+	return terms, nil
+}
+
+func withTerms(comics []Comics, terms map[int][]core.Term) []core.Comics {
 	result := make([]core.Comics, len(comics))
 	for i, c := range comics {
 		result[i] = core.Comics{
-			ID:    c.ID,
-			URL:   c.URL,
-			Words: []string(c.Words),
+			ID:         c.ID,
+			URL:        c.URL,
+			Words:      []string(c.Words),
+			Title:      c.Title,
+			Alt:        c.Alt,
+			Transcript: c.Transcript,
+			Published:  c.Published.Time,
+			Terms:      terms[c.ID],
 		}
 	}
-	return result, nil
+	return result
 }
@@ -1,7 +1,15 @@
 package db
 
 import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"testing/quick"
+	"time"
+
+	"yadro.com/course/search/core"
 )
 
 func TestStringArray_Scan_Nil(t *testing.T) {
@@ -59,4 +67,160 @@ func TestStringArray_Value_WithValues(t *testing.T) {
 	}
 }
 
+func TestStringArray_Scan_Quoted(t *testing.T) {
+	cases := []struct {
+		value any
+		want  []string
+	}{
+		{`{a,"b,c"," d "}`, []string{"a", "b,c", " d "}},
+		{`{"say \"hi\"","back\\slash","{}"}`, []string{`say "hi"`, `back\slash`, "{}"}},
+		{`{"null",NULL,""}`, nil},
+		{`{"NULL","null",""}`, []string{"NULL", "null", ""}},
+		{[]byte(`{ёжик,"a b"}`), []string{"ёжик", "a b"}},
+	}
+	for _, c := range cases {
+		var a StringArray
+		err := a.Scan(c.value)
+		if c.want == nil {
+			if err == nil {
+				t.Fatalf("Scan(%q): expected error for NULL element, got %q", c.value, a)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Scan(%q) returned error: %v", c.value, err)
+		}
+		if !slices.Equal(a, c.want) {
+			t.Fatalf("Scan(%q): expected %q, got %q", c.value, c.want, a)
+		}
+	}
+
+	var a StringArray
+	if err := a.Scan(42); err == nil {
+		t.Fatalf("expected error for unsupported type")
+	}
+}
+
+// words - случайные слова из символов, которые Postgres экранирует в массивах
+type words []string
+
+func (words) Generate(r *rand.Rand, size int) reflect.Value {
+	const alphabet = "ab ,\"\\{}'ёж\t"
+	specials := []string{"", "NULL", "null", " ", `\`, `"`, "{}"}
+	runes := []rune(alphabet)
+	w := make(words, r.Intn(size+1))
+	for i := range w {
+		if r.Intn(4) == 0 {
+			w[i] = specials[r.Intn(len(specials))]
+			continue
+		}
+		word := make([]rune, r.Intn(size+1))
+		for j := range word {
+			word[j] = runes[r.Intn(len(runes))]
+		}
+		w[i] = string(word)
+	}
+	return reflect.ValueOf(w)
+}
+
+func TestStringArray_RoundTrip(t *testing.T) {
+	roundTrip := func(w words) bool {
+		v, err := StringArray(w).Value()
+		if err != nil {
+			return false
+		}
+		var a StringArray
+		if err := a.Scan(v); err != nil {
+			return false
+		}
+		return slices.Equal(a, StringArray(w))
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntArray_Scan(t *testing.T) {
+	var a IntArray
+	if err := a.Scan("{1,5,12}"); err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if len(a) != 3 || a[0] != 1 || a[2] != 12 {
+		t.Fatalf("unexpected values: %v", a)
+	}
+
+	if err := a.Scan([]byte("{}")); err != nil || len(a) != 0 {
+		t.Fatalf("expected empty slice, got %v, %v", a, err)
+	}
+
+	if err := a.Scan("{1,x}"); err == nil {
+		t.Fatalf("expected error for bad element")
+	}
+	if err := a.Scan(42); err == nil {
+		t.Fatalf("expected error for unsupported type")
+	}
+}
+
+func TestWithTerms(t *testing.T) {
+	comics := []Comics{{ID: 1, URL: "u1", Words: StringArray{"linux"}, Title: "Linux", Alt: "alt", Transcript: "text"}}
+	terms := map[int][]core.Term{1: {{Field: "title", Term: "linux", Surface: "linux", TF: 1}}}
+
+	got := withTerms(comics, terms)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 comics, got %d", len(got))
+	}
+	c := got[0]
+	if c.ID != 1 || c.URL != "u1" || c.Title != "Linux" || c.Alt != "alt" || c.Transcript != "text" {
+		t.Fatalf("unexpected comics: %#v", c)
+	}
+	if len(c.Words) != 1 || len(c.Terms) != 1 || c.Terms[0].Surface != "linux" {
+		t.Fatalf("unexpected words or terms: %#v", c)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	scorer := core.BM25{K1: 1.2, B: 0.75}
+	stats := core.CorpusStats{Docs: 100, TotalLength: 2000}
+	query := core.Query{Words: []string{"linux", "linu"}, Weights: map[string]float64{"linu": 0.5}}
+
+	sql, args := searchQuery(query, scorer, stats)
+	if !strings.Contains(sql, "WHERE c.words && $1::text[]\n") || len(args) != 6 {
+		t.Fatalf("unexpected query %q with %v", sql, args)
+	}
+	if !slices.Equal(args[1].([]float64), []float64{1, 0.5}) || args[2] != 1.2 || args[4] != 100 || args[5] != 2000.0 {
+		t.Fatalf("unexpected args: %v", args)
+	}
 
+	query.Filter = core.Filter{
+		From:  time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC),
+		MinID: 10,
+		MaxID: 500,
+	}
+	sql, args = searchQuery(query, scorer, stats)
+	want := "WHERE c.words && $1::text[] AND c.published >= $7::date AND c.published < $8::date AND c.id >= $9 AND c.id <= $10"
+	if !strings.Contains(sql, want) {
+		t.Fatalf("expected %q in %q", want, sql)
+	}
+	if len(args) != 10 || args[6] != "2006-01-01" || args[7] != "2010-01-01" || args[8] != 10 || args[9] != 500 {
+		t.Fatalf("unexpected args: %v", args)
+	}
+}
+
+func TestHeadlineSnippet(t *testing.T) {
+	snippet, ok := headlineSnippet(core.FieldTranscript, "  the \x02Linux\x03\n\n\x02kernel\x03 ёжик ")
+	if !ok {
+		t.Fatalf("expected snippet")
+	}
+	want := []core.Highlight{{Start: 4, End: 9}, {Start: 10, End: 16}}
+	if snippet.Text != "the Linux kernel ёжик" || !slices.Equal(snippet.Highlights, want) {
+		t.Fatalf("unexpected snippet: %+v", snippet)
+	}
+	if snippet.Field != core.FieldTranscript {
+		t.Fatalf("unexpected field %q", snippet.Field)
+	}
+
+	if _, ok := headlineSnippet(core.FieldAlt, "nothing matched"); ok {
+		t.Fatalf("expected no snippet without highlights")
+	}
+}
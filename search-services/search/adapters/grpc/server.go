@@ -3,10 +3,12 @@ package grpc
 import (
 	"context"
 	"errors"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	searchpb "yadro.com/course/proto/search"
 	"yadro.com/course/search/core"
 )
@@ -27,43 +29,136 @@ func (s *Server) Ping(_ context.Context, _ *emptypb.Empty) (*emptypb.Empty, erro
 }
 
 func (s *Server) Search(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	return s.search(ctx, req, s.service.Search)
+}
+
+func (s *Server) IndexSearch(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	return s.search(ctx, req, s.service.IndexSearch)
+}
+
+func (s *Server) FullTextSearch(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+	return s.search(ctx, req, s.service.FullTextSearch)
+}
+
+func (s *Server) search(
+	ctx context.Context, req *searchpb.SearchRequest,
+	search func(context.Context, core.SearchRequest) (core.SearchResult, error),
+) (*searchpb.SearchReply, error) {
 	if req.Limit == 0 {
 		req.Limit = defaultLimit
 	}
-	results, err := s.service.Search(ctx, req.Phrase, int(req.Limit))
+	result, err := search(ctx, toRequest(req))
 	if err != nil {
-		if errors.Is(err, core.ErrNotFound) {
+		switch {
+		case errors.Is(err, core.ErrNotFound):
 			return nil, status.Error(codes.NotFound, "nothing found")
+		case errors.Is(err, core.ErrBadArguments):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		return nil, err
 	}
-	comics := make([]*searchpb.Comics, 0, len(results))
-	for _, c := range results {
+	comics := make([]*searchpb.Comics, 0, len(result.Comics))
+	for _, c := range result.Comics {
 		comics = append(comics, &searchpb.Comics{
-			Id:  int64(c.ID),
-			Url: c.URL,
+			Id:       int64(c.ID),
+			Url:      c.URL,
+			Score:    c.Score,
+			Snippets: toSnippets(c.Snippets),
 		})
 	}
-	return &searchpb.SearchReply{Comics: comics}, nil
+	return &searchpb.SearchReply{
+		Comics:        comics,
+		Suggestions:   result.Suggestions,
+		Total:         int64(result.Total),
+		NextPageToken: result.NextPageToken,
+	}, nil
 }
 
-func (s *Server) IndexSearch(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchReply, error) {
+func toRequest(req *searchpb.SearchRequest) core.SearchRequest {
+	return core.SearchRequest{
+		Phrase: req.Phrase,
+		Filter: core.Filter{
+			From:  toDay(req.From),
+			To:    toDay(req.To),
+			MinID: int(req.MinId),
+			MaxID: int(req.MaxId),
+		},
+		Limit:     int(req.Limit),
+		Offset:    int(req.Offset),
+		PageToken: req.PageToken,
+		Fuzzy:     req.Fuzzy,
+	}
+}
+
+// toDay drops the time of day, the filter works with publication days
+func toDay(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime().UTC().Truncate(24 * time.Hour)
+}
+
+func toSnippets(snippets []core.Snippet) []*searchpb.Snippet {
+	res := make([]*searchpb.Snippet, 0, len(snippets))
+	for _, snippet := range snippets {
+		highlights := make([]*searchpb.Highlight, 0, len(snippet.Highlights))
+		for _, h := range snippet.Highlights {
+			highlights = append(highlights, &searchpb.Highlight{Start: int64(h.Start), End: int64(h.End)})
+		}
+		res = append(res, &searchpb.Snippet{Field: snippet.Field, Text: snippet.Text, Highlights: highlights})
+	}
+	return res
+}
+
+func (s *Server) GetComic(ctx context.Context, req *searchpb.ComicRequest) (*searchpb.ComicReply, error) {
+	if req.Id <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "bad comics id")
+	}
+	c, err := s.service.GetComic(ctx, int(req.Id))
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "comics is not found")
+		}
+		return nil, err
+	}
+	reply := &searchpb.ComicReply{
+		Id:         int64(c.ID),
+		Url:        c.URL,
+		Title:      c.Title,
+		SafeTitle:  c.SafeTitle,
+		Alt:        c.Alt,
+		Transcript: c.Transcript,
+		Link:       c.Link,
+		News:       c.News,
+		Words:      c.Words,
+	}
+	if !c.Published.IsZero() {
+		reply.Published = timestamppb.New(c.Published)
+	}
+	return reply, nil
+}
+
+func (s *Server) Suggest(ctx context.Context, req *searchpb.SuggestRequest) (*searchpb.SuggestReply, error) {
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "bad limit")
+	}
 	if req.Limit == 0 {
 		req.Limit = defaultLimit
 	}
-	results, err := s.service.IndexSearch(ctx, req.Phrase, int(req.Limit))
+	completions, err := s.service.Suggest(ctx, req.Prefix, int(req.Limit))
 	if err != nil {
-		if errors.Is(err, core.ErrNotFound) {
-			return nil, status.Error(codes.NotFound, "nothing found")
+		if errors.Is(err, core.ErrBadArguments) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		return nil, err
 	}
-	comics := make([]*searchpb.Comics, 0, len(results))
-	for _, c := range results {
-		comics = append(comics, &searchpb.Comics{
-			Id:  int64(c.ID),
-			Url: c.URL,
+	reply := &searchpb.SuggestReply{Completions: make([]*searchpb.Completion, 0, len(completions))}
+	for _, c := range completions {
+		reply.Completions = append(reply.Completions, &searchpb.Completion{
+			Text:      c.Text,
+			Kind:      string(c.Kind),
+			Frequency: int64(c.Frequency),
 		})
 	}
-	return &searchpb.SearchReply{Comics: comics}, nil
+	return reply, nil
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	searchpb "yadro.com/course/proto/search"
 	"yadro.com/course/search/core"
 )
@@ -17,14 +19,48 @@ type fakeSearcher struct {
 	searchErr         error
 	indexSearchResult []core.Comics
 	indexSearchErr    error
+	ftsResult         []core.Comics
+	suggestions       []string
+	completions       []core.Completion
+	nextPageToken     string
+	req               *core.SearchRequest
+	suggestErr        error
+	comic             core.Comics
+	comicErr          error
 }
 
-func (f fakeSearcher) Search(ctx context.Context, phrase string, limit int) ([]core.Comics, error) {
-	return f.searchResult, f.searchErr
+func (f fakeSearcher) GetComic(ctx context.Context, id int) (core.Comics, error) {
+	return f.comic, f.comicErr
 }
 
-func (f fakeSearcher) IndexSearch(ctx context.Context, phrase string, limit int) ([]core.Comics, error) {
-	return f.indexSearchResult, f.indexSearchErr
+func (f fakeSearcher) Search(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	if f.req != nil {
+		*f.req = req
+	}
+	return core.SearchResult{Comics: f.searchResult, Total: len(f.searchResult), Suggestions: f.suggestions}, f.searchErr
+}
+
+func (f fakeSearcher) IndexSearch(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	if f.req != nil {
+		*f.req = req
+	}
+	return core.SearchResult{
+		Comics:        f.indexSearchResult,
+		Total:         len(f.indexSearchResult),
+		NextPageToken: f.nextPageToken,
+		Suggestions:   f.suggestions,
+	}, f.indexSearchErr
+}
+
+func (f fakeSearcher) FullTextSearch(ctx context.Context, req core.SearchRequest) (core.SearchResult, error) {
+	if f.req != nil {
+		*f.req = req
+	}
+	return core.SearchResult{Comics: f.ftsResult, Total: len(f.ftsResult)}, nil
+}
+
+func (f fakeSearcher) Suggest(ctx context.Context, prefix string, limit int) ([]core.Completion, error) {
+	return f.completions, f.suggestErr
 }
 
 func TestServer_Ping(t *testing.T) {
@@ -42,8 +78,8 @@ func TestServer_Ping(t *testing.T) {
 func TestServer_Search_DefaultLimitAndMapping(t *testing.T) {
 	s := NewServer(fakeSearcher{
 		searchResult: []core.Comics{
-			{ID: 1, URL: "url1"},
-			{ID: 2, URL: "url2"},
+			{ID: 1, URL: "url1", Score: 2.5},
+			{ID: 2, URL: "url2", Score: 1},
 		},
 	})
 
@@ -59,11 +95,23 @@ func TestServer_Search_DefaultLimitAndMapping(t *testing.T) {
 	if len(resp.Comics) != 2 {
 		t.Fatalf("expected 2 comics, got %d", len(resp.Comics))
 	}
-	if resp.Comics[0].Id != 1 || resp.Comics[0].Url != "url1" {
+	if resp.Comics[0].Id != 1 || resp.Comics[0].Url != "url1" || resp.Comics[0].Score != 2.5 {
 		t.Fatalf("unexpected first comics: %#v", resp.Comics[0])
 	}
 }
 
+func TestServer_IndexSearch_Suggestions(t *testing.T) {
+	s := NewServer(fakeSearcher{suggestions: []string{"recursion"}})
+
+	resp, err := s.IndexSearch(context.Background(), &searchpb.SearchRequest{Phrase: "recursoin"})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	if len(resp.Comics) != 0 || len(resp.Suggestions) != 1 || resp.Suggestions[0] != "recursion" {
+		t.Fatalf("unexpected reply: %#v", resp)
+	}
+}
+
 func TestServer_Search_NotFound(t *testing.T) {
 	s := NewServer(fakeSearcher{
 		searchErr: core.ErrNotFound,
@@ -153,3 +201,146 @@ func TestServer_IndexSearch_OtherError(t *testing.T) {
 }
 
 
+
+func TestServer_GetComic(t *testing.T) {
+	published := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	s := NewServer(fakeSearcher{comic: core.Comics{
+		ID: 1, URL: "u1", Title: "t", Alt: "a", Words: []string{"w"}, Published: published,
+	}})
+
+	reply, err := s.GetComic(context.Background(), &searchpb.ComicRequest{Id: 1})
+	if err != nil {
+		t.Fatalf("GetComic returned error: %v", err)
+	}
+	if reply.Id != 1 || reply.Title != "t" || reply.Alt != "a" || len(reply.Words) != 1 {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+	if !reply.Published.AsTime().Equal(published) {
+		t.Fatalf("expected published %v, got %v", published, reply.Published.AsTime())
+	}
+}
+
+func TestServer_GetComic_Errors(t *testing.T) {
+	s := NewServer(fakeSearcher{comicErr: core.ErrNotFound})
+
+	_, err := s.GetComic(context.Background(), &searchpb.ComicRequest{Id: 1})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	_, err = s.GetComic(context.Background(), &searchpb.ComicRequest{Id: 0})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestServer_Suggest(t *testing.T) {
+	s := NewServer(fakeSearcher{completions: []core.Completion{
+		{Text: "linux", Kind: core.CompletionWord, Frequency: 4},
+		{Text: "Linux User", Kind: core.CompletionTitle, Frequency: 1},
+	}})
+
+	resp, err := s.Suggest(context.Background(), &searchpb.SuggestRequest{Prefix: "lin"})
+	if err != nil {
+		t.Fatalf("Suggest returned error: %v", err)
+	}
+	if len(resp.Completions) != 2 {
+		t.Fatalf("expected 2 completions, got %d", len(resp.Completions))
+	}
+	c := resp.Completions[1]
+	if c.Text != "Linux User" || c.Kind != "title" || c.Frequency != 1 {
+		t.Fatalf("unexpected completion: %v", c)
+	}
+}
+
+func TestServer_Suggest_BadArguments(t *testing.T) {
+	s := NewServer(fakeSearcher{suggestErr: core.ErrBadArguments})
+
+	_, err := s.Suggest(context.Background(), &searchpb.SuggestRequest{Prefix: " "})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	_, err = NewServer(fakeSearcher{}).Suggest(context.Background(), &searchpb.SuggestRequest{Prefix: "lin", Limit: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for negative limit, got %v", err)
+	}
+}
+
+func TestServer_IndexSearch_Snippets(t *testing.T) {
+	snippet := core.Snippet{Field: "alt", Text: "the linux kernel", Highlights: []core.Highlight{{Start: 4, End: 9}}}
+	s := NewServer(fakeSearcher{indexSearchResult: []core.Comics{{ID: 1, Snippets: []core.Snippet{snippet}}}})
+
+	resp, err := s.IndexSearch(context.Background(), &searchpb.SearchRequest{Phrase: "linux"})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	snippets := resp.Comics[0].Snippets
+	if len(snippets) != 1 || snippets[0].Field != "alt" || snippets[0].Text != snippet.Text {
+		t.Fatalf("unexpected snippets: %v", snippets)
+	}
+	if h := snippets[0].Highlights; len(h) != 1 || h[0].Start != 4 || h[0].End != 9 {
+		t.Fatalf("unexpected highlights: %v", h)
+	}
+}
+
+func TestServer_IndexSearch_Pages(t *testing.T) {
+	var req core.SearchRequest
+	s := NewServer(fakeSearcher{
+		indexSearchResult: []core.Comics{{ID: 1}},
+		nextPageToken:     "next",
+		req:               &req,
+	})
+
+	resp, err := s.IndexSearch(context.Background(), &searchpb.SearchRequest{
+		Phrase: "linux", Limit: 1, Offset: 2, PageToken: "page", Fuzzy: true,
+	})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	want := core.SearchRequest{Phrase: "linux", Limit: 1, Offset: 2, PageToken: "page", Fuzzy: true}
+	if req != want {
+		t.Fatalf("expected request %+v, got %+v", want, req)
+	}
+	if resp.Total != 1 || resp.NextPageToken != "next" {
+		t.Fatalf("unexpected reply: %v", resp)
+	}
+}
+
+func TestServer_IndexSearch_Filter(t *testing.T) {
+	var req core.SearchRequest
+	s := NewServer(fakeSearcher{req: &req})
+
+	_, err := s.IndexSearch(context.Background(), &searchpb.SearchRequest{
+		Phrase: "linux",
+		From:   timestamppb.New(time.Date(2006, 1, 1, 15, 30, 0, 0, time.UTC)),
+		MinId:  10,
+		MaxId:  20,
+	})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	want := core.Filter{From: time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC), MinID: 10, MaxID: 20}
+	if req.Filter != want {
+		t.Fatalf("expected filter %+v, got %+v", want, req.Filter)
+	}
+}
+
+func TestServer_FullTextSearch(t *testing.T) {
+	var req core.SearchRequest
+	s := NewServer(fakeSearcher{
+		ftsResult: []core.Comics{{ID: 7, URL: "u", Score: 0.5, Snippets: []core.Snippet{
+			{Field: core.FieldAlt, Text: "linux kernel", Highlights: []core.Highlight{{Start: 0, End: 5}}},
+		}}},
+		req: &req,
+	})
+
+	resp, err := s.FullTextSearch(context.Background(), &searchpb.SearchRequest{Phrase: "linux"})
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	if req.Phrase != "linux" || req.Limit != defaultLimit {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if resp.Total != 1 || len(resp.Comics) != 1 || resp.Comics[0].Id != 7 || len(resp.Comics[0].Snippets) != 1 {
+		t.Fatalf("unexpected reply: %v", resp)
+	}
+}
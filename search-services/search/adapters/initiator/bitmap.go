@@ -0,0 +1,49 @@
+package initiator
+
+import "time"
+
+// bitmap - множество id комиксов от 0 до размера битовой карты
+type bitmap []uint64
+
+func newBitmap(size int) bitmap {
+	return make(bitmap, (size+63)/64)
+}
+
+func (b bitmap) set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+// setRange добавляет id от from до to включительно
+func (b bitmap) setRange(from, to int) {
+	for i := from; i <= to; i++ {
+		b.set(i)
+	}
+}
+
+func (b bitmap) has(i int) bool {
+	return i >= 0 && i/64 < len(b) && b[i/64]&(1<<(i%64)) != 0
+}
+
+// and оставляет в b только id, которые есть в other
+func (b bitmap) and(other bitmap) {
+	for i := range b {
+		if i < len(other) {
+			b[i] &= other[i]
+		} else {
+			b[i] = 0
+		}
+	}
+}
+
+// datedDoc - комикс в списке по дате публикации
+type datedDoc struct {
+	published time.Time
+	id        int
+}
+
+func compareDated(a, b datedDoc) int {
+	if c := a.published.Compare(b.published); c != 0 {
+		return c
+	}
+	return a.id - b.id
+}
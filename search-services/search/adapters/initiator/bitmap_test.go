@@ -0,0 +1,124 @@
+package initiator
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"yadro.com/course/search/core"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBitmap(t *testing.T) {
+	b := newBitmap(130)
+	b.setRange(60, 70)
+	b.set(129)
+	other := newBitmap(100)
+	other.setRange(65, 99)
+	b.and(other)
+
+	var got []int
+	for i := -1; i < 200; i++ {
+		if b.has(i) {
+			got = append(got, i)
+		}
+	}
+	if want := []int{65, 66, 67, 68, 69, 70}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestIndex_Filter(t *testing.T) {
+	idx := buildIndex(map[int]core.Document{
+		1: {Published: day(2006, 1, 1)},
+		2: {Published: day(2009, 12, 31)},
+		3: {},
+		4: {Published: day(2010, 1, 1)},
+	}, nil)
+	idx.put(5, core.Document{Published: day(2008, 6, 1)}, "u5")
+	idx.put(4, core.Document{Published: day(2011, 1, 1)}, "u4")
+	idx.remove(1)
+
+	allowed := func(f core.Filter) []int {
+		b := idx.filter(f, 5)
+		var ids []int
+		for id := range 6 {
+			if b.has(id) {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	cases := []struct {
+		filter core.Filter
+		want   []int
+	}{
+		{core.Filter{To: day(2010, 1, 1)}, []int{2, 5}},
+		{core.Filter{From: day(2009, 12, 31)}, []int{2, 4}},
+		{core.Filter{From: day(2008, 1, 1), To: day(2009, 12, 31)}, []int{5}},
+		{core.Filter{MinID: 3, MaxID: 4}, []int{3, 4}},
+		{core.Filter{MinID: 3, To: day(2010, 1, 1)}, []int{5}},
+		{core.Filter{MaxID: 100}, []int{0, 1, 2, 3, 4, 5}},
+	}
+	for _, c := range cases {
+		if got := allowed(c.filter); !slices.Equal(got, c.want) {
+			t.Fatalf("%+v: expected %v, got %v", c.filter, c.want, got)
+		}
+	}
+	if !slices.IsSortedFunc(idx.dated, compareDated) || len(idx.dated) != 3 {
+		t.Fatalf("expected sorted dated comics without removed ones, got %v", idx.dated)
+	}
+}
+
+func TestInitiator_GetIndexedComics_Filter(t *testing.T) {
+	init := newTestInitiator(&resolvingDB{})
+	init.index = buildIndex(map[int]core.Document{
+		1: {TF: map[string]int{"a": 1}, Length: 1, Published: day(2007, 1, 1)},
+		2: {TF: map[string]int{"a": 1}, Length: 1, Published: day(2012, 1, 1)},
+		3: {TF: map[string]int{"a": 1}, Length: 1, Published: day(2008, 1, 1)},
+	}, nil)
+
+	query := core.Query{
+		Words:  []string{"a"},
+		Root:   core.TermNode{Stem: "a"},
+		Filter: core.Filter{To: day(2010, 1, 1), MinID: 2},
+	}
+	res, total, err := init.GetIndexedComics(context.Background(), query, 10, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if total != 1 || len(res) != 1 || res[0].ID != 3 {
+		t.Fatalf("expected only comics 3, got %v of %d", res, total)
+	}
+	if ids := init.index.ids("a"); len(ids) != 3 {
+		t.Fatalf("expected postings to be left intact, got %v", ids)
+	}
+}
+
+func TestInitiator_UpdateIndex_KeepsPublished(t *testing.T) {
+	db := &fakeDB{comicsByIDs: []core.Comics{
+		{ID: 1, URL: "u1", Words: []string{"a"}, Published: day(2008, 1, 1)},
+		{ID: 2, URL: "u2", Words: []string{"a"}, Published: day(2012, 1, 1)},
+	}}
+	init := newTestInitiator(db)
+	if err := init.UpdateIndex(context.Background(), []int{1, 2}, nil); err != nil {
+		t.Fatalf("UpdateIndex returned error: %v", err)
+	}
+
+	query := core.Query{
+		Words:  []string{"a"},
+		Root:   core.TermNode{Stem: "a"},
+		Filter: core.Filter{From: day(2007, 1, 1), To: day(2010, 1, 1)},
+	}
+	res, total, err := init.GetIndexedComics(context.Background(), query, 10, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if total != 1 || len(res) != 1 || res[0].ID != 1 {
+		t.Fatalf("expected comics updated from events to be filtered by date, got %v of %d", res, total)
+	}
+}
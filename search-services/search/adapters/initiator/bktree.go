@@ -0,0 +1,81 @@
+package initiator
+
+// bkTree - BK-дерево термов словаря для поиска похожих термов по расстоянию Левенштейна.
+// Потомки узла сгруппированы по расстоянию до него, поэтому по неравенству
+// треугольника при поиске обходятся только ветви, в которых может быть ответ.
+type bkTree struct {
+	root *bkNode
+}
+
+type bkNode struct {
+	term     string
+	children map[int]*bkNode
+}
+
+// add добавляет терм, повторное добавление ничего не меняет
+func (t *bkTree) add(term string) {
+	if t.root == nil {
+		t.root = &bkNode{term: term}
+		return
+	}
+	node := t.root
+	for {
+		d := levenshtein(term, node.term)
+		if d == 0 {
+			return
+		}
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{term: term}
+			return
+		}
+		node = child
+	}
+}
+
+// search вызывает fn для каждого терма на расстоянии не больше maxDistance от term
+func (t *bkTree) search(term string, maxDistance int, fn func(term string, distance int)) {
+	if t.root == nil {
+		return
+	}
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := levenshtein(term, node.term)
+		if d <= maxDistance {
+			fn(node.term, d)
+		}
+		for childDistance, child := range node.children {
+			if childDistance >= d-maxDistance && childDistance <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+}
+
+// levenshtein считает редакционное расстояние между строками по символам
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,141 @@
+package initiator
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"yadro.com/course/search/core"
+)
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "cat", 3},
+		{"cat", "cat", 0},
+		{"cat", "cut", 1},
+		{"cat", "cats", 1},
+		{"recursoin", "recurs", 3},
+		{"physci", "physic", 2},
+		{"ёж", "еж", 1},
+	}
+	for _, c := range cases {
+		if got := levenshtein(c.a, c.b); got != c.want {
+			t.Fatalf("levenshtein(%q, %q): expected %d, got %d", c.a, c.b, c.want, got)
+		}
+	}
+}
+
+func TestBKTree_SearchMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomTerm := func() string {
+		b := make([]byte, 2+rng.IntN(6))
+		for i := range b {
+			b[i] = "abcde"[rng.IntN(5)]
+		}
+		return string(b)
+	}
+
+	var tree bkTree
+	terms := map[string]bool{}
+	for range 500 {
+		term := randomTerm()
+		tree.add(term)
+		terms[term] = true
+	}
+
+	for range 50 {
+		query := randomTerm()
+		for maxDistance := range 3 {
+			var got []string
+			tree.search(query, maxDistance, func(term string, distance int) {
+				if distance != levenshtein(query, term) {
+					t.Fatalf("wrong distance %d between %q and %q", distance, query, term)
+				}
+				got = append(got, term)
+			})
+			var want []string
+			for term := range terms {
+				if levenshtein(query, term) <= maxDistance {
+					want = append(want, term)
+				}
+			}
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%q within %d: expected %v, got %v", query, maxDistance, want, got)
+			}
+		}
+	}
+}
+
+func TestIndex_Similar(t *testing.T) {
+	idx := buildIndex(map[int]core.Document{
+		1: {TF: map[string]int{"galaxi": 1}, Forms: map[string]string{"galaxi": "galaxies"}},
+		2: {TF: map[string]int{"galax": 1, "galaxi": 1}, Forms: map[string]string{"galax": "galax", "galaxi": "galaxy"}},
+		4: {TF: map[string]int{"galaxi": 1}, Forms: map[string]string{"galaxi": "galaxy"}},
+	}, nil)
+	idx.put(3, core.Document{TF: map[string]int{"galaxia": 1}}, "u3")
+	idx.remove(2)
+
+	got := idx.similar("galxi", 2)
+	want := []core.SimilarTerm{
+		{Term: "galaxi", Form: "galaxies", Distance: 1, Frequency: 2},
+		{Term: "galaxia", Distance: 2, Frequency: 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if _, ok := idx.forms["galax"]; ok {
+		t.Fatalf("expected forms of removed terms to be dropped: %v", idx.forms)
+	}
+}
+
+func TestInitiator_GetIndexedComics_FuzzyBelowExact(t *testing.T) {
+	init := newTestInitiator(&resolvingDB{})
+	// у нечёткого совпадения выше частота, но вес меньше
+	init.index = buildIndex(map[int]core.Document{
+		1: {TF: map[string]int{"galaxi": 1}, Length: 1},
+		2: {TF: map[string]int{"galxi": 3}, Length: 3},
+	}, nil)
+
+	query := core.Query{
+		Words:   []string{"galaxi", "galxi"},
+		Weights: map[string]float64{"galxi": 0.5},
+		Root:    core.OrNode{Nodes: []core.Node{core.TermNode{Stem: "galaxi"}, core.TermNode{Stem: "galxi"}}},
+	}
+	comics, _, err := init.GetIndexedComics(context.Background(), query, 10, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if len(comics) != 2 || comics[0].ID != 1 || comics[1].ID != 2 {
+		t.Fatalf("expected exact match first, got %v", comics)
+	}
+}
+
+func TestInitiator_GetIndexedComics_RareFuzzyBelowExact(t *testing.T) {
+	init := newTestInitiator(&resolvingDB{})
+	// нечёткий терм реже точного, и его взвешенный BM25 выше
+	docs := map[int]core.Document{5: {TF: map[string]int{"galxi": 1}, Length: 1}}
+	for id := 1; id <= 4; id++ {
+		docs[id] = core.Document{TF: map[string]int{"galaxi": 1}, Length: 1}
+	}
+	init.index = buildIndex(docs, nil)
+
+	query := core.Query{
+		Words:   []string{"galaxi", "galxi"},
+		Weights: map[string]float64{"galxi": 0.5},
+		Root:    core.OrNode{Nodes: []core.Node{core.TermNode{Stem: "galaxi"}, core.TermNode{Stem: "galxi"}}},
+	}
+	comics, _, err := init.GetIndexedComics(context.Background(), query, 10, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if len(comics) != 5 || comics[4].ID != 5 || comics[4].Score <= comics[0].Score {
+		t.Fatalf("expected rare fuzzy match to rank below exact ones, got %v", comics)
+	}
+}
@@ -0,0 +1,379 @@
+package initiator
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"yadro.com/course/search/core"
+)
+
+// postings - список вхождений терма: id комиксов по возрастанию и частоты терма в них
+type postings struct {
+	ids []int
+	tfs []int
+}
+
+// index - инвертированный индекс: терм -> список вхождений, отсортированный по id комикса
+type index struct {
+	docs map[int]core.Document
+	// urls хранит ссылки на комиксы, чтобы отвечать на поиск без обращения к БД
+	urls     map[int]string
+	postings map[string]*postings
+	stats    core.CorpusStats
+	// vocabulary содержит все термы, когда-либо попадавшие в индекс: удалённые
+	// термы остаются в дереве до перестроения и отбрасываются при поиске
+	vocabulary *bkTree
+	// forms считает для каждого терма, в скольких комиксах встречается каждая его форма
+	forms map[string]map[string]int
+	// words и titles - префиксные деревья слов и заголовков комиксов для автодополнения
+	words  *trie
+	titles *trie
+	// dated - комиксы с датой публикации по возрастанию даты для фильтра по датам
+	dated []datedDoc
+}
+
+func newIndex() *index {
+	return &index{
+		docs:       make(map[int]core.Document),
+		urls:       make(map[int]string),
+		postings:   make(map[string]*postings),
+		stats:      core.NewCorpusStats(),
+		vocabulary: &bkTree{},
+		forms:      make(map[string]map[string]int),
+		words:      &trie{},
+		titles:     &trie{},
+	}
+}
+
+// buildIndex строит индекс по документам и ссылкам на комиксы с нуля
+func buildIndex(docs map[int]core.Document, urls map[int]string) *index {
+	idx := newIndex()
+	idx.docs = docs
+	if urls != nil {
+		idx.urls = urls
+	}
+
+	// комиксы обходятся по возрастанию id, поэтому списки вхождений
+	// получаются отсортированными без дополнительной сортировки
+	for _, id := range slices.Sorted(maps.Keys(docs)) {
+		doc := docs[id]
+		for term, tf := range doc.TF {
+			list, ok := idx.postings[term]
+			if !ok {
+				list = &postings{}
+				idx.postings[term] = list
+			}
+			list.ids = append(list.ids, id)
+			list.tfs = append(list.tfs, tf)
+		}
+		idx.stats.Add(doc.TF)
+		idx.addForms(doc.Forms)
+		idx.addCompletions(doc)
+		if !doc.Published.IsZero() {
+			idx.dated = append(idx.dated, datedDoc{published: doc.Published, id: id})
+		}
+	}
+	for _, term := range slices.Sorted(maps.Keys(idx.postings)) {
+		idx.vocabulary.add(term)
+	}
+	slices.SortFunc(idx.dated, compareDated)
+	return idx
+}
+
+// put добавляет документ в индекс, заменяя прежнюю версию
+func (idx *index) put(id int, doc core.Document, url string) {
+	idx.remove(id)
+	idx.docs[id] = doc
+	idx.urls[id] = url
+	for term, tf := range doc.TF {
+		list, ok := idx.postings[term]
+		if !ok {
+			list = &postings{}
+			idx.postings[term] = list
+			idx.vocabulary.add(term)
+		}
+		i, _ := slices.BinarySearch(list.ids, id)
+		list.ids = slices.Insert(list.ids, i, id)
+		list.tfs = slices.Insert(list.tfs, i, tf)
+	}
+	idx.stats.Add(doc.TF)
+	idx.addForms(doc.Forms)
+	idx.addCompletions(doc)
+	if !doc.Published.IsZero() {
+		d := datedDoc{published: doc.Published, id: id}
+		i, _ := slices.BinarySearchFunc(idx.dated, d, compareDated)
+		idx.dated = slices.Insert(idx.dated, i, d)
+	}
+}
+
+// remove удаляет документ из индекса, если он там есть
+func (idx *index) remove(id int) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.TF {
+		list := idx.postings[term]
+		i, found := slices.BinarySearch(list.ids, id)
+		if !found {
+			continue
+		}
+		list.ids = slices.Delete(list.ids, i, i+1)
+		list.tfs = slices.Delete(list.tfs, i, i+1)
+		if len(list.ids) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.stats.Remove(doc.TF)
+	for term, form := range doc.Forms {
+		counts := idx.forms[term]
+		if counts[form]--; counts[form] <= 0 {
+			delete(counts, form)
+		}
+		if len(counts) == 0 {
+			delete(idx.forms, term)
+		}
+	}
+	for term := range doc.TF {
+		idx.words.remove(cmp.Or(doc.Forms[term], term))
+	}
+	if doc.Title != "" {
+		idx.titles.remove(strings.ToLower(doc.Title))
+	}
+	if i, found := slices.BinarySearchFunc(idx.dated, datedDoc{published: doc.Published, id: id}, compareDated); found {
+		idx.dated = slices.Delete(idx.dated, i, i+1)
+	}
+	delete(idx.docs, id)
+	delete(idx.urls, id)
+}
+
+func (idx *index) addForms(forms map[string]string) {
+	for term, form := range forms {
+		counts, ok := idx.forms[term]
+		if !ok {
+			counts = make(map[string]int)
+			idx.forms[term] = counts
+		}
+		counts[form]++
+	}
+}
+
+// addCompletions добавляет в деревья автодополнения слова документа в исходной форме,
+// а для комиксов без форм - основы, и заголовок
+func (idx *index) addCompletions(doc core.Document) {
+	for term := range doc.TF {
+		word := cmp.Or(doc.Forms[term], term)
+		idx.words.add(word, word)
+	}
+	if doc.Title != "" {
+		idx.titles.add(strings.ToLower(doc.Title), doc.Title)
+	}
+}
+
+// complete возвращает не больше limit слов и заголовков, начинающихся с prefix,
+// в порядке убывания числа комиксов с ними
+func (idx *index) complete(prefix string, limit int) []core.Completion {
+	var res []core.Completion
+	idx.words.complete(prefix, func(text string, count int) {
+		res = append(res, core.Completion{Text: text, Kind: core.CompletionWord, Frequency: count})
+	})
+	idx.titles.complete(prefix, func(text string, count int) {
+		res = append(res, core.Completion{Text: text, Kind: core.CompletionTitle, Frequency: count})
+	})
+	return topCompletions(res, limit)
+}
+
+// form возвращает самую частую форму терма, при равенстве - первую по алфавиту
+func (idx *index) form(term string) string {
+	var best string
+	for form, n := range idx.forms[term] {
+		if n > idx.forms[term][best] || n == idx.forms[term][best] && form < best {
+			best = form
+		}
+	}
+	return best
+}
+
+// ids возвращает отсортированные id комиксов, содержащих терм.
+// Срез принадлежит индексу и не должен изменяться.
+func (idx *index) ids(term string) []int {
+	if list, ok := idx.postings[term]; ok {
+		return list.ids
+	}
+	return nil
+}
+
+// similar возвращает термы индекса на расстоянии не больше maxDistance от term,
+// ближайшие первыми
+func (idx *index) similar(term string, maxDistance int) []core.SimilarTerm {
+	var res []core.SimilarTerm
+	idx.vocabulary.search(term, maxDistance, func(t string, distance int) {
+		if list, ok := idx.postings[t]; ok {
+			res = append(res, core.SimilarTerm{
+				Term:      t,
+				Form:      idx.form(t),
+				Distance:  distance,
+				Frequency: len(list.ids),
+			})
+		}
+	})
+	slices.SortFunc(res, func(a, b core.SimilarTerm) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Term, b.Term)
+	})
+	return res
+}
+
+// candidates возвращает отсортированные id комиксов, которые могут подойти под запрос.
+// Это надмножество результата: поля, позиции и исключения проверяет Query.Match.
+func (idx *index) candidates(node core.Node) []int {
+	switch node := node.(type) {
+	case core.TermNode:
+		return idx.ids(node.Stem)
+	case core.PhraseNode:
+		lists := make([][]int, len(node.Stems))
+		for i, stem := range node.Stems {
+			lists[i] = idx.ids(stem)
+		}
+		return intersect(lists...)
+	case core.NearNode:
+		return intersect(idx.ids(node.Left), idx.ids(node.Right))
+	case core.BoolNode:
+		if len(node.Must) > 0 {
+			lists := make([][]int, len(node.Must))
+			for i, n := range node.Must {
+				lists[i] = idx.candidates(n)
+			}
+			return intersect(lists...)
+		}
+		lists := make([][]int, len(node.Should))
+		for i, n := range node.Should {
+			lists[i] = idx.candidates(n)
+		}
+		return union(lists...)
+	case core.OrNode:
+		lists := make([][]int, len(node.Nodes))
+		for i, n := range node.Nodes {
+			lists[i] = idx.candidates(n)
+		}
+		return union(lists...)
+	}
+	return nil
+}
+
+// filter возвращает битовую карту комиксов с id не больше maxID, проходящих фильтр.
+// Диапазон id задаётся целиком, диапазон дат находится двоичным поиском по dated.
+func (idx *index) filter(f core.Filter, maxID int) bitmap {
+	allowed := newBitmap(maxID + 1)
+	to := maxID
+	if f.MaxID > 0 {
+		to = min(f.MaxID, maxID)
+	}
+	allowed.setRange(max(f.MinID, 0), to)
+	if !f.Dated() {
+		return allowed
+	}
+
+	from := 0
+	if !f.From.IsZero() {
+		from = sort.Search(len(idx.dated), func(i int) bool { return !idx.dated[i].published.Before(f.From) })
+	}
+	end := len(idx.dated)
+	if !f.To.IsZero() {
+		end = sort.Search(len(idx.dated), func(i int) bool { return !idx.dated[i].published.Before(f.To) })
+	}
+	dated := newBitmap(maxID + 1)
+	for _, d := range idx.dated[from:max(from, end)] {
+		if d.id <= maxID {
+			dated.set(d.id)
+		}
+	}
+	allowed.and(dated)
+	return allowed
+}
+
+// score считает BM25 для отсортированных кандидатов по спискам вхождений слов запроса
+// с учётом весов нечётких совпадений
+func (idx *index) score(scorer core.BM25, query core.Query, ids []int) []float64 {
+	scores := make([]float64, len(ids))
+	for _, word := range query.Words {
+		weight := query.Weight(word)
+		list, ok := idx.postings[word]
+		if !ok {
+			continue
+		}
+		// кандидаты отсортированы, поэтому поиск продолжается с предыдущей позиции
+		from := 0
+		for i, id := range ids {
+			j, found := slices.BinarySearch(list.ids[from:], id)
+			from += j
+			if !found {
+				continue
+			}
+			scores[i] += weight * scorer.TermScore(idx.stats, word, list.tfs[from], idx.docs[id].Length)
+		}
+	}
+	return scores
+}
+
+// intersect пересекает отсортированные списки, начиная с самого короткого.
+// Элементы короткого списка ищутся в длинных двоичным поиском, поэтому стоимость
+// определяется размером наименьшего списка, а не размером корпуса.
+func intersect(lists ...[]int) []int {
+	if len(lists) == 0 {
+		return nil
+	}
+	slices.SortFunc(lists, func(a, b []int) int {
+		return cmp.Compare(len(a), len(b))
+	})
+
+	res := slices.Clone(lists[0])
+	for _, list := range lists[1:] {
+		n := 0
+		for _, id := range res {
+			i := sort.SearchInts(list, id)
+			if i < len(list) && list[i] == id {
+				res[n] = id
+				n++
+			}
+			list = list[i:]
+		}
+		res = res[:n]
+		if n == 0 {
+			break
+		}
+	}
+	return res
+}
+
+// union объединяет отсортированные списки слиянием
+func union(lists ...[]int) []int {
+	var res []int
+	for _, list := range lists {
+		merged := make([]int, 0, len(res)+len(list))
+		i, j := 0, 0
+		for i < len(res) && j < len(list) {
+			switch {
+			case res[i] < list[j]:
+				merged = append(merged, res[i])
+				i++
+			case res[i] > list[j]:
+				merged = append(merged, list[j])
+				j++
+			default:
+				merged = append(merged, res[i])
+				i++
+				j++
+			}
+		}
+		merged = append(merged, res[i:]...)
+		merged = append(merged, list[j:]...)
+		res = merged
+	}
+	return res
+}
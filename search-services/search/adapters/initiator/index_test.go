@@ -0,0 +1,156 @@
+package initiator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"yadro.com/course/search/core"
+)
+
+func TestIntersect(t *testing.T) {
+	got := intersect([]int{1, 3, 5, 7, 9}, []int{3, 4, 5, 9}, []int{5, 9, 11})
+	if !slices.Equal(got, []int{5, 9}) {
+		t.Fatalf("unexpected intersection: %v", got)
+	}
+	if got := intersect([]int{1, 2}, nil); len(got) != 0 {
+		t.Fatalf("expected empty intersection, got %v", got)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	got := union([]int{1, 5, 9}, []int{2, 5, 10}, nil, []int{9, 11})
+	if !slices.Equal(got, []int{1, 2, 5, 9, 10, 11}) {
+		t.Fatalf("unexpected union: %v", got)
+	}
+}
+
+func TestIndex_Candidates(t *testing.T) {
+	idx := buildIndex(map[int]core.Document{
+		1: {TF: map[string]int{"cat": 1, "dog": 1}},
+		2: {TF: map[string]int{"cat": 2}},
+		3: {TF: map[string]int{"dog": 1, "mouse": 1}},
+	}, nil)
+
+	cases := []struct {
+		node core.Node
+		want []int
+	}{
+		{core.TermNode{Stem: "cat"}, []int{1, 2}},
+		{core.TermNode{Stem: "missing"}, []int{}},
+		{core.PhraseNode{Stems: []string{"cat", "dog"}, Offsets: []int{0, 1}}, []int{1}},
+		{core.NearNode{Left: "dog", Right: "mouse", Distance: 1}, []int{3}},
+		{core.BoolNode{Should: []core.Node{core.TermNode{Stem: "cat"}, core.TermNode{Stem: "mouse"}}}, []int{1, 2, 3}},
+		{core.BoolNode{
+			Must:   []core.Node{core.TermNode{Stem: "dog"}},
+			Should: []core.Node{core.TermNode{Stem: "cat"}},
+		}, []int{1, 3}},
+		{core.OrNode{Nodes: []core.Node{core.TermNode{Stem: "mouse"}, core.TermNode{Stem: "cat"}}}, []int{1, 2, 3}},
+		{nil, nil},
+	}
+	for i, c := range cases {
+		if got := idx.candidates(c.node); !slices.Equal(got, c.want) {
+			t.Fatalf("case %d: expected %v, got %v", i, c.want, got)
+		}
+	}
+}
+
+func TestIndex_ScoreMatchesBM25(t *testing.T) {
+	docs := map[int]core.Document{
+		1: {TF: map[string]int{"cat": 3, "dog": 1}, Length: 4},
+		2: {TF: map[string]int{"cat": 1, "fish": 2}, Length: 3},
+	}
+	idx := buildIndex(docs, nil)
+	scorer := core.BM25{K1: 1.2, B: 0.75}
+	words := []string{"cat", "dog"}
+
+	scores := idx.score(scorer, core.Query{Words: words}, []int{1, 2})
+	for i, id := range []int{1, 2} {
+		var want float64
+		for _, word := range words {
+			want += scorer.TermScore(idx.stats, word, docs[id].TF[word], docs[id].Length)
+		}
+		if scores[i] != want {
+			t.Fatalf("comics %d: expected score %v, got %v", id, want, scores[i])
+		}
+	}
+}
+
+// resolvingDB находит в БД любой запрошенный комикс
+type resolvingDB struct {
+	fakeDB
+}
+
+func (r *resolvingDB) GetComicsByIDs(ctx context.Context, ids ...int) ([]core.Comics, error) {
+	res := make([]core.Comics, len(ids))
+	for i, id := range ids {
+		res[i] = core.Comics{ID: id}
+	}
+	return res, nil
+}
+
+// benchInitiator строит индекс из corpus комиксов, где терм "rare" встречается
+// в posting комиксах, а терм "common" - в каждом
+func benchInitiator(b *testing.B, corpus, posting int) *Initiator {
+	b.Helper()
+	step := corpus / posting
+	docs := make(map[int]core.Document, corpus)
+	for id := 1; id <= corpus; id++ {
+		tf := map[string]int{"common": 1, fmt.Sprintf("word%d", id%1000): 1}
+		if id%step == 0 {
+			tf["rare"] = 1
+		}
+		docs[id] = core.Document{TF: tf, Length: len(tf)}
+	}
+	init := newTestInitiator(&resolvingDB{})
+	init.index = buildIndex(docs, nil)
+	return init
+}
+
+// Стоимость запроса определяется размером списка вхождений, а не размером корпуса:
+// при росте корпуса с постоянным posting время почти не меняется
+func BenchmarkInitiator_GetIndexedComics(b *testing.B) {
+	for _, bc := range []struct {
+		corpus, posting int
+	}{
+		{1_000, 10},
+		{100_000, 10},
+		{100_000, 1_000},
+		{100_000, 10_000},
+	} {
+		b.Run(fmt.Sprintf("corpus=%d/posting=%d", bc.corpus, bc.posting), func(b *testing.B) {
+			init := benchInitiator(b, bc.corpus, bc.posting)
+			query := core.Query{Words: []string{"rare"}, Root: core.TermNode{Stem: "rare"}}
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for range b.N {
+				if _, _, err := init.GetIndexedComics(ctx, query, 10, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Пересечение с частым термом стоит как короткий список вхождений
+func BenchmarkInitiator_GetIndexedComics_Intersection(b *testing.B) {
+	for _, corpus := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("corpus=%d/posting=10", corpus), func(b *testing.B) {
+			init := benchInitiator(b, corpus, 10)
+			query := core.Query{
+				Words: []string{"rare", "common"},
+				Root:  core.BoolNode{Must: []core.Node{core.TermNode{Stem: "rare"}, core.TermNode{Stem: "common"}}},
+			}
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for range b.N {
+				if _, _, err := init.GetIndexedComics(ctx, query, 10, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
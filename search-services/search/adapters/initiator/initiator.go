@@ -1,11 +1,14 @@
 package initiator
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"sort"
-	"strconv"
+	"maps"
+	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,124 +16,173 @@ import (
 )
 
 type Initiator struct {
-	log           *slog.Logger
-	indexedComics map[string][]string // id комикса - список слов
-	mu            sync.RWMutex
-	db            core.Storager
-	ttl           time.Duration
-	stopCh        chan struct{}
+	log    *slog.Logger
+	index  *index
+	scorer core.BM25
+	mu     sync.RWMutex // защищает index и checkpoint
+	// checkpoint - номер в потоке последнего события, применённого к индексу
+	checkpoint uint64
+	// updateMu упорядочивает изменения индекса, чтобы перестроение по старому
+	// снимку БД не затёрло более позднее обновление
+	updateMu sync.Mutex
+	db       core.Storager
+	ttl      time.Duration
+	// snapshotPath - файл снимка индекса, пустой путь отключает снимки
+	snapshotPath   string
+	snapshotPeriod time.Duration
+	stopCh         chan struct{}
 }
 
-func NewInitiator(log *slog.Logger, db core.Storager, ttl time.Duration) *Initiator {
+// catchUpRetry - пауза между попытками догнать БД, если при старте она недоступна
+const catchUpRetry = 10 * time.Second
+
+func NewInitiator(
+	log *slog.Logger, db core.Storager, ttl time.Duration, scorer core.BM25,
+	snapshotPath string, snapshotPeriod time.Duration,
+) *Initiator {
 
 	return &Initiator{
-		log:           log,
-		db:            db,
-		indexedComics: make(map[string][]string),
-		ttl:           ttl,
-		stopCh:        make(chan struct{}),
+		log:            log,
+		db:             db,
+		index:          newIndex(),
+		scorer:         scorer,
+		ttl:            ttl,
+		snapshotPath:   snapshotPath,
+		snapshotPeriod: snapshotPeriod,
+		stopCh:         make(chan struct{}),
 	}
 }
 
-func (initiator *Initiator) GetIndexedComics(ctx context.Context, words []string, limit int) ([]core.Comics, error) {
-	initiator.mu.RLock()
-	defer initiator.mu.RUnlock()
-
-	initiator.log.Info("GetIndexedComics called", "words", words, "limit", limit, "indexed_count", len(initiator.indexedComics))
+func (initiator *Initiator) GetIndexedComics(ctx context.Context, query core.Query, limit, offset int) ([]core.Comics, int, error) {
+	words := query.Words
+	initiator.log.Info("GetIndexedComics called", "query", query, "limit", limit, "offset", offset)
 
 	if len(words) == 0 {
-		return []core.Comics{}, nil
+		return []core.Comics{}, 0, nil
 	}
 
-	// Создаем set слов для быстрой проверки
-	wordsSet := make(map[string]bool)
-	for _, word := range words {
-		wordsSet[word] = true
-	}
-
-	// Подсчитываем релевантность для каждого комикса в индексе
-	type comicScore struct {
-		id           int
-		score        int
-		matchedWords int
-		totalWords   int
-		perfectMatch bool
+	ranked := initiator.rank(query)
+	if len(ranked) == 0 {
+		initiator.log.Info("GetIndexedComics no matches", "words", words)
+		return []core.Comics{}, 0, nil
 	}
+	total := len(ranked)
+	initiator.log.Info("GetIndexedComics found matches", "count", total, "words", words)
+	ranked = ranked[min(offset, total):]
 
-	scores := make([]comicScore, 0)
+	// комиксы, которых уже нет в БД, пропускаются, а вместо них берутся следующие по релевантности
+	comics := make([]core.Comics, 0, min(limit, len(ranked)))
+	var missing []int
+	for len(ranked) > 0 && len(comics) < limit {
+		page := ranked[:min(limit-len(comics), len(ranked))]
+		ranked = ranked[len(page):]
 
-	for comicIDStr, comicWords := range initiator.indexedComics {
-		comicID, err := strconv.Atoi(comicIDStr)
+		ids := make([]int, len(page))
+		for i, r := range page {
+			ids[i] = r.id
+		}
+		initiator.log.Info("GetIndexedComics fetching comics", "ids", ids)
+		found, err := initiator.db.GetComicsByIDs(ctx, ids...)
 		if err != nil {
-			continue
+			// пока БД недоступна, поиск отвечает по ссылкам, сохранённым в индексе
+			initiator.log.Error("GetIndexedComics failed to get comics, serving from index", "error", err, "ids", ids)
+			found = initiator.cached(ids)
 		}
 
-		matchedCount := 0
-		totalMatches := 0
-		for _, word := range comicWords {
-			if wordsSet[word] {
-				matchedCount++
-				totalMatches++
+		// БД не сохраняет порядок, восстанавливаем его по релевантности
+		byID := make(map[int]core.Comics, len(found))
+		for _, c := range found {
+			byID[c.ID] = c
+		}
+		for _, r := range page {
+			c, ok := byID[r.id]
+			if !ok {
+				missing = append(missing, r.id)
+				continue
 			}
+			c.Score = r.score
+			comics = append(comics, c)
 		}
+	}
 
-		if matchedCount == 0 {
-			continue
+	if len(missing) > 0 {
+		// комикс мог появиться в БД и попасть в индекс после чтения, поэтому
+		// удаление идёт через UpdateIndex: он заново проверяет комиксы в БД
+		initiator.log.Info("GetIndexedComics removing comics missing in db", "ids", missing)
+		if err := initiator.UpdateIndex(ctx, missing, nil); err != nil {
+			initiator.log.Error("GetIndexedComics failed to remove missing comics", "error", err, "ids", missing)
 		}
+		total -= len(missing)
+	}
 
-		perfectMatch := matchedCount == len(words)
+	initiator.log.Info("GetIndexedComics returning", "count", len(comics), "total", total)
+	return comics, total, nil
+}
 
-		scores = append(scores, comicScore{
-			id:           comicID,
-			score:        totalMatches,
-			matchedWords: matchedCount,
-			totalWords:   len(comicWords),
-			perfectMatch: perfectMatch,
-		})
-	}
+// cached возвращает комиксы ids с данными из индекса
+func (initiator *Initiator) cached(ids []int) []core.Comics {
+	initiator.mu.RLock()
+	defer initiator.mu.RUnlock()
 
-	if len(scores) == 0 {
-		initiator.log.Info("GetIndexedComics no matches", "words", words)
-		return []core.Comics{}, nil
+	comics := make([]core.Comics, 0, len(ids))
+	for _, id := range ids {
+		if url, ok := initiator.index.urls[id]; ok {
+			comics = append(comics, core.Comics{ID: id, URL: url})
+		}
 	}
+	return comics
+}
 
-	initiator.log.Info("GetIndexedComics found matches", "count", len(scores), "words", words)
+type rankedComic struct {
+	id    int
+	score float64
+	exact bool // найден по слову запроса, а не только по нечёткому совпадению
+}
 
-	// Ранжирование
-	sort.Slice(scores, func(i, j int) bool {
-		if scores[i].perfectMatch != scores[j].perfectMatch {
-			return scores[i].perfectMatch
-		}
-		if scores[i].matchedWords != scores[j].matchedWords {
-			return scores[i].matchedWords > scores[j].matchedWords
-		}
-		if scores[i].score != scores[j].score {
-			return scores[i].score > scores[j].score
-		}
-		return scores[i].totalWords < scores[j].totalWords
-	})
+// rank возвращает все подходящие под запрос комиксы по убыванию релевантности
+func (initiator *Initiator) rank(query core.Query) []rankedComic {
+	initiator.mu.RLock()
+	defer initiator.mu.RUnlock()
 
-	comicIDs := make([]int, 0, limit)
-	for i, cs := range scores {
-		if i >= limit {
-			break
-		}
-		comicIDs = append(comicIDs, cs.id)
+	// кандидаты берутся из списков вхождений, а не перебором всего индекса
+	candidates := initiator.index.candidates(query.Root)
+	if !query.Filter.Empty() && len(candidates) > 0 {
+		allowed := initiator.index.filter(query.Filter, candidates[len(candidates)-1])
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(id int) bool { return !allowed.has(id) })
 	}
+	scores := initiator.index.score(initiator.scorer, query, candidates)
 
-	initiator.log.Info("GetIndexedComics fetching comics", "ids", comicIDs)
-	comics, err := initiator.db.GetComicsByIDs(ctx, comicIDs...)
-	if err != nil {
-		initiator.log.Error("GetIndexedComics failed to get comics", "error", err, "ids", comicIDs)
-		return nil, fmt.Errorf("failed to get comics by ids: %w", err)
+	var ranked []rankedComic
+	for i, comicID := range candidates {
+		if scores[i] == 0 || !query.Match(initiator.index.docs[comicID]) {
+			continue
+		}
+		doc := initiator.index.docs[comicID]
+		ranked = append(ranked, rankedComic{id: comicID, score: scores[i], exact: query.Exact(doc)})
 	}
-	initiator.log.Info("GetIndexedComics returning", "count", len(comics))
-	return comics, nil
+
+	// Ранжирование по BM25, комиксы с точными совпадениями выше нечётких
+	slices.SortFunc(ranked, func(a, b rankedComic) int {
+		if a.exact != b.exact {
+			if a.exact {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+	return ranked
 }
 
+// IndexComics строит новый индекс по всем комиксам из БД и подменяет им текущий,
+// поэтому удалённые из БД комиксы пропадают из индекса. Поиск во время построения
+// продолжает работать по старому индексу.
 func (initiator *Initiator) IndexComics(ctx context.Context) error {
-	initiator.mu.Lock()
-	defer initiator.mu.Unlock()
+	initiator.updateMu.Lock()
+	defer initiator.updateMu.Unlock()
 
 	comics, err := initiator.db.GetAllComics(ctx)
 	if err != nil {
@@ -138,47 +190,260 @@ func (initiator *Initiator) IndexComics(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: есть проблема в том, что если комикс удалится из БД, то он останется в
-	// индексе (но у нас нет такого функционала вроде)
+	docs := make(map[int]core.Document, len(comics))
+	urls := make(map[int]string, len(comics))
+	for _, comic := range comics {
+		docs[comic.ID] = core.NewDocument(comic)
+		urls[comic.ID] = comic.URL
+	}
+	// списки вхождений и статистика корпуса для BM25 пересчитываются вместе
+	idx := buildIndex(docs, urls)
+
+	initiator.mu.Lock()
+	initiator.index = idx
+	initiator.mu.Unlock()
+
+	initiator.log.Info("index rebuilt", "indexed", len(docs), "terms", len(idx.postings))
+	return nil
+}
+
+// UpdateIndex переиндексирует только изменившиеся комиксы и удаляет удалённые.
+// Изменённые комиксы, которых уже нет в БД, тоже удаляются из индекса.
+func (initiator *Initiator) UpdateIndex(ctx context.Context, changed, removed []int) error {
+	initiator.updateMu.Lock()
+	defer initiator.updateMu.Unlock()
+
+	var comics []core.Comics
+	if len(changed) > 0 {
+		var err error
+		// комиксы читаются до захвата блокировки индекса, чтобы не задерживать поиск
+		comics, err = initiator.db.GetComicsByIDs(ctx, changed...)
+		if err != nil {
+			initiator.log.Error("failed to get changed comics", "error", err, "ids", changed)
+			return fmt.Errorf("failed to get comics by ids: %w", err)
+		}
+	}
+
+	found := make(map[int]bool, len(comics))
 	for _, comic := range comics {
-		comicIDStr := strconv.Itoa(comic.ID)
-		initiator.indexedComics[comicIDStr] = comic.Words
+		found[comic.ID] = true
+	}
+	removed = slices.Clone(removed)
+	for _, id := range changed {
+		if !found[id] {
+			removed = append(removed, id)
+		}
 	}
 
-	initiator.log.Info("index rebuilt", "comics", len(comics), "indexed", len(initiator.indexedComics))
+	initiator.apply(comics, removed)
 	return nil
 }
 
-func (initiator *Initiator) Start(ctx context.Context) {
-	initiator.log.Info("building index immediately on startup")
-	if err := initiator.IndexComics(ctx); err != nil {
-		initiator.log.Error("failed to build index on startup", "error", err)
+// apply удаляет комиксы removed и добавляет или заменяет комиксы changed
+func (initiator *Initiator) apply(changed []core.Comics, removed []int) {
+	initiator.mu.Lock()
+	defer initiator.mu.Unlock()
+
+	// статистика могла быть отдана через CorpusStats, поэтому меняется её копия
+	initiator.index.stats = initiator.index.stats.Clone()
+	for _, id := range removed {
+		initiator.index.remove(id)
+	}
+	for _, comic := range changed {
+		initiator.index.put(comic.ID, core.NewDocument(comic), comic.URL)
+	}
+
+	initiator.log.Info("index updated", "changed", len(changed), "removed", len(removed), "indexed", len(initiator.index.docs))
+}
+
+// Start догоняет БД по индексу, загруженному из снимка (warm), а если снимка нет,
+// строит индекс по БД. Затем индекс перестраивается раз в ttl и периодически
+// сохраняется в снимок.
+func (initiator *Initiator) Start(ctx context.Context, warm bool) {
+	var err error
+	if warm {
+		err = initiator.catchUp(ctx)
+	} else {
+		initiator.log.Info("building index immediately on startup")
+		err = initiator.IndexComics(ctx)
+	}
+	pending := err != nil
+	if pending {
+		initiator.log.Error("failed to sync index with db on startup, will retry", "error", err)
 	}
 
 	ticker := time.NewTicker(initiator.ttl)
 	defer ticker.Stop()
 
+	var snapshots <-chan time.Time
+	if initiator.snapshotPath != "" && initiator.snapshotPeriod > 0 {
+		snapshotTicker := time.NewTicker(initiator.snapshotPeriod)
+		defer snapshotTicker.Stop()
+		snapshots = snapshotTicker.C
+	}
+
 	for {
+		var retry <-chan time.Time
+		if pending {
+			retry = time.After(catchUpRetry)
+		}
+
 		select {
 		case <-ticker.C:
 			initiator.log.Debug("rebuilding index by timer")
 			if err := initiator.IndexComics(ctx); err != nil {
 				initiator.log.Error("failed to rebuild index", "error", err)
+			} else {
+				pending = false
+			}
+		case <-retry:
+			if err := initiator.catchUp(ctx); err != nil {
+				initiator.log.Error("failed to sync index with db", "error", err)
+			} else {
+				pending = false
+			}
+		case <-snapshots:
+			if err := initiator.SaveSnapshot(); err != nil {
+				initiator.log.Error("failed to save index snapshot", "error", err)
 			}
+		case <-ctx.Done():
+			return
 		case <-initiator.stopCh:
 			initiator.log.Info("stopping index initiator due to Close call")
+			return
+		}
+	}
+}
+
+// SaveSnapshot записывает текущий индекс в файл снимка
+func (initiator *Initiator) SaveSnapshot() error {
+	if initiator.snapshotPath == "" {
+		return nil
+	}
+
+	// документы в индексе не изменяются, а заменяются целиком,
+	// поэтому достаточно скопировать словари под блокировкой
+	initiator.mu.RLock()
+	s := snapshot{
+		CreatedAt:  time.Now(),
+		Checkpoint: initiator.checkpoint,
+		Docs:       maps.Clone(initiator.index.docs),
+		URLs:       maps.Clone(initiator.index.urls),
+	}
+	initiator.mu.RUnlock()
+
+	if err := writeSnapshot(initiator.snapshotPath, s); err != nil {
+		return err
+	}
+	initiator.log.Info("index snapshot saved", "path", initiator.snapshotPath, "indexed", len(s.Docs))
+	return nil
+}
+
+// LoadSnapshot подменяет индекс загруженным из снимка и сообщает, удалось ли это.
+// Вызывается до чтения событий, чтобы они воспроизводились с события после снимка.
+func (initiator *Initiator) LoadSnapshot() bool {
+	if initiator.snapshotPath == "" {
+		return false
+	}
+	s, err := readSnapshot(initiator.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		initiator.log.Info("index snapshot not found", "path", initiator.snapshotPath)
+		return false
+	}
+	if err != nil {
+		initiator.log.Error("failed to load index snapshot", "path", initiator.snapshotPath, "error", err)
+		return false
+	}
+
+	idx := buildIndex(s.Docs, s.URLs)
+
+	initiator.updateMu.Lock()
+	defer initiator.updateMu.Unlock()
+	initiator.mu.Lock()
+	initiator.index = idx
+	initiator.checkpoint = s.Checkpoint
+	initiator.mu.Unlock()
+
+	initiator.log.Info("index loaded from snapshot", "created_at", s.CreatedAt,
+		"checkpoint", s.Checkpoint, "indexed", len(idx.docs), "terms", len(idx.postings))
+	return true
+}
+
+// catchUp сверяет индекс с БД: переиндексирует комиксы, появившиеся после снимка,
+// и удаляет из индекса комиксы, которых в БД больше нет. Изменения комиксов после
+// снимка применяются воспроизведением событий с его checkpoint.
+func (initiator *Initiator) catchUp(ctx context.Context) error {
+	ids, err := initiator.db.IDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get comics ids: %w", err)
+	}
+
+	inDB := make(map[int]bool, len(ids))
+	var added, removed []int
+	initiator.mu.RLock()
+	for _, id := range ids {
+		inDB[id] = true
+		if _, ok := initiator.index.docs[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	for id := range initiator.index.docs {
+		if !inDB[id] {
+			removed = append(removed, id)
 		}
 	}
+	initiator.mu.RUnlock()
+
+	initiator.log.Info("catching up index with db", "added", len(added), "removed", len(removed))
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	return initiator.UpdateIndex(ctx, added, removed)
+}
+
+// Checkpoint возвращает номер последнего применённого события, 0 - событий не было
+func (initiator *Initiator) Checkpoint() uint64 {
+	initiator.mu.RLock()
+	defer initiator.mu.RUnlock()
+	return initiator.checkpoint
+}
+
+// SetCheckpoint запоминает номер события после его применения к индексу,
+// номер сохраняется вместе со снимком
+func (initiator *Initiator) SetCheckpoint(seq uint64) {
+	initiator.mu.Lock()
+	defer initiator.mu.Unlock()
+	initiator.checkpoint = max(initiator.checkpoint, seq)
 }
 
 func (initiator *Initiator) ClearIndex(ctx context.Context) error {
 	initiator.log.Info("clearing index")
+	initiator.updateMu.Lock()
+	defer initiator.updateMu.Unlock()
 	initiator.mu.Lock()
 	defer initiator.mu.Unlock()
-	initiator.indexedComics = make(map[string][]string)
+	initiator.index = newIndex()
 	return nil
 }
 
+func (initiator *Initiator) SimilarTerms(term string, maxDistance int) []core.SimilarTerm {
+	initiator.mu.RLock()
+	defer initiator.mu.RUnlock()
+	return initiator.index.similar(term, maxDistance)
+}
+
+func (initiator *Initiator) Complete(prefix string, limit int) []core.Completion {
+	initiator.mu.RLock()
+	defer initiator.mu.RUnlock()
+	return initiator.index.complete(prefix, limit)
+}
+
+func (initiator *Initiator) CorpusStats() core.CorpusStats {
+	initiator.mu.RLock()
+	defer initiator.mu.RUnlock()
+	return initiator.index.stats
+}
+
 func (initiator *Initiator) Close() error {
 	close(initiator.stopCh)
 	return nil
@@ -2,8 +2,10 @@ package initiator
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"slices"
 	"testing"
 	"time"
 
@@ -16,12 +18,19 @@ type fakeDB struct {
 	comicsByIDs    []core.Comics
 	comicsByIDsErr error
 	lastGetArgs    []int
+	getCalls       [][]int
+	ids            []int
+	idsErr         error
 }
 
-func (f *fakeDB) Search(ctx context.Context, keyword string) ([]int, error) {
+func (f *fakeDB) Search(ctx context.Context, query core.Query, scorer core.BM25, stats core.CorpusStats) ([]core.Ranked, error) {
 	return nil, nil
 }
 
+func (f *fakeDB) FullTextSearch(ctx context.Context, phrase string, filter core.Filter, limit, offset int) ([]core.Comics, int, error) {
+	return nil, 0, nil
+}
+
 func (f *fakeDB) Get(ctx context.Context, id int) (core.Comics, error) {
 	return core.Comics{}, nil
 }
@@ -30,21 +39,33 @@ func (f *fakeDB) GetAllComics(ctx context.Context) ([]core.Comics, error) {
 	return f.allComics, f.allComicsErr
 }
 
+func (f *fakeDB) GetComicsTerms(ctx context.Context, stems []string, ids ...int) ([]core.Comics, error) {
+	return nil, nil
+}
+
 func (f *fakeDB) GetComicsByIDs(ctx context.Context, ids ...int) ([]core.Comics, error) {
 	f.lastGetArgs = ids
+	f.getCalls = append(f.getCalls, ids)
 
 	if f.comicsByIDsErr != nil {
 		return nil, f.comicsByIDsErr
 	}
-	if len(f.comicsByIDs) < len(ids) {
-		return f.comicsByIDs, nil
+	var res []core.Comics
+	for _, c := range f.comicsByIDs {
+		if slices.Contains(ids, c.ID) {
+			res = append(res, c)
+		}
 	}
-	return f.comicsByIDs[:len(ids)], nil
+	return res, nil
+}
+
+func (f *fakeDB) IDs(ctx context.Context) ([]int, error) {
+	return f.ids, f.idsErr
 }
 
 func newTestInitiator(db core.Storager) *Initiator {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	return NewInitiator(logger, db, time.Minute)
+	return NewInitiator(logger, db, time.Minute, core.BM25{K1: 1.2, B: 0.75}, "", 0)
 }
 
 func TestInitiator_IndexComics_BuildsIndex(t *testing.T) {
@@ -64,15 +85,18 @@ func TestInitiator_IndexComics_BuildsIndex(t *testing.T) {
 	init.mu.RLock()
 	defer init.mu.RUnlock()
 
-	if len(init.indexedComics) != 2 {
-		t.Fatalf("expected 2 indexed comics, got %d", len(init.indexedComics))
+	if len(init.index.docs) != 2 {
+		t.Fatalf("expected 2 indexed comics, got %d", len(init.index.docs))
+	}
+	if got := init.index.postings["b"]; !slices.Equal(got.ids, []int{1, 2}) || !slices.Equal(got.tfs, []int{1, 1}) {
+		t.Fatalf("unexpected postings for b: %v", got)
 	}
 }
 
 func TestInitiator_GetIndexedComics_EmptyWords(t *testing.T) {
 	init := newTestInitiator(&fakeDB{})
 
-	res, err := init.GetIndexedComics(context.Background(), nil, 10)
+	res, _, err := init.GetIndexedComics(context.Background(), core.Query{}, 10, 0)
 	if err != nil {
 		t.Fatalf("GetIndexedComics returned error: %v", err)
 	}
@@ -83,17 +107,24 @@ func TestInitiator_GetIndexedComics_EmptyWords(t *testing.T) {
 
 func TestInitiator_GetIndexedComics_ScoringAndLimit(t *testing.T) {
 	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Words: []string{"linux", "cpu"}},
+			{ID: 2, URL: "u2", Words: []string{"linux"}},
+		},
 		comicsByIDs: []core.Comics{
 			{ID: 1, URL: "u1"},
 			{ID: 2, URL: "u2"},
 		},
 	}
 	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
 
-	init.indexedComics["1"] = []string{"linux", "cpu"}
-	init.indexedComics["2"] = []string{"linux"}
-
-	res, err := init.GetIndexedComics(context.Background(), []string{"linux", "cpu"}, 1)
+	res, _, err := init.GetIndexedComics(context.Background(), core.Query{
+		Words: []string{"linux", "cpu"},
+		Root:  core.BoolNode{Should: []core.Node{core.TermNode{Stem: "linux"}, core.TermNode{Stem: "cpu"}}},
+	}, 1, 0)
 	if err != nil {
 		t.Fatalf("GetIndexedComics returned error: %v", err)
 	}
@@ -103,11 +134,39 @@ func TestInitiator_GetIndexedComics_ScoringAndLimit(t *testing.T) {
 	if res[0].ID != 1 {
 		t.Fatalf("expected id=1, got %d", res[0].ID)
 	}
+	if res[0].Score <= 0 {
+		t.Fatalf("expected positive score, got %v", res[0].Score)
+	}
+}
+
+func TestInitiator_IndexComics_CorpusStats(t *testing.T) {
+	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Words: []string{"a", "b"}},
+			{ID: 2, URL: "u2", Words: []string{"b", "c", "d"}},
+		},
+	}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	stats := init.CorpusStats()
+	if stats.Docs != 2 || stats.TotalLength != 5 || stats.DocFreq["b"] != 2 || stats.DocFreq["a"] != 1 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+
+	if err := init.ClearIndex(context.Background()); err != nil {
+		t.Fatalf("ClearIndex returned error: %v", err)
+	}
+	if stats := init.CorpusStats(); stats.Docs != 0 {
+		t.Fatalf("expected empty stats after clear, got %#v", stats)
+	}
 }
 
 func TestInitiator_ClearIndex(t *testing.T) {
 	init := newTestInitiator(&fakeDB{})
-	init.indexedComics["1"] = []string{"a"}
+	init.index = buildIndex(map[int]core.Document{1: {TF: map[string]int{"a": 1}, Length: 1}}, nil)
 
 	err := init.ClearIndex(context.Background())
 	if err != nil {
@@ -117,7 +176,225 @@ func TestInitiator_ClearIndex(t *testing.T) {
 	init.mu.RLock()
 	defer init.mu.RUnlock()
 
-	if len(init.indexedComics) != 0 {
-		t.Fatalf("expected empty index, got %d", len(init.indexedComics))
+	if len(init.index.docs) != 0 || len(init.index.postings) != 0 {
+		t.Fatalf("expected empty index, got %d", len(init.index.docs))
+	}
+}
+
+func TestInitiator_GetIndexedComics_Phrase(t *testing.T) {
+	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Terms: []core.Term{
+				{Field: "title", Term: "binari", TF: 1, Positions: []int{0}},
+				{Field: "title", Term: "tree", TF: 1, Positions: []int{1}},
+			}},
+			{ID: 2, URL: "u2", Terms: []core.Term{
+				{Field: "title", Term: "tree", TF: 1, Positions: []int{0}},
+				{Field: "alt", Term: "binari", TF: 1, Positions: []int{1}},
+			}},
+		},
+		comicsByIDs: []core.Comics{{ID: 1, URL: "u1"}},
+	}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	query := core.Query{
+		Words: []string{"binari", "tree"},
+		Root:  core.PhraseNode{Stems: []string{"binari", "tree"}, Offsets: []int{0, 1}},
+	}
+	res, _, err := init.GetIndexedComics(context.Background(), query, 10, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if len(db.lastGetArgs) != 1 || db.lastGetArgs[0] != 1 {
+		t.Fatalf("expected only comics 1 to match phrase, got %v", db.lastGetArgs)
+	}
+	if len(res) != 1 || res[0].ID != 1 {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+}
+
+func TestInitiator_UpdateIndex(t *testing.T) {
+	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Words: []string{"a", "b"}},
+			{ID: 2, URL: "u2", Words: []string{"b", "c"}},
+		},
+	}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+	before := init.CorpusStats()
+
+	db.comicsByIDs = []core.Comics{{ID: 3, URL: "u3", Words: []string{"c", "d"}}}
+	if err := init.UpdateIndex(context.Background(), []int{3}, []int{1}); err != nil {
+		t.Fatalf("UpdateIndex returned error: %v", err)
+	}
+
+	if !slices.Equal(db.lastGetArgs, []int{3}) {
+		t.Fatalf("expected only changed comics to be fetched, got %v", db.lastGetArgs)
+	}
+	if _, ok := init.index.postings["a"]; ok {
+		t.Fatalf("expected postings of removed comics to be dropped")
+	}
+	if got := init.index.postings["c"]; !slices.Equal(got.ids, []int{2, 3}) {
+		t.Fatalf("unexpected postings for c: %v", got)
+	}
+	stats := init.CorpusStats()
+	if stats.Docs != 2 || stats.DocFreq["c"] != 2 || stats.DocFreq["b"] != 1 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+	if before.Docs != 2 || before.DocFreq["a"] != 1 {
+		t.Fatalf("expected previously returned stats to be unchanged: %#v", before)
+	}
+}
+
+func TestInitiator_UpdateIndex_Error(t *testing.T) {
+	init := newTestInitiator(&fakeDB{comicsByIDsErr: errors.New("db error")})
+	if err := init.UpdateIndex(context.Background(), []int{1}, nil); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestInitiator_IndexComics_DropsDeleted(t *testing.T) {
+	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Words: []string{"a"}},
+			{ID: 2, URL: "u2", Words: []string{"a", "b"}},
+		},
+	}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	db.allComics = db.allComics[:1]
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	if _, ok := init.index.docs[2]; ok || len(init.index.docs) != 1 {
+		t.Fatalf("expected deleted comics to disappear from index, got %d docs", len(init.index.docs))
+	}
+	if _, ok := init.index.postings["b"]; ok {
+		t.Fatalf("expected postings of deleted comics to disappear")
+	}
+	if init.CorpusStats().Docs != 1 {
+		t.Fatalf("unexpected stats: %#v", init.CorpusStats())
+	}
+}
+
+func TestInitiator_GetIndexedComics_SkipsMissing(t *testing.T) {
+	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Words: []string{"linux", "linux", "linux"}},
+			{ID: 2, URL: "u2", Words: []string{"linux", "linux"}},
+			{ID: 3, URL: "u3", Words: []string{"linux"}},
+		},
+		// comics 2 was deleted from DB after indexing
+		comicsByIDs: []core.Comics{{ID: 1, URL: "u1"}, {ID: 3, URL: "u3"}},
+	}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	query := core.Query{Words: []string{"linux"}, Root: core.TermNode{Stem: "linux"}}
+	res, _, err := init.GetIndexedComics(context.Background(), query, 2, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if len(res) != 2 || res[0].ID != 1 || res[1].ID != 3 {
+		t.Fatalf("expected missing comics to be replaced by the next one, got %#v", res)
+	}
+	// the next candidate is fetched, then the missing comics is checked again
+	if len(db.getCalls) != 3 || !slices.Equal(db.getCalls[1], []int{3}) || !slices.Equal(db.getCalls[2], []int{2}) {
+		t.Fatalf("expected the next candidate to be fetched, got %v", db.getCalls)
+	}
+	if _, ok := init.index.docs[2]; ok {
+		t.Fatalf("expected missing comics to be removed from index")
+	}
+}
+
+// appearingDB returns comics appear starting from the second request, as if it
+// was stored between the read of a search and a concurrent update of the index
+type appearingDB struct {
+	fakeDB
+	appear core.Comics
+	gets   int
+}
+
+func (d *appearingDB) GetComicsByIDs(ctx context.Context, ids ...int) ([]core.Comics, error) {
+	if d.gets++; d.gets == 2 {
+		d.comicsByIDs = append(d.comicsByIDs, d.appear)
+	}
+	return d.fakeDB.GetComicsByIDs(ctx, ids...)
+}
+
+func TestInitiator_GetIndexedComics_KeepsComicsStoredMeanwhile(t *testing.T) {
+	comics := core.Comics{ID: 2, URL: "u2", Words: []string{"linux", "linux"}}
+	db := &appearingDB{
+		fakeDB: fakeDB{
+			allComics:   []core.Comics{{ID: 1, URL: "u1", Words: []string{"linux"}}, comics},
+			comicsByIDs: []core.Comics{{ID: 1, URL: "u1", Words: []string{"linux"}}},
+		},
+		appear: comics,
+	}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	query := core.Query{Words: []string{"linux"}, Root: core.TermNode{Stem: "linux"}}
+	if _, _, err := init.GetIndexedComics(context.Background(), query, 2, 0); err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if _, ok := init.index.docs[2]; !ok {
+		t.Fatalf("expected comics stored after the search read to stay in index")
+	}
+}
+
+func TestInitiator_UpdateIndex_RemovesMissingChanged(t *testing.T) {
+	db := &fakeDB{allComics: []core.Comics{{ID: 1, URL: "u1", Words: []string{"a"}}}}
+	init := newTestInitiator(db)
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+
+	if err := init.UpdateIndex(context.Background(), []int{1}, nil); err != nil {
+		t.Fatalf("UpdateIndex returned error: %v", err)
+	}
+	if len(init.index.docs) != 0 {
+		t.Fatalf("expected comics missing in db to be removed, got %d docs", len(init.index.docs))
+	}
+}
+
+func TestInitiator_GetIndexedComics_Offset(t *testing.T) {
+	init := newTestInitiator(&resolvingDB{})
+	docs := make(map[int]core.Document)
+	for id := 1; id <= 5; id++ {
+		// чем больше id, тем чаще терм и выше релевантность
+		docs[id] = core.Document{TF: map[string]int{"a": id}, Length: 5}
+	}
+	init.index = buildIndex(docs, nil)
+
+	query := core.Query{Words: []string{"a"}, Root: core.TermNode{Stem: "a"}}
+	res, total, err := init.GetIndexedComics(context.Background(), query, 2, 1)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if total != 5 || len(res) != 2 || res[0].ID != 4 || res[1].ID != 3 {
+		t.Fatalf("expected comics 4 and 3 of 5, got %v of %d", res, total)
+	}
+
+	res, total, err = init.GetIndexedComics(context.Background(), query, 2, 7)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if total != 5 || len(res) != 0 {
+		t.Fatalf("expected empty page of 5, got %v of %d", res, total)
 	}
 }
@@ -0,0 +1,140 @@
+package initiator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"yadro.com/course/search/core"
+)
+
+// Формат файла снимка:
+//
+//	magic    [4]byte - "XKIX"
+//	version  uint32  - версия формата, snapshotVersion; в версии 2 у документов появились
+//	                    формы термов, в версии 3 - заголовки, в версии 4 - даты публикации
+//	length   uint64  - длина данных в байтах
+//	checksum uint32  - CRC-32C данных
+//	data     []byte  - snapshot в кодировке gob
+//
+// Числа записываются в порядке big-endian.
+const snapshotVersion uint32 = 4
+
+var (
+	snapshotMagic = [4]byte{'X', 'K', 'I', 'X'}
+	castagnoli    = crc32.MakeTable(crc32.Castagnoli)
+
+	errSnapshotFormat   = errors.New("not an index snapshot")
+	errSnapshotVersion  = errors.New("unsupported snapshot version")
+	errSnapshotChecksum = errors.New("snapshot checksum mismatch")
+)
+
+type snapshotHeader struct {
+	Magic    [4]byte
+	Version  uint32
+	Length   uint64
+	Checksum uint32
+}
+
+// snapshot - содержимое индекса, из которого он восстанавливается без обращения к БД.
+// Списки вхождений и статистика корпуса не сохраняются, а строятся заново при загрузке.
+// Checkpoint - номер в потоке последнего события, применённого к индексу:
+// после загрузки события воспроизводятся со следующего номера.
+type snapshot struct {
+	CreatedAt  time.Time
+	Checkpoint uint64
+	Docs       map[int]core.Document
+	URLs       map[int]string
+}
+
+// writeSnapshot атомарно записывает снимок в path: данные пишутся во временный
+// файл рядом с ним, который затем переименовывается
+func writeSnapshot(path string, s snapshot) (err error) {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(s); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	header := snapshotHeader{
+		Magic:    snapshotMagic,
+		Version:  snapshotVersion,
+		Length:   uint64(data.Len()),
+		Checksum: crc32.Checksum(data.Bytes(), castagnoli),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if err := binary.Write(f, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if _, err := f.Write(data.Bytes()); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// readSnapshot читает снимок и проверяет его версию и контрольную сумму
+func readSnapshot(path string) (snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return snapshot{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return snapshot{}, err
+	}
+	var header snapshotHeader
+	if err := binary.Read(f, binary.BigEndian, &header); err != nil {
+		return snapshot{}, fmt.Errorf("%w: %v", errSnapshotFormat, err)
+	}
+	if header.Magic != snapshotMagic {
+		return snapshot{}, errSnapshotFormat
+	}
+	if header.Version != snapshotVersion {
+		return snapshot{}, fmt.Errorf("%w: %d", errSnapshotVersion, header.Version)
+	}
+	// длина сверяется с размером файла, чтобы не выделять память по испорченному заголовку
+	if header.Length != uint64(info.Size())-uint64(binary.Size(header)) {
+		return snapshot{}, fmt.Errorf("%w: length %d does not match file size", errSnapshotFormat, header.Length)
+	}
+
+	data := make([]byte, header.Length)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return snapshot{}, fmt.Errorf("%w: %v", errSnapshotFormat, err)
+	}
+	if crc32.Checksum(data, castagnoli) != header.Checksum {
+		return snapshot{}, errSnapshotChecksum
+	}
+
+	var s snapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return snapshot{}, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return s, nil
+}
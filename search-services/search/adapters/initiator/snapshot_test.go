@@ -0,0 +1,154 @@
+package initiator
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"yadro.com/course/search/core"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.snap")
+	want := snapshot{
+		Docs: map[int]core.Document{
+			1: {TF: map[string]int{"cat": 2}, Length: 2, Positions: core.Positions{"cat": {"title": {0, 3}}}},
+		},
+		URLs: map[int]string{1: "u1"},
+	}
+
+	if err := writeSnapshot(path, want); err != nil {
+		t.Fatalf("writeSnapshot returned error: %v", err)
+	}
+	got, err := readSnapshot(path)
+	if err != nil {
+		t.Fatalf("readSnapshot returned error: %v", err)
+	}
+	doc := got.Docs[1]
+	if doc.Length != 2 || doc.TF["cat"] != 2 || !slices.Equal(doc.Positions["cat"]["title"], []int{0, 3}) {
+		t.Fatalf("unexpected document: %#v", doc)
+	}
+	if got.URLs[1] != "u1" {
+		t.Fatalf("unexpected urls: %v", got.URLs)
+	}
+}
+
+func TestSnapshot_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.snap")
+	if err := writeSnapshot(path, snapshot{URLs: map[int]string{1: "u1"}}); err != nil {
+		t.Fatalf("writeSnapshot returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headerSize := binary.Size(snapshotHeader{})
+
+	cases := []struct {
+		name   string
+		mutate func([]byte) []byte
+		want   error
+	}{
+		{"checksum", func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }, errSnapshotChecksum},
+		{"version", func(b []byte) []byte { binary.BigEndian.PutUint32(b[4:8], snapshotVersion+1); return b }, errSnapshotVersion},
+		{"magic", func(b []byte) []byte { b[0] = 'Y'; return b }, errSnapshotFormat},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-1] }, errSnapshotFormat},
+		{"header", func(b []byte) []byte { return b[:headerSize-1] }, errSnapshotFormat},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			broken := filepath.Join(t.TempDir(), "index.snap")
+			if err := os.WriteFile(broken, c.mutate(slices.Clone(data)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readSnapshot(broken); !errors.Is(err, c.want) {
+				t.Fatalf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestInitiator_WarmStart_CatchesUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.snap")
+	db := &fakeDB{
+		allComics: []core.Comics{
+			{ID: 1, URL: "u1", Words: []string{"a"}},
+			{ID: 2, URL: "u2", Words: []string{"a", "b"}},
+		},
+	}
+	init := newTestInitiator(db)
+	init.snapshotPath = path
+	if err := init.IndexComics(context.Background()); err != nil {
+		t.Fatalf("IndexComics returned error: %v", err)
+	}
+	init.SetCheckpoint(7)
+	init.SetCheckpoint(5)
+	if err := init.SaveSnapshot(); err != nil {
+		t.Fatalf("SaveSnapshot returned error: %v", err)
+	}
+
+	// после снимка комикс 2 удалён, а комикс 3 добавлен
+	db = &fakeDB{
+		ids:         []int{1, 3},
+		comicsByIDs: []core.Comics{{ID: 3, URL: "u3", Words: []string{"b"}}},
+	}
+	restarted := newTestInitiator(db)
+	restarted.snapshotPath = path
+	if !restarted.LoadSnapshot() {
+		t.Fatalf("expected snapshot to be loaded")
+	}
+	if got := restarted.Checkpoint(); got != 7 {
+		t.Fatalf("expected checkpoint 7 to be restored, got %d", got)
+	}
+	if err := restarted.catchUp(context.Background()); err != nil {
+		t.Fatalf("catchUp returned error: %v", err)
+	}
+
+	if !slices.Equal(db.lastGetArgs, []int{3}) {
+		t.Fatalf("expected only new comics to be fetched, got %v", db.lastGetArgs)
+	}
+	restarted.mu.RLock()
+	defer restarted.mu.RUnlock()
+	if got := restarted.index.ids("a"); !slices.Equal(got, []int{1}) {
+		t.Fatalf("unexpected postings for a: %v", got)
+	}
+	if got := restarted.index.ids("b"); !slices.Equal(got, []int{3}) {
+		t.Fatalf("unexpected postings for b: %v", got)
+	}
+	if restarted.index.urls[1] != "u1" || restarted.index.urls[3] != "u3" {
+		t.Fatalf("unexpected urls: %v", restarted.index.urls)
+	}
+}
+
+func TestInitiator_LoadSnapshot_Missing(t *testing.T) {
+	init := newTestInitiator(&fakeDB{})
+	init.snapshotPath = filepath.Join(t.TempDir(), "index.snap")
+	if init.LoadSnapshot() {
+		t.Fatalf("expected missing snapshot not to be loaded")
+	}
+}
+
+func TestInitiator_GetIndexedComics_ServesFromIndexWhenDBDown(t *testing.T) {
+	init := newTestInitiator(&fakeDB{comicsByIDsErr: errors.New("db is down")})
+	init.index = buildIndex(map[int]core.Document{
+		1: {TF: map[string]int{"a": 1}, Length: 1},
+		2: {TF: map[string]int{"a": 2}, Length: 2},
+	}, map[int]string{1: "u1", 2: "u2"})
+
+	comics, _, err := init.GetIndexedComics(context.Background(), core.Query{Words: []string{"a"}, Root: core.TermNode{Stem: "a"}}, 10, 0)
+	if err != nil {
+		t.Fatalf("GetIndexedComics returned error: %v", err)
+	}
+	if len(comics) != 2 {
+		t.Fatalf("expected 2 comics, got %v", comics)
+	}
+	for _, c := range comics {
+		if c.URL != init.index.urls[c.ID] || c.Score == 0 {
+			t.Fatalf("unexpected comics: %#v", c)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package initiator
+
+import (
+	"cmp"
+	"slices"
+
+	"yadro.com/course/search/core"
+)
+
+// trie - префиксное дерево строк словаря с числом комиксов, в которых встречается каждая строка
+type trie struct {
+	root trieNode
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	// count - число комиксов со строкой, заканчивающейся в этом узле
+	count int
+	// text - строка в исходном виде, ключ дерева приводится к нижнему регистру
+	text string
+}
+
+// add увеличивает число комиксов со строкой key
+func (t *trie) add(key, text string) {
+	node := &t.root
+	for _, r := range key {
+		child, ok := node.children[r]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+	}
+	if node.count == 0 {
+		node.text = text
+	}
+	node.count++
+}
+
+// remove уменьшает число комиксов со строкой key и удаляет опустевшие ветви
+func (t *trie) remove(key string) {
+	path := []*trieNode{&t.root}
+	keys := []rune(key)
+	for _, r := range keys {
+		child, ok := path[len(path)-1].children[r]
+		if !ok {
+			return
+		}
+		path = append(path, child)
+	}
+	node := path[len(path)-1]
+	if node.count == 0 {
+		return
+	}
+	if node.count--; node.count == 0 {
+		node.text = ""
+	}
+	for i := len(keys); i > 0 && path[i].count == 0 && len(path[i].children) == 0; i-- {
+		delete(path[i-1].children, keys[i-1])
+	}
+}
+
+// complete вызывает fn для каждой строки, начинающейся с prefix
+func (t *trie) complete(prefix string, fn func(text string, count int)) {
+	node := &t.root
+	for _, r := range prefix {
+		child, ok := node.children[r]
+		if !ok {
+			return
+		}
+		node = child
+	}
+	stack := []*trieNode{node}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node.count > 0 {
+			fn(node.text, node.count)
+		}
+		for _, child := range node.children {
+			stack = append(stack, child)
+		}
+	}
+}
+
+// topCompletions оставляет limit самых частых дополнений, при равенстве - более короткие
+// и слова раньше заголовков
+func topCompletions(completions []core.Completion, limit int) []core.Completion {
+	isTitle := func(c core.Completion) int {
+		if c.Kind == core.CompletionTitle {
+			return 1
+		}
+		return 0
+	}
+	slices.SortFunc(completions, func(a, b core.Completion) int {
+		return cmp.Or(
+			cmp.Compare(b.Frequency, a.Frequency),
+			cmp.Compare(len(a.Text), len(b.Text)),
+			cmp.Compare(isTitle(a), isTitle(b)),
+			cmp.Compare(a.Text, b.Text),
+		)
+	})
+	return completions[:min(limit, len(completions))]
+}
@@ -0,0 +1,63 @@
+package initiator
+
+import (
+	"slices"
+	"testing"
+
+	"yadro.com/course/search/core"
+)
+
+func TestTrie_AddRemoveComplete(t *testing.T) {
+	var tr trie
+	tr.add("link", "link")
+	tr.add("linux", "linux")
+	tr.add("linux", "linux")
+	tr.add("lisp", "lisp")
+
+	complete := func(prefix string) map[string]int {
+		got := map[string]int{}
+		tr.complete(prefix, func(text string, count int) { got[text] = count })
+		return got
+	}
+	if got := complete("lin"); len(got) != 2 || got["linux"] != 2 || got["link"] != 1 {
+		t.Fatalf("unexpected completions: %v", got)
+	}
+
+	tr.remove("linux")
+	tr.remove("link")
+	tr.remove("missing")
+	if got := complete("lin"); len(got) != 1 || got["linux"] != 1 {
+		t.Fatalf("unexpected completions after remove: %v", got)
+	}
+	if _, ok := tr.root.children['l'].children['i'].children['n'].children['k']; ok {
+		t.Fatalf("expected empty branch to be pruned")
+	}
+	if got := complete("x"); len(got) != 0 {
+		t.Fatalf("expected no completions, got %v", got)
+	}
+}
+
+func TestIndex_Complete(t *testing.T) {
+	idx := buildIndex(map[int]core.Document{
+		1: {TF: map[string]int{"linux": 1, "link": 1}, Forms: map[string]string{"link": "links"}, Title: "Linux User at Best Buy"},
+		2: {TF: map[string]int{"linux": 2}, Title: "Supported Features"},
+	}, nil)
+	idx.put(3, core.Document{TF: map[string]int{"linux": 1, "lisp": 1}, Title: "Lisp"}, "u3")
+
+	got := idx.complete("li", 3)
+	want := []core.Completion{
+		{Text: "linux", Kind: core.CompletionWord, Frequency: 3},
+		{Text: "lisp", Kind: core.CompletionWord, Frequency: 1},
+		{Text: "Lisp", Kind: core.CompletionTitle, Frequency: 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	idx.remove(1)
+	got = idx.complete("lin", 10)
+	want = []core.Completion{{Text: "linux", Kind: core.CompletionWord, Frequency: 2}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v after remove, got %v", want, got)
+	}
+}
@@ -2,57 +2,233 @@ package nats
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"yadro.com/course/events"
 	"yadro.com/course/search/core"
 )
 
+const (
+	// setupTimeout ограничивает создание потока и потребителя при старте
+	setupTimeout = 10 * time.Second
+	// redeliveryDelay - пауза перед повторной доставкой необработанного события
+	redeliveryDelay = 5 * time.Second
+)
+
 type natsConn interface {
-	Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscription, error)
 	Drain() error
 }
 
+type consumer interface {
+	Consume(handler jetstream.MessageHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error)
+}
+
+// Listener читает события через долговременного потребителя JetStream.
+// Событие подтверждается только после применения к индексу, поэтому после
+// перезапуска чтение продолжается с последнего подтверждённого события.
+// Если индекс загружен из снимка, события читаются с checkpoint снимка.
 type Listener struct {
-	nc        natsConn
-	log       *slog.Logger
-	initiator core.Initiator
-	topic     string
+	nc         natsConn
+	consumer   consumer
+	consuming  jetstream.ConsumeContext
+	log        *slog.Logger
+	initiator  core.Initiator
+	cache      core.Invalidator
+	checkpoint core.Checkpointer
+	topic      string
+	lastSeq    uint64 // номер последнего полученного сообщения в потоке, 0 - сообщений ещё не было
+	// expired - событий после checkpoint снимка в потоке уже нет
+	expired bool
 }
 
-func NewListener(address string, topic string, log *slog.Logger, initiator core.Initiator) (*Listener, error) {
+func NewListener(address, topic, stream, durable string, log *slog.Logger,
+	initiator core.Initiator, cache core.Invalidator, checkpoint core.Checkpointer,
+) (*Listener, error) {
 	nc, err := nats.Connect(address)
 	if err != nil {
 		return nil, err
 	}
-	return &Listener{nc: nc, log: log, initiator: initiator, topic: topic}, nil
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	s, err := js.CreateOrUpdateStream(ctx, events.StreamConfig(stream, topic))
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to create stream %q: %v", stream, err)
+	}
+	config := jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: topic,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	}
+	lastSeq, expired, err := replayFrom(ctx, s, durable, checkpoint.Checkpoint(), &config, log)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	c, err := s.CreateOrUpdateConsumer(ctx, config)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to create consumer %q: %v", durable, err)
+	}
+	return &Listener{
+		nc:         nc,
+		consumer:   c,
+		log:        log,
+		initiator:  initiator,
+		cache:      cache,
+		checkpoint: checkpoint,
+		topic:      topic,
+		lastSeq:    lastSeq,
+		expired:    expired,
+	}, nil
 }
 
+// Expired сообщает, что событий после checkpoint снимка в потоке уже нет:
+// изменения комиксов после снимка не восстановить, и индекс строится по БД
+func (l *Listener) Expired() bool {
+	return l.expired
+}
+
+// replayFrom настраивает потребителя на чтение событий после checkpoint индекса,
+// загруженного из снимка. Прежний потребитель мог подтвердить события, применённые
+// к индексу уже после снимка, поэтому он пересоздаётся. Возвращает номер, с которым
+// сравнивается первое событие при поиске пропусков, и признак того, что событий
+// после checkpoint в потоке нет.
+func replayFrom(ctx context.Context, s jetstream.Stream, durable string, checkpoint uint64,
+	config *jetstream.ConsumerConfig, log *slog.Logger,
+) (uint64, bool, error) {
+	if checkpoint == 0 {
+		return 0, false, nil
+	}
+	info, err := s.Info(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get stream info: %v", err)
+	}
+	if err := s.DeleteConsumer(ctx, durable); err != nil && !errors.Is(err, jetstream.ErrConsumerNotFound) {
+		return 0, false, fmt.Errorf("failed to reset consumer %q: %v", durable, err)
+	}
+	// поток создан заново, и его номера не связаны со снимком, или события после
+	// checkpoint удалены по возрасту: пропуск не заметить, если новых событий нет
+	if checkpoint > info.State.LastSeq || checkpoint+1 < info.State.FirstSeq {
+		log.Info("events after index checkpoint are not in stream, reading all events",
+			"checkpoint", checkpoint, "first", info.State.FirstSeq, "last", info.State.LastSeq)
+		return 0, true, nil
+	}
+	log.Info("replaying events after index checkpoint", "checkpoint", checkpoint, "last", info.State.LastSeq)
+	config.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+	config.OptStartSeq = checkpoint + 1
+	return checkpoint, false, nil
+}
+
+// Listen начинает чтение событий и сразу возвращает управление
 func (l *Listener) Listen(ctx context.Context) {
-	_, err := l.nc.Subscribe(l.topic, func(msg *nats.Msg) {
-		message := string(msg.Data)
-		l.log.Info("received message", "topic", l.topic, "data", message)
-
-		switch message {
-		case "update":
-			l.log.Info("handling update event, rebuilding index")
-			if err := l.initiator.IndexComics(ctx); err != nil {
-				l.log.Info("failed to rebuild index", "error", err)
-			}
-		case "drop":
-			l.log.Info("handling drop event, clearing index")
-			if err := l.initiator.ClearIndex(ctx); err != nil {
-				l.log.Info("failed to clear index", "error", err)
+	consuming, err := l.consumer.Consume(func(msg jetstream.Msg) {
+		var seq uint64
+		if meta, err := msg.Metadata(); err == nil {
+			seq = meta.Sequence.Stream
+		}
+		if err := l.handle(ctx, msg.Data(), seq); err != nil {
+			l.log.Info("failed to handle event, it will be redelivered", "sequence", seq, "error", err)
+			if err := msg.NakWithDelay(redeliveryDelay); err != nil {
+				l.log.Info("failed to nak event", "error", err)
 			}
+			return
+		}
+		if seq > 0 {
+			l.checkpoint.SetCheckpoint(seq)
+		}
+		if err := msg.Ack(); err != nil {
+			l.log.Info("failed to ack event", "sequence", seq, "error", err)
 		}
-
 	})
-
 	if err != nil {
 		l.log.Info("failed to subscribe", "error", err)
+		return
 	}
+	l.consuming = consuming
+}
+
+// handle применяет событие с номером seq в потоке к индексу. Если содержимое события
+// не удалось разобрать или между событиями пропущены номера, индекс перестраивается полностью.
+func (l *Listener) handle(ctx context.Context, data []byte, seq uint64) error {
+	l.log.Info("received message", "topic", l.topic, "sequence", seq, "data", string(data))
+	// комиксы в БД уже изменились, поэтому кэш сбрасывается, даже если индекс обновить не удалось
+	defer l.cache.Invalidate()
+
+	// повторная доставка имеет тот же номер и не считается пропуском
+	gap := l.lastSeq != 0 && seq > l.lastSeq+1
+	l.lastSeq = max(l.lastSeq, seq)
+
+	msg, err := events.Unmarshal(data)
+	if err != nil {
+		// старый формат: в сообщении только тип события
+		l.log.Info("event without payload, falling back to full reload", "error", err)
+		return l.handleLegacy(ctx, string(data))
+	}
+
+	switch core.EventType(msg.Type) {
+	case core.EventTypeUpdating:
+		if gap {
+			l.log.Info("event sequence gap, rebuilding index", "sequence", seq)
+			return l.reload(ctx)
+		}
+		l.log.Info("handling update event", "job", msg.JobID, "sequence", seq,
+			"added", len(msg.Added), "changed", len(msg.Changed), "removed", len(msg.Removed))
+		changed := append(slices.Clone(msg.Added), msg.Changed...)
+		if err := l.initiator.UpdateIndex(ctx, changed, msg.Removed); err != nil {
+			l.log.Info("failed to update index, rebuilding", "error", err)
+			return l.reload(ctx)
+		}
+		return nil
+	case core.EventTypeDropped:
+		return l.clear(ctx)
+	default:
+		l.log.Info("unknown event type", "type", msg.Type)
+		return nil
+	}
+}
+
+func (l *Listener) handleLegacy(ctx context.Context, message string) error {
+	switch message {
+	case "drop":
+		return l.clear(ctx)
+	default:
+		return l.reload(ctx)
+	}
+}
+
+func (l *Listener) reload(ctx context.Context) error {
+	l.log.Info("handling update event, rebuilding index")
+	if err := l.initiator.IndexComics(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild index: %w", err)
+	}
+	return nil
+}
+
+func (l *Listener) clear(ctx context.Context) error {
+	l.log.Info("handling drop event, clearing index")
+	if err := l.initiator.ClearIndex(ctx); err != nil {
+		return fmt.Errorf("failed to clear index: %w", err)
+	}
+	return nil
 }
 
 func (l *Listener) Close() error {
+	if l.consuming != nil {
+		l.consuming.Stop()
+	}
 	return l.nc.Drain()
 }
@@ -4,130 +4,450 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"yadro.com/course/events"
 	"yadro.com/course/search/core"
 )
 
 type fakeNATSConn struct {
-	subscribeErr error
-	drainErr     error
-	subscribed   []struct {
-		subject string
-		handler nats.MsgHandler
-	}
-}
-
-func (f *fakeNATSConn) Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscription, error) {
-	f.subscribed = append(f.subscribed, struct {
-		subject string
-		handler nats.MsgHandler
-	}{subj, cb})
-	if f.subscribeErr != nil {
-		return nil, f.subscribeErr
-	}
-	return &nats.Subscription{}, nil
+	drainErr error
 }
 
 func (f *fakeNATSConn) Drain() error {
 	return f.drainErr
 }
 
+type fakeConsumer struct {
+	consumeErr error
+}
+
+func (f *fakeConsumer) Consume(handler jetstream.MessageHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	return nil, f.consumeErr
+}
+
 type fakeInitiator struct {
-	indexErr error
-	clearErr error
-	indexed  bool
-	cleared  bool
+	mu        sync.Mutex
+	indexErr  error
+	updateErr error
+	clearErr  error
+	indexed   bool
+	cleared   bool
+	changed   []int
+	removed   []int
+	// invalidated - сколько раз сброшен кэш поиска
+	invalidated int
+	checkpoint  uint64
 }
 
-func (f *fakeInitiator) GetIndexedComics(ctx context.Context, words []string, limit int) ([]core.Comics, error) {
-	return nil, nil
+func (f *fakeInitiator) GetIndexedComics(ctx context.Context, query core.Query, limit, offset int) ([]core.Comics, int, error) {
+	return nil, 0, nil
 }
 
 func (f *fakeInitiator) IndexComics(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.indexed = true
 	return f.indexErr
 }
 
+func (f *fakeInitiator) UpdateIndex(ctx context.Context, changed, removed []int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.changed = append(f.changed, changed...)
+	f.removed = append(f.removed, removed...)
+	return f.updateErr
+}
+
 func (f *fakeInitiator) ClearIndex(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.cleared = true
 	return f.clearErr
 }
 
-func TestListener_Listen_Update(t *testing.T) {
-	fakeConn := &fakeNATSConn{}
-	fakeInit := &fakeInitiator{}
-	l := &Listener{
-		nc:        fakeConn,
-		log:       slog.Default(),
-		initiator: fakeInit,
-		topic:     "test.topic",
-	}
+func (f *fakeInitiator) CorpusStats() core.CorpusStats {
+	return core.NewCorpusStats()
+}
 
-	ctx := context.Background()
-	l.Listen(ctx)
+func (f *fakeInitiator) Checkpoint() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.checkpoint
+}
+
+func (f *fakeInitiator) SetCheckpoint(seq uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkpoint = max(f.checkpoint, seq)
+}
 
-	if len(fakeConn.subscribed) != 1 {
-		t.Fatalf("expected 1 subscription, got %d", len(fakeConn.subscribed))
+func (f *fakeInitiator) SimilarTerms(term string, maxDistance int) []core.SimilarTerm {
+	return nil
+}
+
+func (f *fakeInitiator) Complete(prefix string, limit int) []core.Completion {
+	return nil
+}
+
+func (f *fakeInitiator) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalidated++
+}
+
+func (f *fakeInitiator) changedIDs() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.changed)
+}
+
+func newTestListener(init *fakeInitiator) *Listener {
+	return &Listener{
+		nc:         &fakeNATSConn{},
+		consumer:   &fakeConsumer{},
+		log:        slog.Default(),
+		initiator:  init,
+		cache:      init,
+		checkpoint: init,
+		topic:      "test.topic",
 	}
+}
 
-	msg := &nats.Msg{Data: []byte("update")}
-	fakeConn.subscribed[0].handler(msg)
+func encode(t *testing.T, msg events.Message) []byte {
+	t.Helper()
+	data, err := events.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	return data
+}
 
+func TestListener_Handle_Legacy(t *testing.T) {
+	fakeInit := &fakeInitiator{}
+	if err := newTestListener(fakeInit).handle(context.Background(), []byte("update"), 1); err != nil {
+		t.Fatalf("handle returned error: %v", err)
+	}
 	if !fakeInit.indexed {
 		t.Fatalf("expected IndexComics to be called")
 	}
+
+	fakeInit = &fakeInitiator{}
+	if err := newTestListener(fakeInit).handle(context.Background(), []byte("drop"), 1); err != nil {
+		t.Fatalf("handle returned error: %v", err)
+	}
+	if !fakeInit.cleared {
+		t.Fatalf("expected ClearIndex to be called")
+	}
 }
 
-func TestListener_Listen_Drop(t *testing.T) {
-	fakeConn := &fakeNATSConn{}
+func TestListener_Handle_Incremental(t *testing.T) {
 	fakeInit := &fakeInitiator{}
-	l := &Listener{
-		nc:        fakeConn,
-		log:       slog.Default(),
-		initiator: fakeInit,
-		topic:     "test.topic",
+	l := newTestListener(fakeInit)
+
+	err := l.handle(context.Background(), encode(t, events.Message{
+		Sequence: 1, Type: "update", JobID: 2, Added: []int{3, 4}, Changed: []int{5}, Removed: []int{1},
+	}), 1)
+	if err != nil {
+		t.Fatalf("handle returned error: %v", err)
 	}
 
+	if fakeInit.indexed {
+		t.Fatalf("expected no full reload")
+	}
+	if !slices.Equal(fakeInit.changed, []int{3, 4, 5}) || !slices.Equal(fakeInit.removed, []int{1}) {
+		t.Fatalf("unexpected update: changed %v, removed %v", fakeInit.changed, fakeInit.removed)
+	}
+	if fakeInit.invalidated != 1 {
+		t.Fatalf("expected search cache to be invalidated once, got %d", fakeInit.invalidated)
+	}
+}
+
+func TestListener_Handle_SequenceGap(t *testing.T) {
+	fakeInit := &fakeInitiator{}
+	l := newTestListener(fakeInit)
 	ctx := context.Background()
-	l.Listen(ctx)
 
-	if len(fakeConn.subscribed) != 1 {
-		t.Fatalf("expected 1 subscription, got %d", len(fakeConn.subscribed))
+	_ = l.handle(ctx, encode(t, events.Message{Type: "update", Added: []int{1}}), 1)
+	_ = l.handle(ctx, encode(t, events.Message{Type: "update", Added: []int{2}}), 2)
+	// redelivery is not a gap
+	_ = l.handle(ctx, encode(t, events.Message{Type: "update", Added: []int{2}}), 2)
+	if fakeInit.indexed {
+		t.Fatalf("expected no full reload for consecutive events")
 	}
 
-	msg := &nats.Msg{Data: []byte("drop")}
-	fakeConn.subscribed[0].handler(msg)
+	_ = l.handle(ctx, encode(t, events.Message{Type: "update", Added: []int{4}}), 4)
+	if !fakeInit.indexed {
+		t.Fatalf("expected full reload after sequence gap")
+	}
+	if !slices.Equal(fakeInit.changed, []int{1, 2, 2}) {
+		t.Fatalf("expected event after gap not to be applied incrementally, got %v", fakeInit.changed)
+	}
+}
 
-	if !fakeInit.cleared {
-		t.Fatalf("expected ClearIndex to be called")
+func TestListener_Handle_Fallbacks(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		init *fakeInitiator
+	}{
+		{"unsupported version", []byte(`{"version":99,"type":"update"}`), &fakeInitiator{}},
+		{"failed update", encode(t, events.Message{Type: "update", Added: []int{1}}),
+			&fakeInitiator{updateErr: errors.New("db error")}},
+	}
+	for _, c := range cases {
+		if err := newTestListener(c.init).handle(context.Background(), c.data, 1); err != nil {
+			t.Fatalf("%s: handle returned error: %v", c.name, err)
+		}
+		if !c.init.indexed {
+			t.Fatalf("%s: expected full reload", c.name)
+		}
 	}
 }
 
-func TestListener_Listen_SubscribeError(t *testing.T) {
-	fakeConn := &fakeNATSConn{subscribeErr: errors.New("nats error")}
+func TestListener_Handle_Errors(t *testing.T) {
+	fakeInit := &fakeInitiator{updateErr: errors.New("db error"), indexErr: errors.New("db error")}
+	err := newTestListener(fakeInit).handle(context.Background(), encode(t, events.Message{Type: "update", Added: []int{1}}), 1)
+	if err == nil {
+		t.Fatalf("expected error to get event redelivered")
+	}
+	if fakeInit.invalidated != 1 {
+		t.Fatalf("expected search cache to be invalidated after failed update")
+	}
+
+	fakeInit = &fakeInitiator{clearErr: errors.New("db error")}
+	if err := newTestListener(fakeInit).handle(context.Background(), encode(t, events.Message{Type: "drop"}), 1); err == nil {
+		t.Fatalf("expected error to get event redelivered")
+	}
+}
+
+func TestListener_Handle_StructuredDrop(t *testing.T) {
 	fakeInit := &fakeInitiator{}
-	l := &Listener{
-		nc:        fakeConn,
-		log:       slog.Default(),
-		initiator: fakeInit,
-		topic:     "test.topic",
+	err := newTestListener(fakeInit).handle(context.Background(), encode(t, events.Message{Type: "drop"}), 1)
+	if err != nil {
+		t.Fatalf("handle returned error: %v", err)
 	}
+	if !fakeInit.cleared || fakeInit.indexed {
+		t.Fatalf("expected only ClearIndex to be called")
+	}
+}
 
+func TestListener_Listen_ConsumeError(t *testing.T) {
+	l := newTestListener(&fakeInitiator{})
+	l.consumer = &fakeConsumer{consumeErr: errors.New("nats error")}
+
+	l.Listen(context.Background())
+	if l.consuming != nil {
+		t.Fatalf("expected no consume context on error")
+	}
+}
+
+func TestListener_Close(t *testing.T) {
+	l := newTestListener(&fakeInitiator{})
+
+	err := l.Close()
+	if err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+}
+
+func runJetStream(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create nats server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server is not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func waitChanged(t *testing.T, init *fakeInitiator, want []int) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for !slices.Equal(init.changedIDs(), want) {
+		select {
+		case <-deadline:
+			t.Fatalf("expected changed %v, got %v", want, init.changedIDs())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestListener_JetStream_ReplayAfterRestart(t *testing.T) {
+	const topic = "test.topic"
+	s := runJetStream(t)
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("failed to create jetstream: %v", err)
+	}
 	ctx := context.Background()
+	publish := func(id int) {
+		t.Helper()
+		data := encode(t, events.Message{Sequence: int64(id), Type: "update", Added: []int{id}})
+		if _, err := js.Publish(ctx, topic, data); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	first := &fakeInitiator{}
+	l, err := NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), first, first, first)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
 	l.Listen(ctx)
+	publish(1)
+	publish(2)
+	waitChanged(t, first, []int{1, 2})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// events published while search is down are delivered after restart
+	publish(3)
+
+	second := &fakeInitiator{}
+	l, err = NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), second, second, second)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	defer l.Close()
+	l.Listen(ctx)
+	waitChanged(t, second, []int{3})
 }
 
-func TestListener_Close(t *testing.T) {
-	fakeConn := &fakeNATSConn{}
-	l := &Listener{
-		nc:  fakeConn,
-		log: slog.Default(),
+func TestListener_JetStream_ReplayFromCheckpoint(t *testing.T) {
+	const topic = "test.topic"
+	s := runJetStream(t)
+	ctx := context.Background()
+
+	first := &fakeInitiator{}
+	l, err := NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), first, first, first)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	js, err := jetstream.New(l.nc.(*nats.Conn))
+	if err != nil {
+		t.Fatalf("failed to create jetstream: %v", err)
+	}
+	l.Listen(ctx)
+	for id := 1; id <= 3; id++ {
+		data := encode(t, events.Message{Sequence: int64(id), Type: "update", Added: []int{id}})
+		if _, err := js.Publish(ctx, topic, data); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	waitChanged(t, first, []int{1, 2, 3})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if got := first.Checkpoint(); got != 3 {
+		t.Fatalf("expected checkpoint of the last applied event, got %d", got)
 	}
 
-	err := l.Close()
+	// the index is loaded from a snapshot taken after the first event:
+	// events 2 and 3 are already acked but applied again
+	second := &fakeInitiator{checkpoint: 1}
+	l, err = NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), second, second, second)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	defer l.Close()
+	l.Listen(ctx)
+	waitChanged(t, second, []int{2, 3})
+	if second.indexed {
+		t.Fatalf("expected no full reload when replaying from checkpoint")
+	}
+}
+
+func TestListener_JetStream_CheckpointAheadOfStream(t *testing.T) {
+	s := runJetStream(t)
+
+	// the stream is empty, e.g. NATS data was lost, so the snapshot checkpoint is not in it
+	init := &fakeInitiator{checkpoint: 10}
+	l, err := NewListener(s.ClientURL(), "test.topic", "TEST_EVENTS", "search", slog.Default(), init, init, init)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	defer l.Close()
+	if l.lastSeq != 0 || !l.Expired() {
+		t.Fatalf("expected all events to be read and the snapshot to be dropped, got last sequence %d", l.lastSeq)
+	}
+}
+
+func TestListener_JetStream_EventsAfterCheckpointExpired(t *testing.T) {
+	const topic = "test.topic"
+	s := runJetStream(t)
+	ctx := context.Background()
+
+	first := &fakeInitiator{}
+	l, err := NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), first, first, first)
 	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	js, err := jetstream.New(l.nc.(*nats.Conn))
+	if err != nil {
+		t.Fatalf("failed to create jetstream: %v", err)
+	}
+	for id := 1; id <= 3; id++ {
+		data := encode(t, events.Message{Sequence: int64(id), Type: "update", Changed: []int{id}})
+		if _, err := js.Publish(ctx, topic, data); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+	// events are removed by age, the stream keeps only their numbers
+	stream, err := js.Stream(ctx, "TEST_EVENTS")
+	if err != nil {
+		t.Fatalf("failed to get stream: %v", err)
+	}
+	if err := stream.Purge(ctx); err != nil {
+		t.Fatalf("failed to purge stream: %v", err)
+	}
+	if err := l.Close(); err != nil {
 		t.Fatalf("Close returned error: %v", err)
 	}
+
+	// the snapshot was taken after the first event, changes 2 and 3 are lost
+	second := &fakeInitiator{checkpoint: 1}
+	l, err = NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), second, second, second)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	defer l.Close()
+	if !l.Expired() {
+		t.Fatalf("expected the snapshot to be dropped when events after its checkpoint expired")
+	}
+
+	// the snapshot is up to date with the stream
+	third := &fakeInitiator{checkpoint: 3}
+	l, err = NewListener(s.ClientURL(), topic, "TEST_EVENTS", "search", slog.Default(), third, third, third)
+	if err != nil {
+		t.Fatalf("NewListener returned error: %v", err)
+	}
+	defer l.Close()
+	if l.Expired() || l.lastSeq != 3 {
+		t.Fatalf("expected replay after the last event, got expired %t, last sequence %d", l.Expired(), l.lastSeq)
+	}
 }
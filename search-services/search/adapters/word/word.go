@@ -40,6 +40,27 @@ func (c *Client) Norm(ctx context.Context, phrase string) ([]string, error) {
 	return resp.Words, nil
 }
 
+func (c *Client) NormDetailed(ctx context.Context, phrase string) ([]core.Token, error) {
+	resp, err := c.client.NormDetailed(ctx, &wordspb.WordsRequest{Phrase: phrase})
+	if err != nil {
+		if status.Code(err) == codes.ResourceExhausted {
+			return nil, core.ErrBadArguments
+		}
+		return nil, err
+	}
+
+	tokens := make([]core.Token, 0, len(resp.Tokens))
+	for _, t := range resp.Tokens {
+		tokens = append(tokens, core.Token{
+			Surface:  t.Surface,
+			Stem:     t.Stem,
+			Position: int(t.Position),
+			StopWord: t.StopWord,
+		})
+	}
+	return tokens, nil
+}
+
 func (c *Client) Close() error {
 	return c.conn.Close()
 }
@@ -15,8 +15,9 @@ import (
 )
 
 type fakeWordsClient struct {
-	normRep *wordspb.WordsReply
-	normErr error
+	normRep   *wordspb.WordsReply
+	tokensRep *wordspb.TokensReply
+	normErr   error
 }
 
 func (f fakeWordsClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
@@ -27,6 +28,10 @@ func (f fakeWordsClient) Norm(ctx context.Context, in *wordspb.WordsRequest, opt
 	return f.normRep, f.normErr
 }
 
+func (f fakeWordsClient) NormDetailed(ctx context.Context, in *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.TokensReply, error) {
+	return f.tokensRep, f.normErr
+}
+
 func newSearchWordsClient(f fakeWordsClient) *Client {
 	logger := slog.Default()
 	return &Client{
@@ -61,3 +66,25 @@ func TestClient_Norm_BadArguments(t *testing.T) {
 		t.Fatalf("expected ErrBadArguments, got %v", err)
 	}
 }
+
+func TestClient_NormDetailed(t *testing.T) {
+	c := newSearchWordsClient(fakeWordsClient{
+		tokensRep: &wordspb.TokensReply{Tokens: []*wordspb.Token{
+			{Surface: "of", Stem: "of", Position: 0, StopWord: true},
+			{Surface: "trees", Stem: "tree", Position: 1},
+		}},
+	})
+
+	res, err := c.NormDetailed(context.Background(), "of trees")
+	if err != nil {
+		t.Fatalf("NormDetailed returned error: %v", err)
+	}
+	if len(res) != 2 || !res[0].StopWord || res[1].Stem != "tree" || res[1].Position != 1 {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+
+	c = newSearchWordsClient(fakeWordsClient{normErr: errors.New("unavailable")})
+	if _, err := c.NormDetailed(context.Background(), "phrase"); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
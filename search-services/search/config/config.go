@@ -13,9 +13,20 @@ type Config struct {
 	DBAddress    string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
 	WordsAddress string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
 
-	IndexTTL      time.Duration `yaml:"index_ttl" env:"INDEX_TTL" env-default:"24h"`
-	BrokerAddress string        `yaml:"broker_address" env:"BROKER_ADDRESS" env-default:"nats://nats:4222"`
-	Topic         string        `yaml:"topic" env:"TOPIC" env-default:"xkcd.db.updated"`
+	IndexTTL       time.Duration `yaml:"index_ttl" env:"INDEX_TTL" env-default:"24h"`
+	SnapshotPath   string        `yaml:"snapshot_path" env:"SNAPSHOT_PATH"`
+	SnapshotPeriod time.Duration `yaml:"snapshot_period" env:"SNAPSHOT_PERIOD" env-default:"10m"`
+	BrokerAddress  string        `yaml:"broker_address" env:"BROKER_ADDRESS" env-default:"nats://nats:4222"`
+	Topic          string        `yaml:"topic" env:"TOPIC" env-default:"xkcd.db.updated"`
+	Stream         string        `yaml:"stream" env:"STREAM" env-default:"XKCD_EVENTS"`
+	Durable        string        `yaml:"durable" env:"DURABLE" env-default:"search"`
+
+	CacheSize        int           `yaml:"cache_size" env:"CACHE_SIZE" env-default:"1000"`
+	CacheTTL         time.Duration `yaml:"cache_ttl" env:"CACHE_TTL" env-default:"5m"`
+	CacheStatsPeriod time.Duration `yaml:"cache_stats_period" env:"CACHE_STATS_PERIOD" env-default:"1m"`
+
+	BM25K1 float64 `yaml:"bm25_k1" env:"BM25_K1" env-default:"1.2"`
+	BM25B  float64 `yaml:"bm25_b" env:"BM25_B" env-default:"0.75"`
 }
 
 func MustLoad(configPath string) Config {
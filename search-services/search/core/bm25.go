@@ -0,0 +1,86 @@
+package core
+
+import (
+	"maps"
+	"math"
+)
+
+// BM25 ranks documents by the Okapi BM25 formula.
+// K1 controls term frequency saturation, B controls document length normalization.
+type BM25 struct {
+	K1 float64
+	B  float64
+}
+
+// CorpusStats describes the indexed collection and is needed to compute
+// term rarity and average document length.
+type CorpusStats struct {
+	Docs        int
+	TotalLength int
+	DocFreq     map[string]int
+}
+
+func NewCorpusStats() CorpusStats {
+	return CorpusStats{DocFreq: map[string]int{}}
+}
+
+// Add accounts a document given by its term frequencies.
+func (s *CorpusStats) Add(tf map[string]int) {
+	s.Docs++
+	for term, n := range tf {
+		s.TotalLength += n
+		s.DocFreq[term]++
+	}
+}
+
+// Remove undoes Add for a document with term frequencies tf.
+func (s *CorpusStats) Remove(tf map[string]int) {
+	s.Docs--
+	for term, n := range tf {
+		s.TotalLength -= n
+		if s.DocFreq[term]--; s.DocFreq[term] <= 0 {
+			delete(s.DocFreq, term)
+		}
+	}
+}
+
+// Clone returns a copy that can be changed independently.
+func (s CorpusStats) Clone() CorpusStats {
+	s.DocFreq = maps.Clone(s.DocFreq)
+	return s
+}
+
+func (s CorpusStats) AvgLength() float64 {
+	if s.Docs == 0 {
+		return 0
+	}
+	return float64(s.TotalLength) / float64(s.Docs)
+}
+
+// IDF is always positive so that very common terms never lower the score.
+func (s CorpusStats) IDF(term string) float64 {
+	df := float64(s.DocFreq[term])
+	return math.Log(1 + (float64(s.Docs)-df+0.5)/(df+0.5))
+}
+
+// TermScore is the contribution of a single term with frequency tf
+// to the score of a document with the given length.
+func (b BM25) TermScore(stats CorpusStats, term string, tf, length int) float64 {
+	if tf == 0 {
+		return 0
+	}
+	norm := 1.0
+	if avg := stats.AvgLength(); avg > 0 {
+		norm = 1 - b.B + b.B*float64(length)/avg
+	}
+	f := float64(tf)
+	return stats.IDF(term) * f * (b.K1 + 1) / (f + b.K1*norm)
+}
+
+func TermFrequencies(terms []string) map[string]int {
+	tf := make(map[string]int, len(terms))
+	for _, term := range terms {
+		tf[term]++
+	}
+	return tf
+}
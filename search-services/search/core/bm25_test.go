@@ -0,0 +1,61 @@
+package core
+
+import "testing"
+
+func TestBM25_TermScore(t *testing.T) {
+	stats := NewCorpusStats()
+	stats.Add(TermFrequencies([]string{"linux", "kernel"}))
+	stats.Add(TermFrequencies([]string{"linux", "cpu", "fan", "case", "power"}))
+	stats.Add(TermFrequencies([]string{"window", "door"}))
+
+	bm := BM25{K1: 1.2, B: 0.75}
+	short := bm.TermScore(stats, "linux", 1, 2)
+	long := bm.TermScore(stats, "linux", 1, 5)
+	if short <= long {
+		t.Fatalf("expected shorter document to score higher: %v <= %v", short, long)
+	}
+
+	if s := bm.TermScore(stats, "linux", 0, 2); s != 0 {
+		t.Fatalf("expected zero score without matches, got %v", s)
+	}
+
+	if stats.IDF("kernel") <= stats.IDF("linux") {
+		t.Fatalf("expected rare term to have higher IDF")
+	}
+}
+
+func TestBM25_TermFrequencySaturation(t *testing.T) {
+	stats := NewCorpusStats()
+	stats.Add(map[string]int{"a": 1})
+	stats.Add(map[string]int{"b": 1})
+
+	bm := BM25{K1: 1.2, B: 0}
+	one := bm.TermScore(stats, "a", 1, 1)
+	two := bm.TermScore(stats, "a", 2, 1)
+	many := bm.TermScore(stats, "a", 100, 1)
+	if !(one < two && two < many) {
+		t.Fatalf("expected score to grow with tf: %v %v %v", one, two, many)
+	}
+	if many > one*(bm.K1+1) {
+		t.Fatalf("expected score to saturate, got %v", many)
+	}
+}
+
+func TestCorpusStats_RemoveAndClone(t *testing.T) {
+	stats := NewCorpusStats()
+	stats.Add(map[string]int{"a": 2, "b": 1})
+	stats.Add(map[string]int{"a": 1})
+
+	clone := stats.Clone()
+	clone.Remove(map[string]int{"a": 2, "b": 1})
+
+	if clone.Docs != 1 || clone.TotalLength != 1 || clone.DocFreq["a"] != 1 {
+		t.Fatalf("unexpected stats after remove: %#v", clone)
+	}
+	if _, ok := clone.DocFreq["b"]; ok {
+		t.Fatalf("expected term without documents to be removed: %#v", clone.DocFreq)
+	}
+	if stats.Docs != 2 || stats.DocFreq["b"] != 1 {
+		t.Fatalf("expected original stats to be unchanged: %#v", stats)
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	modeDB    = "db"
+	modeIndex = "index"
+)
+
+// CacheStats are counters of the query cache, Size is the number of cached pages
+type CacheStats struct {
+	Hits   int
+	Misses int
+	Size   int
+}
+
+// QueryCache is a bounded LRU cache of ranked comics IDs of normalized queries.
+// Pages expire after ttl and all of them are dropped when comics change.
+// A nil cache caches nothing.
+type QueryCache struct {
+	mu     sync.Mutex
+	size   int
+	ttl    time.Duration
+	pages  map[string]*list.Element
+	recent *list.List // of *cachedPage, the most recently used first
+	stats  CacheStats
+	now    func() time.Time
+	// generation is increased by Invalidate, so pages searched before
+	// the invalidation are not put back
+	generation uint64
+}
+
+type cachedPage struct {
+	key     string
+	ranked  []Ranked
+	total   int
+	expires time.Time
+}
+
+// NewQueryCache creates a cache of size pages, zero ttl does not expire them.
+// Non-positive size disables caching.
+func NewQueryCache(size int, ttl time.Duration) *QueryCache {
+	if size <= 0 {
+		return nil
+	}
+	return &QueryCache{
+		size:   size,
+		ttl:    ttl,
+		pages:  make(map[string]*list.Element, size),
+		recent: list.New(),
+		now:    time.Now,
+	}
+}
+
+// cacheKey identifies a page of the normalized query searched in the mode
+func cacheKey(mode string, query Query, limit, offset int) string {
+	f := query.Filter
+	return fmt.Sprintf("%s\x00%d\x00%d\x00%s\x00%s\x00%d\x00%d\x00%q\x00%#v\x00%#v",
+		mode, limit, offset, f.From.Format(time.DateOnly), f.To.Format(time.DateOnly), f.MinID, f.MaxID,
+		query.Words, query.Weights, query.Root)
+}
+
+// get returns the page by key and the generation of the cache to put the page
+// searched on a miss
+func (c *QueryCache) get(key string) (cachedPage, uint64, bool) {
+	if c == nil {
+		return cachedPage{}, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.pages[key]
+	if ok && c.ttl > 0 && c.now().After(e.Value.(*cachedPage).expires) {
+		c.remove(e)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return cachedPage{}, c.generation, false
+	}
+	c.stats.Hits++
+	c.recent.MoveToFront(e)
+	return *e.Value.(*cachedPage), c.generation, true
+}
+
+// put caches ranked comics of the page unless the cache was invalidated after get
+func (c *QueryCache) put(key string, generation uint64, comics []Comics, total int) {
+	if c == nil {
+		return
+	}
+	page := &cachedPage{
+		key:    key,
+		ranked: make([]Ranked, len(comics)),
+		total:  total,
+	}
+	for i, comic := range comics {
+		page.ranked[i] = Ranked{ID: comic.ID, Score: comic.Score}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	page.expires = c.now().Add(c.ttl)
+	if e, ok := c.pages[key]; ok {
+		e.Value = page
+		c.recent.MoveToFront(e)
+		return
+	}
+	c.pages[key] = c.recent.PushFront(page)
+	for c.recent.Len() > c.size {
+		c.remove(c.recent.Back())
+	}
+}
+
+func (c *QueryCache) remove(e *list.Element) {
+	c.recent.Remove(e)
+	delete(c.pages, e.Value.(*cachedPage).key)
+}
+
+// Invalidate drops all cached pages
+func (c *QueryCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.pages = make(map[string]*list.Element, c.size)
+	c.recent.Init()
+}
+
+func (c *QueryCache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.recent.Len()
+	return stats
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQueryCache_LRUAndTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewQueryCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b"} {
+		_, generation, _ := c.get(key)
+		c.put(key, generation, []Comics{{ID: len(key), Score: 1.5}}, 7)
+	}
+	if page, _, ok := c.get("a"); !ok || !slices.Equal(page.ranked, []Ranked{{ID: 1, Score: 1.5}}) || page.total != 7 {
+		t.Fatalf("expected cached page, got %+v, %t", page, ok)
+	}
+	// "b" is the least recently used
+	c.put("c", 0, nil, 0)
+	if _, _, ok := c.get("b"); ok {
+		t.Fatalf("expected the least recently used page to be evicted")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, _, ok := c.get("a"); ok {
+		t.Fatalf("expected expired page to be missed")
+	}
+	if stats := c.Stats(); stats != (CacheStats{Hits: 1, Misses: 4, Size: 1}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestQueryCache_Invalidate(t *testing.T) {
+	c := NewQueryCache(10, 0)
+	_, generation, _ := c.get("a")
+	c.put("a", generation, []Comics{{ID: 1}}, 1)
+
+	_, stale, _ := c.get("b")
+	c.Invalidate()
+	c.put("b", stale, []Comics{{ID: 2}}, 1)
+	if _, _, ok := c.get("a"); ok {
+		t.Fatalf("expected cache to be empty after invalidation")
+	}
+	if _, _, ok := c.get("b"); ok {
+		t.Fatalf("expected page searched before invalidation not to be cached")
+	}
+}
+
+func TestQueryCache_Disabled(t *testing.T) {
+	c := NewQueryCache(0, time.Minute)
+	c.put("a", 0, []Comics{{ID: 1}}, 1)
+	if _, _, ok := c.get("a"); ok || c.Stats() != (CacheStats{}) {
+		t.Fatalf("expected disabled cache")
+	}
+	c.Invalidate()
+}
+
+func TestCacheKey(t *testing.T) {
+	query := Query{Words: []string{"linux"}, Root: TermNode{Stem: "linux"}}
+	key := cacheKey(modeDB, query, 10, 0)
+
+	filtered := query
+	filtered.Filter = Filter{MinID: 5}
+	other := []string{
+		cacheKey(modeIndex, query, 10, 0),
+		cacheKey(modeDB, query, 20, 0),
+		cacheKey(modeDB, query, 10, 10),
+		cacheKey(modeDB, filtered, 10, 0),
+		cacheKey(modeDB, Query{Words: []string{"linux"}, Root: TermNode{Field: "title", Stem: "linux"}}, 10, 0),
+	}
+	for _, k := range other {
+		if k == key {
+			t.Fatalf("expected different keys, got %q", k)
+		}
+	}
+	if cacheKey(modeDB, query, 10, 0) != key {
+		t.Fatalf("expected equal queries to have equal keys")
+	}
+}
+
+func TestService_Search_Cache(t *testing.T) {
+	var searches int
+	db := fakeStorager{
+		searchResults: map[string][]int{"linux": {1, 2}},
+		comics: map[int]Comics{
+			1: {ID: 1, Words: []string{"linux"}},
+			2: {ID: 2, Words: []string{"linux", "linux"}},
+		},
+		searches: &searches,
+	}
+	cache := NewQueryCache(10, time.Minute)
+	s, err := NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), db, fakeWords{}, fakeInitiator{}, BM25{K1: 1.2, B: 0.75}, cache)
+	if err != nil {
+		t.Fatalf("NewService returned error: %v", err)
+	}
+
+	req := SearchRequest{Phrase: "linux", Limit: 10}
+	first, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	second, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if searches != 1 {
+		t.Fatalf("expected one DB search, got %d", searches)
+	}
+	if len(second.Comics) != 2 || second.Total != 2 || second.Comics[0].ID != first.Comics[0].ID ||
+		second.Comics[0].Score != first.Comics[0].Score {
+		t.Fatalf("expected cached result %+v, got %+v", first, second)
+	}
+
+	s.Invalidate()
+	if _, err := s.Search(context.Background(), req); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if searches != 2 {
+		t.Fatalf("expected search after invalidation, got %d searches", searches)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
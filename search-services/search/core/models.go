@@ -1,5 +1,10 @@
 package core
 
+import (
+	"fmt"
+	"time"
+)
+
 type EventType string
 
 const (
@@ -13,8 +18,145 @@ type DBStats struct {
 	ComicsFetched int
 }
 
+type Token struct {
+	Surface  string
+	Stem     string
+	Position int
+	StopWord bool
+}
+
+// Term is occurrence statistics of a stem within one field of comics.
+// Surface is the most frequent form of the stem in the field, if known.
+type Term struct {
+	Field     string
+	Term      string
+	Surface   string
+	TF        int
+	Positions []int
+}
+
+// SimilarTerm is a vocabulary term and its edit distance from a query term.
+// Form is the most frequent surface form of the term, Frequency is the number of comics with it.
+type SimilarTerm struct {
+	Term      string
+	Form      string
+	Distance  int
+	Frequency int
+}
+
+type CompletionKind string
+
+const (
+	CompletionWord  CompletionKind = "word"
+	CompletionTitle CompletionKind = "title"
+)
+
+// Completion is an indexed word or comics title starting with a prefix.
+// Frequency is the number of comics with it.
+type Completion struct {
+	Text      string
+	Kind      CompletionKind
+	Frequency int
+}
+
+// Filter restricts search to comics published in [From, To) with IDs in [MinID, MaxID].
+// Dates are UTC days, zero values do not restrict. Comics without a publication
+// date do not pass a date restriction.
+type Filter struct {
+	From  time.Time
+	To    time.Time
+	MinID int
+	MaxID int
+}
+
+func (f Filter) Empty() bool {
+	return f.MinID == 0 && f.MaxID == 0 && !f.Dated()
+}
+
+func (f Filter) Dated() bool {
+	return !f.From.IsZero() || !f.To.IsZero()
+}
+
+// validate checks that the ranges of the filter are not empty
+func (f Filter) validate() error {
+	if f.MinID < 0 || f.MaxID < 0 {
+		return fmt.Errorf("%w: negative comics id", ErrBadArguments)
+	}
+	if f.MaxID > 0 && f.MinID > f.MaxID {
+		return fmt.Errorf("%w: min id is greater than max id", ErrBadArguments)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("%w: empty date range", ErrBadArguments)
+	}
+	return nil
+}
+
+// SearchRequest is a search phrase, filter and the page of results to return.
+// PageToken is NextPageToken of the previous page, it takes precedence over Offset.
+type SearchRequest struct {
+	Phrase    string
+	Filter    Filter
+	Limit     int
+	Offset    int
+	PageToken string
+	Fuzzy     bool
+}
+
+// SearchResult is a page of found comics and, when nothing is found, corrected phrases to try.
+// Total is the number of all matches, NextPageToken is empty on the last page.
+type SearchResult struct {
+	Comics        []Comics
+	Total         int
+	NextPageToken string
+	Suggestions   []string
+}
+
+// Highlight is a byte range of a matched word in the snippet text
+type Highlight struct {
+	Start int
+	End   int
+}
+
+// Snippet is a fragment of a comics field with the matched words highlighted
+type Snippet struct {
+	Field      string
+	Text       string
+	Highlights []Highlight
+}
+
 type Comics struct {
+	ID         int
+	URL        string
+	Words      []string
+	Terms      []Term
+	Title      string
+	SafeTitle  string
+	Alt        string
+	Transcript string
+	Link       string
+	News       string
+	Published  time.Time
+	Score      float64
+	Snippets   []Snippet
+}
+
+// Ranked is a found comics ID with its relevance score
+type Ranked struct {
 	ID    int
-	URL   string
-	Words []string
+	Score float64
+}
+
+// Frequencies returns term frequencies and length of comics.
+// Comics stored without terms fall back to the list of unique words.
+func (c Comics) Frequencies() (map[string]int, int) {
+	if len(c.Terms) == 0 {
+		return TermFrequencies(c.Words), len(c.Words)
+	}
+	tf := make(map[string]int, len(c.Terms))
+	var length int
+	for _, t := range c.Terms {
+		tf[t.Term] += t.TF
+		length += t.TF
+	}
+	return tf, length
 }
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageToken encodes the offset of the next page with a checksum of the query,
+// so the token can not be used with another phrase
+func pageToken(req SearchRequest, offset int) string {
+	token := fmt.Sprintf("%d:%08x", offset, queryChecksum(req))
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+// pageOffset returns the offset of the requested page from its token or Offset
+func pageOffset(req SearchRequest) (int, error) {
+	if req.Limit < 0 || req.Offset < 0 {
+		return 0, fmt.Errorf("%w: negative limit or offset", ErrBadArguments)
+	}
+	if req.PageToken == "" {
+		return req.Offset, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad page token", ErrBadArguments)
+	}
+	value, checksum, found := strings.Cut(string(data), ":")
+	offset, err := strconv.Atoi(value)
+	if !found || err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: bad page token", ErrBadArguments)
+	}
+	if checksum != fmt.Sprintf("%08x", queryChecksum(req)) {
+		return 0, fmt.Errorf("%w: page token of another query", ErrBadArguments)
+	}
+	return offset, nil
+}
+
+// pageOf returns the ranked comics of the page after offset. The end of the page
+// is counted from the rest of the comics, so a huge offset or limit does not overflow.
+func pageOf(ranked []Ranked, limit, offset int) []Ranked {
+	if offset >= len(ranked) {
+		return nil
+	}
+	return ranked[offset : offset+min(limit, len(ranked)-offset)]
+}
+
+func queryChecksum(req SearchRequest) uint32 {
+	f := req.Filter
+	key := fmt.Sprintf("%t\x00%s\x00%s\x00%d\x00%d\x00%s",
+		req.Fuzzy, f.From.Format(time.DateOnly), f.To.Format(time.DateOnly), f.MinID, f.MaxID, req.Phrase)
+	return crc32.ChecksumIEEE([]byte(key))
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPageOffset(t *testing.T) {
+	req := SearchRequest{Phrase: "linux", Limit: 10}
+	token := pageToken(req, 20)
+
+	offset, err := pageOffset(SearchRequest{Phrase: "linux", Limit: 5, Offset: 3, PageToken: token})
+	if err != nil {
+		t.Fatalf("pageOffset returned error: %v", err)
+	}
+	if offset != 20 {
+		t.Fatalf("expected token to take precedence, got offset %d", offset)
+	}
+	if offset, err := pageOffset(SearchRequest{Phrase: "linux", Offset: 3}); err != nil || offset != 3 {
+		t.Fatalf("expected offset 3, got %d, %v", offset, err)
+	}
+
+	bad := []SearchRequest{
+		{Phrase: "linux", Offset: -1},
+		{Phrase: "linux", Limit: -1},
+		{Phrase: "linux", PageToken: "not a token!"},
+		{Phrase: "linux", PageToken: pageToken(req, 20)[1:]},
+		{Phrase: "windows", PageToken: token},
+		{Phrase: "linux", Fuzzy: true, PageToken: token},
+	}
+	for _, r := range bad {
+		if _, err := pageOffset(r); !errors.Is(err, ErrBadArguments) {
+			t.Fatalf("%+v: expected ErrBadArguments, got %v", r, err)
+		}
+	}
+}
+
+func TestService_IndexSearch_Pages(t *testing.T) {
+	init := fakeInitiator{indexedComics: []Comics{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}}
+	s := newTestService(t, fakeStorager{}, fakeWords{}, init)
+
+	req := SearchRequest{Phrase: "linux", Limit: 2}
+	var ids []int
+	for range 3 {
+		result, err := s.IndexSearch(context.Background(), req)
+		if err != nil {
+			t.Fatalf("IndexSearch returned error: %v", err)
+		}
+		if result.Total != 5 {
+			t.Fatalf("expected total 5, got %d", result.Total)
+		}
+		for _, c := range result.Comics {
+			ids = append(ids, c.ID)
+		}
+		req.PageToken = result.NextPageToken
+	}
+	if len(ids) != 5 || ids[0] != 1 || ids[4] != 5 {
+		t.Fatalf("expected all comics page by page, got %v", ids)
+	}
+	if req.PageToken != "" {
+		t.Fatalf("expected no token after the last page, got %q", req.PageToken)
+	}
+
+	result, err := s.IndexSearch(context.Background(), SearchRequest{Phrase: "linux", Limit: 2, Offset: 10})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	if len(result.Comics) != 0 || result.Total != 5 || result.NextPageToken != "" || result.Suggestions != nil {
+		t.Fatalf("expected empty page past the end, got %#v", result)
+	}
+}
+
+func TestService_Search_PageAndTotal(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{"linux": {1, 2, 3}},
+		comics: map[int]Comics{
+			1: {ID: 1, Words: []string{"linux"}},
+			2: {ID: 2, Words: []string{"linux"}},
+			3: {ID: 3, Words: []string{"linux"}},
+		},
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "linux", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if result.Total != 3 || len(result.Comics) != 1 || result.Comics[0].ID != 2 {
+		t.Fatalf("expected the second of 3 comics, got %#v", result)
+	}
+	if offset, err := pageOffset(SearchRequest{Phrase: "linux", PageToken: result.NextPageToken}); err != nil || offset != 2 {
+		t.Fatalf("expected next page at 2, got %d, %v", offset, err)
+	}
+}
+
+func TestService_Search_HugeOffset(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{"linux": {1, 2}, "windows": {2}},
+		comics: map[int]Comics{
+			1: {ID: 1, Words: []string{"linux"}},
+			2: {ID: 2, Words: []string{"linux", "windows"}},
+		},
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	// offset+limit overflows int, the page must be empty instead of a panic
+	for _, phrase := range []string{"linux", "linux -windows"} {
+		req := SearchRequest{Phrase: phrase, Limit: 10, Offset: math.MaxInt}
+		result, err := s.Search(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", phrase, err)
+		}
+		if len(result.Comics) != 0 || result.Total == 0 || result.NextPageToken != "" {
+			t.Fatalf("Search(%q): expected empty last page, got %#v", phrase, result)
+		}
+
+		req.Offset, req.PageToken = 0, pageToken(req, math.MaxInt)
+		if result, err = s.Search(context.Background(), req); err != nil || len(result.Comics) != 0 {
+			t.Fatalf("Search(%q) by token: expected empty page, got %#v, %v", phrase, result, err)
+		}
+	}
+}
+
+func TestService_Search_FetchesOnlyPage(t *testing.T) {
+	var fetched [][]int
+	db := fakeStorager{
+		searchResults: map[string][]int{"linux": {1, 2, 3}, "windows": {3}},
+		comics: map[int]Comics{
+			1: {ID: 1, Words: []string{"linux"}},
+			2: {ID: 2, Words: []string{"linux", "linux"}},
+			3: {ID: 3, Words: []string{"linux", "windows"}},
+		},
+		fetched: &fetched,
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "linux", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if result.Total != 3 || len(fetched) != 1 || !slices.Equal(fetched[0], []int{result.Comics[0].ID}) {
+		t.Fatalf("expected only the comics of the page to be fetched, got %v of %d", fetched, result.Total)
+	}
+
+	// exclusions are checked on terms of all found comics, only the page is fetched
+	fetched = nil
+	result, err = s.Search(context.Background(), SearchRequest{Phrase: "linux -windows", Limit: 1})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if result.Total != 2 || len(fetched) != 1 || !slices.Equal(fetched[0], []int{result.Comics[0].ID}) {
+		t.Fatalf("expected only the comics of the page to be fetched, got %v of %d", fetched, result.Total)
+	}
+	if result.Comics[0].ID == 3 {
+		t.Fatalf("expected excluded comics to be skipped, got %v", result.Comics)
+	}
+}
+
+func TestService_Search_MatchesInBatches(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{"cat": {}},
+		comics:        map[int]Comics{},
+	}
+	// every third comics has the phrase in the title
+	for id := 1; id <= 2*matchBatch+10; id++ {
+		title := []int{1}
+		if id%3 == 0 {
+			title = []int{0}
+		}
+		db.searchResults["cat"] = append(db.searchResults["cat"], id)
+		db.comics[id] = Comics{ID: id, Words: []string{"cat", "dog"}, Terms: []Term{
+			{Field: FieldTitle, Term: "cat", TF: 1, Positions: title},
+			{Field: FieldTitle, Term: "dog", TF: 1, Positions: []int{1}},
+		}}
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: `"cat dog"`, Limit: 5})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if result.Total != (2*matchBatch+10)/3 || len(result.Comics) != 5 {
+		t.Fatalf("expected matches of all batches, got %d comics of %d", len(result.Comics), result.Total)
+	}
+}
+
+func TestQueryStems(t *testing.T) {
+	node := BoolNode{
+		Must:    []Node{PhraseNode{Stems: []string{"b", "a"}, Offsets: []int{0, 1}}},
+		Should:  []Node{OrNode{Nodes: []Node{TermNode{Stem: "c"}, NearNode{Left: "a", Right: "d"}}}},
+		MustNot: []Node{TermNode{Field: "alt", Stem: "e"}},
+	}
+	if got := queryStems(node); !slices.Equal(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Fatalf("unexpected stems: %v", got)
+	}
+}
+
+func TestAnyWord(t *testing.T) {
+	cases := []struct {
+		node Node
+		want bool
+	}{
+		{TermNode{Stem: "a"}, true},
+		{TermNode{Field: "title", Stem: "a"}, false},
+		{BoolNode{Should: []Node{TermNode{Stem: "a"}, OrNode{Nodes: []Node{TermNode{Stem: "b"}, TermNode{Stem: "c"}}}}}, true},
+		{BoolNode{Must: []Node{TermNode{Stem: "a"}}, Should: []Node{TermNode{Stem: "b"}}}, false},
+		{BoolNode{Should: []Node{TermNode{Stem: "a"}}, MustNot: []Node{TermNode{Stem: "b"}}}, false},
+		{OrNode{Nodes: []Node{TermNode{Stem: "a"}, PhraseNode{Stems: []string{"b", "c"}, Offsets: []int{0, 1}}}}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := anyWord(c.node); got != c.want {
+			t.Fatalf("anyWord(%#v): expected %t, got %t", c.node, c.want, got)
+		}
+	}
+}
+
+func TestService_Search_Filter(t *testing.T) {
+	var filter Filter
+	db := fakeStorager{
+		searchResults: map[string][]int{"linux": {1}},
+		comics:        map[int]Comics{1: {ID: 1, Words: []string{"linux"}}},
+		filter:        &filter,
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	want := Filter{To: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), MinID: 5}
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "linux", Limit: 10, Filter: want})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if filter != want || len(result.Comics) != 1 {
+		t.Fatalf("expected filter %+v to reach the DB, got %+v", want, filter)
+	}
+
+	bad := []Filter{
+		{MinID: -1},
+		{MinID: 10, MaxID: 5},
+		{From: want.To, To: want.To},
+	}
+	for _, f := range bad {
+		if _, err := s.IndexSearch(context.Background(), SearchRequest{Phrase: "linux", Filter: f}); !errors.Is(err, ErrBadArguments) {
+			t.Fatalf("%+v: expected ErrBadArguments, got %v", f, err)
+		}
+	}
+}
+
+func TestPageOffset_TokenOfAnotherFilter(t *testing.T) {
+	req := SearchRequest{Phrase: "linux", Filter: Filter{MaxID: 100}}
+	token := pageToken(req, 10)
+
+	req.PageToken = token
+	if offset, err := pageOffset(req); err != nil || offset != 10 {
+		t.Fatalf("expected offset 10, got %d, %v", offset, err)
+	}
+	req.Filter.MaxID = 200
+	if _, err := pageOffset(req); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments, got %v", err)
+	}
+}
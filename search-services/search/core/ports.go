@@ -5,25 +5,66 @@ import (
 )
 
 type Storager interface {
-	Search(ctx context.Context, keyword string) ([]int, error)
+	// Search finds comics with any of the query words passing the query filter and
+	// ranks them by BM25 of the weighted words, the best first. Document frequencies
+	// are counted over the found comics, Docs and TotalLength are taken from stats.
+	Search(ctx context.Context, query Query, scorer BM25, stats CorpusStats) ([]Ranked, error)
 	Get(ctx context.Context, ID int) (Comics, error)
 	GetAllComics(ctx context.Context) ([]Comics, error)
+	// GetComicsByIDs returns comics in the order of ids, missing ones are skipped
 	GetComicsByIDs(ctx context.Context, ids ...int) ([]Comics, error)
+	// GetComicsTerms returns comics of ids with only their words and terms of the stems,
+	// which is enough to match them against a query of the stems
+	GetComicsTerms(ctx context.Context, stems []string, ids ...int) ([]Comics, error)
+	// FullTextSearch ranks comics by the full-text search of the DB and returns limit
+	// comics after offset with highlighted snippets and the number of all matches
+	FullTextSearch(ctx context.Context, phrase string, filter Filter, limit, offset int) ([]Comics, int, error)
+	IDs(ctx context.Context) ([]int, error)
 }
 
 type Words interface {
-	Norm(ctx context.Context, phrase string) ([]string, error)
+	NormDetailed(ctx context.Context, phrase string) ([]Token, error)
 }
 
 type Searcher interface {
-	Search(context.Context, string, int) ([]Comics, error)
-	IndexSearch(context.Context, string, int) ([]Comics, error)
+	Search(ctx context.Context, req SearchRequest) (SearchResult, error)
+	IndexSearch(ctx context.Context, req SearchRequest) (SearchResult, error)
+	FullTextSearch(ctx context.Context, req SearchRequest) (SearchResult, error)
+	GetComic(context.Context, int) (Comics, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]Completion, error)
+}
+
+// Vocabulary finds indexed terms within maxDistance edits from the term
+type Vocabulary interface {
+	SimilarTerms(term string, maxDistance int) []SimilarTerm
+}
+
+// Completer finds indexed words and comics titles starting with the prefix, the most frequent first
+type Completer interface {
+	Complete(prefix string, limit int) []Completion
 }
 
 type Initiator interface {
-	GetIndexedComics(ctx context.Context, words []string, limit int) ([]Comics, error)
+	Vocabulary
+	Completer
+	// GetIndexedComics returns limit best comics after offset and the number of all matches
+	GetIndexedComics(ctx context.Context, query Query, limit, offset int) ([]Comics, int, error)
 	IndexComics(ctx context.Context) error
+	UpdateIndex(ctx context.Context, changed, removed []int) error
 	ClearIndex(ctx context.Context) error
+	CorpusStats() CorpusStats
+}
+
+// Invalidator drops cached search results when comics change
+type Invalidator interface {
+	Invalidate()
+}
+
+// Checkpointer keeps the stream sequence of the last event applied to the index,
+// so events after a saved index are replayed on warm start
+type Checkpointer interface {
+	Checkpoint() uint64
+	SetCheckpoint(seq uint64)
 }
 
 type Notificator interface {
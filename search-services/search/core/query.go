@@ -0,0 +1,956 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	nearPrefix    = "NEAR/"
+	orOperator    = "OR"
+	fuzzySuffix   = "~"
+	maxQueryDepth = 16
+	// fuzzyWeight is the ranking weight of a vocabulary term one edit away from
+	// the query term, every further edit multiplies it again. It orders fuzzy
+	// matches among themselves, comics with exact matches rank above them anyway.
+	fuzzyWeight = 0.5
+)
+
+// Fields of comics that can be used as query prefix, e.g. title:physics
+const (
+	FieldTitle      = "title"
+	FieldSafeTitle  = "safe_title"
+	FieldTranscript = "transcript"
+	FieldAlt        = "alt"
+)
+
+var queryFields = []string{FieldTitle, FieldSafeTitle, FieldTranscript, FieldAlt}
+
+// Positions maps stem to its sorted positions in every field of comics
+type Positions map[string]map[string][]int
+
+func NewPositions(terms []Term) Positions {
+	p := make(Positions, len(terms))
+	for _, t := range terms {
+		if p[t.Term] == nil {
+			p[t.Term] = make(map[string][]int)
+		}
+		p[t.Term][t.Field] = slices.Sorted(slices.Values(t.Positions))
+	}
+	return p
+}
+
+// Document is comics prepared for query evaluation and ranking.
+// Forms maps stem to its surface form in the comics.
+type Document struct {
+	TF        map[string]int
+	Length    int
+	Positions Positions
+	Forms     map[string]string
+	Title     string
+	Published time.Time
+}
+
+func NewDocument(c Comics) Document {
+	tf, length := c.Frequencies()
+	return Document{TF: tf, Length: length, Positions: NewPositions(c.Terms), Forms: forms(c.Terms), Title: c.Title, Published: c.Published}
+}
+
+// forms picks surface form of every stem from the field where it is the most frequent
+func forms(terms []Term) map[string]string {
+	forms := make(map[string]string)
+	best := make(map[string]int)
+	for _, t := range terms {
+		if t.Surface != "" && t.TF > best[t.Term] {
+			forms[t.Term] = t.Surface
+			best[t.Term] = t.TF
+		}
+	}
+	return forms
+}
+
+// fields returns positions of stem in the field or in all fields when field is empty
+func (d Document) fields(stem, field string) map[string][]int {
+	if field == "" {
+		return d.Positions[stem]
+	}
+	if positions, ok := d.Positions[stem][field]; ok {
+		return map[string][]int{field: positions}
+	}
+	return nil
+}
+
+// Node is a normalized query expression
+type Node interface {
+	Match(d Document) bool
+}
+
+// TermNode matches a stem, optionally only within one field
+type TermNode struct {
+	Field string
+	Stem  string
+}
+
+func (n TermNode) Match(d Document) bool {
+	if n.Field == "" {
+		return d.TF[n.Stem] > 0
+	}
+	return len(d.Positions[n.Stem][n.Field]) > 0
+}
+
+// PhraseNode is a sequence of stems, Offsets are positions relative to the first stem.
+// Gaps left by stop words are kept, so "lord of the rings" matches only that order.
+type PhraseNode struct {
+	Field   string
+	Stems   []string
+	Offsets []int
+}
+
+func (n PhraseNode) Match(d Document) bool {
+	for field, starts := range d.fields(n.Stems[0], n.Field) {
+	next:
+		for _, start := range starts {
+			for i := 1; i < len(n.Stems); i++ {
+				if _, ok := slices.BinarySearch(d.Positions[n.Stems[i]][field], start+n.Offsets[i]); !ok {
+					continue next
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// NearNode requires two stems to be at most Distance positions apart within one field
+type NearNode struct {
+	Field    string
+	Left     string
+	Right    string
+	Distance int
+}
+
+func (n NearNode) Match(d Document) bool {
+	for field, lefts := range d.fields(n.Left, n.Field) {
+		rights := d.Positions[n.Right][field]
+		for _, l := range lefts {
+			// first right position not less than l - Distance
+			i, _ := slices.BinarySearch(rights, l-n.Distance)
+			if i < len(rights) && rights[i] <= l+n.Distance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BoolNode matches when all Must and none of MustNot nodes match.
+// Without Must nodes at least one of Should nodes is required.
+type BoolNode struct {
+	Must    []Node
+	Should  []Node
+	MustNot []Node
+}
+
+func (n BoolNode) Match(d Document) bool {
+	for _, node := range n.MustNot {
+		if node.Match(d) {
+			return false
+		}
+	}
+	for _, node := range n.Must {
+		if !node.Match(d) {
+			return false
+		}
+	}
+	if len(n.Must) > 0 {
+		return true
+	}
+	for _, node := range n.Should {
+		if node.Match(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// OrNode matches when any of its nodes matches
+type OrNode struct {
+	Nodes []Node
+}
+
+func (n OrNode) Match(d Document) bool {
+	for _, node := range n.Nodes {
+		if node.Match(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query is a normalized search phrase.
+// Words are all not excluded stems used for lookup and ranking.
+// Weights lower the rank of fuzzy expansions, other words have weight 1.
+type Query struct {
+	Root    Node
+	Words   []string
+	Weights map[string]float64
+	Filter  Filter
+	// tokens are not excluded words of terms and phrases, they are spell checked
+	// when nothing is found
+	tokens []Token
+}
+
+func (q Query) Match(d Document) bool {
+	return q.Root != nil && q.Root.Match(d)
+}
+
+// anyWord reports whether the node matches every comics with any of its stems,
+// so the comics do not have to be checked for fields, positions and exclusions
+func anyWord(node Node) bool {
+	switch n := node.(type) {
+	case TermNode:
+		return n.Field == ""
+	case OrNode:
+		return !slices.ContainsFunc(n.Nodes, func(node Node) bool { return !anyWord(node) })
+	case BoolNode:
+		return len(n.Must) == 0 && len(n.MustNot) == 0 &&
+			!slices.ContainsFunc(n.Should, func(node Node) bool { return !anyWord(node) })
+	}
+	return false
+}
+
+// queryStems returns all stems of the node, excluded ones included
+func queryStems(node Node) []string {
+	var stems []string
+	var walk func(Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case TermNode:
+			stems = append(stems, n.Stem)
+		case PhraseNode:
+			stems = append(stems, n.Stems...)
+		case NearNode:
+			stems = append(stems, n.Left, n.Right)
+		case OrNode:
+			for _, node := range n.Nodes {
+				walk(node)
+			}
+		case BoolNode:
+			for _, nodes := range [][]Node{n.Must, n.Should, n.MustNot} {
+				for _, node := range nodes {
+					walk(node)
+				}
+			}
+		}
+	}
+	walk(node)
+	slices.Sort(stems)
+	return slices.Compact(stems)
+}
+
+func (q Query) Weight(word string) float64 {
+	if w, ok := q.Weights[word]; ok {
+		return w
+	}
+	return 1
+}
+
+// Exact reports whether the document has any query word that is not a fuzzy expansion.
+// Such documents rank above documents found by fuzzy expansions only, whatever
+// their scores are, because a rare misspelled term would outweigh a common exact one.
+func (q Query) Exact(d Document) bool {
+	for _, word := range q.Words {
+		if _, fuzzy := q.Weights[word]; !fuzzy && d.TF[word] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Score computes weighted BM25 relevance of the document for the query words
+func (q Query) Score(scorer BM25, stats CorpusStats, d Document) float64 {
+	var score float64
+	for _, word := range q.Words {
+		score += q.Weight(word) * scorer.TermScore(stats, word, d.TF[word], d.Length)
+	}
+	return score
+}
+
+type occur int
+
+const (
+	occurShould occur = iota
+	occurMust
+	occurMustNot
+)
+
+// rawNode is a parsed but not normalized query expression
+type rawNode interface{}
+
+type rawTerm struct {
+	field string
+	text  string
+	fuzzy bool
+}
+
+type rawPhrase struct {
+	field string
+	text  string
+}
+
+type rawNear struct {
+	field    string
+	left     string
+	right    string
+	distance int
+}
+
+type rawClause struct {
+	occur occur
+	node  rawNode
+}
+
+type rawBool struct {
+	clauses []rawClause
+}
+
+type rawOr struct {
+	nodes []rawNode
+}
+
+type itemKind int
+
+const (
+	itemWord itemKind = iota
+	itemPhrase
+	itemOpen
+	itemClose
+)
+
+// queryItem is a lexeme of query, prefix holds +/- and field: glued to it
+type queryItem struct {
+	kind   itemKind
+	text   string
+	prefix string
+}
+
+// parseQuery builds expression of the query language:
+//
+//	query  = seq { "OR" seq }
+//	seq    = clause { clause }
+//	clause = [ "+" | "-" ] ( "(" query ")" | [ field ":" ] ( word [ "~" ] | word "NEAR/n" word | "phrase" ) )
+//
+// Clauses without prefix are optional, but phrases and NEAR are required by default.
+// Word followed by ~ is matched fuzzily.
+func parseQuery(phrase string) (rawNode, error) {
+	items, err := splitQuery(phrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: empty query", ErrBadArguments)
+	}
+
+	p := &queryParser{items: items}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.items) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrBadArguments, p.items[p.pos].text)
+	}
+	return node, nil
+}
+
+type queryParser struct {
+	items []queryItem
+	pos   int
+	depth int
+}
+
+func (p *queryParser) peek() (queryItem, bool) {
+	if p.pos >= len(p.items) {
+		return queryItem{}, false
+	}
+	return p.items[p.pos], true
+}
+
+func isOr(item queryItem) bool {
+	return item.kind == itemWord && item.prefix == "" && item.text == orOperator
+}
+
+func (p *queryParser) parseOr() (rawNode, error) {
+	first, err := p.parseSeq()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []rawNode{first}
+	for {
+		item, ok := p.peek()
+		if !ok || !isOr(item) {
+			break
+		}
+		p.pos++
+		next, err := p.parseSeq()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, next)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return rawOr{nodes: nodes}, nil
+}
+
+func (p *queryParser) parseSeq() (rawNode, error) {
+	var seq rawBool
+	for {
+		item, ok := p.peek()
+		if !ok || item.kind == itemClose || isOr(item) {
+			break
+		}
+		clause, err := p.parseClause()
+		if err != nil {
+			return nil, err
+		}
+		seq.clauses = append(seq.clauses, clause)
+	}
+	if len(seq.clauses) == 0 {
+		item, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("%w: missing expression at the end", ErrBadArguments)
+		}
+		return nil, fmt.Errorf("%w: missing expression before %q", ErrBadArguments, item.text)
+	}
+	return seq, nil
+}
+
+func (p *queryParser) parseClause() (rawClause, error) {
+	item := p.items[p.pos]
+	p.pos++
+
+	clause := rawClause{occur: occurShould}
+	prefix := item.prefix
+	if item.kind == itemWord {
+		prefix, item.text = splitPrefix(item.text)
+	}
+	switch {
+	case strings.HasPrefix(prefix, "+"):
+		clause.occur = occurMust
+		prefix = prefix[1:]
+	case strings.HasPrefix(prefix, "-"):
+		clause.occur = occurMustNot
+		prefix = prefix[1:]
+	}
+	field, err := parseField(prefix)
+	if err != nil {
+		return rawClause{}, err
+	}
+
+	switch item.kind {
+	case itemOpen:
+		if field != "" {
+			return rawClause{}, fmt.Errorf("%w: field prefix is not allowed before group", ErrBadArguments)
+		}
+		if p.depth++; p.depth > maxQueryDepth {
+			return rawClause{}, fmt.Errorf("%w: too many nested groups", ErrBadArguments)
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return rawClause{}, err
+		}
+		if next, ok := p.peek(); !ok || next.kind != itemClose {
+			return rawClause{}, fmt.Errorf("%w: unclosed parenthesis", ErrBadArguments)
+		}
+		p.pos++
+		p.depth--
+		clause.node = node
+		return clause, nil
+
+	case itemClose:
+		return rawClause{}, fmt.Errorf("%w: unexpected )", ErrBadArguments)
+
+	case itemPhrase:
+		if clause.occur == occurShould {
+			clause.occur = occurMust
+		}
+		clause.node = rawPhrase{field: field, text: item.text}
+		return clause, nil
+	}
+
+	_, isNear, err := nearDistance(item)
+	if err != nil {
+		return rawClause{}, err
+	}
+	if isNear {
+		return rawClause{}, fmt.Errorf("%w: %s without left operand", ErrBadArguments, item.text)
+	}
+
+	text, fuzzy := strings.CutSuffix(item.text, fuzzySuffix)
+	if fuzzy {
+		if text == "" {
+			return rawClause{}, fmt.Errorf("%w: %s without a word", ErrBadArguments, fuzzySuffix)
+		}
+		clause.node = rawTerm{field: field, text: text, fuzzy: true}
+		return clause, nil
+	}
+
+	if next, ok := p.peek(); ok {
+		distance, isNear, err := nearDistance(next)
+		if err != nil {
+			return rawClause{}, err
+		}
+		if isNear {
+			p.pos++
+			right, ok := p.peek()
+			if !ok || right.kind != itemWord || hasPrefix(right.text) {
+				return rawClause{}, fmt.Errorf("%w: %s needs a word on the right", ErrBadArguments, next.text)
+			}
+			p.pos++
+			if clause.occur == occurShould {
+				clause.occur = occurMust
+			}
+			clause.node = rawNear{field: field, left: item.text, right: right.text, distance: distance}
+			return clause, nil
+		}
+	}
+
+	clause.node = rawTerm{field: field, text: item.text}
+	return clause, nil
+}
+
+// splitPrefix separates +/- and known field: prefix from word
+func splitPrefix(word string) (string, string) {
+	var prefix string
+	if len(word) > 1 && (word[0] == '+' || word[0] == '-') {
+		prefix, word = word[:1], word[1:]
+	}
+	if field, rest, found := strings.Cut(word, ":"); found && rest != "" && slices.Contains(queryFields, field) {
+		return prefix + field + ":", rest
+	}
+	return prefix, word
+}
+
+func hasPrefix(word string) bool {
+	prefix, _ := splitPrefix(word)
+	return prefix != ""
+}
+
+func parseField(prefix string) (string, error) {
+	if prefix == "" {
+		return "", nil
+	}
+	field, found := strings.CutSuffix(prefix, ":")
+	if !found || !slices.Contains(queryFields, field) {
+		return "", fmt.Errorf("%w: unknown field %q", ErrBadArguments, prefix)
+	}
+	return field, nil
+}
+
+func splitQuery(phrase string) ([]queryItem, error) {
+	var items []queryItem
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			items = append(items, queryItem{kind: itemWord, text: current.String()})
+		}
+		current.Reset()
+	}
+	// prefix glued to phrase or group, e.g. -title:"..." or +(...)
+	takePrefix := func() string {
+		prefix := current.String()
+		current.Reset()
+		return prefix
+	}
+
+	runes := []rune(phrase)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '"':
+			prefix := takePrefix()
+			end := slices.Index(runes[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed quote", ErrBadArguments)
+			}
+			text := strings.TrimSpace(string(runes[i+1 : i+1+end]))
+			if text == "" {
+				return nil, fmt.Errorf("%w: empty phrase", ErrBadArguments)
+			}
+			items = append(items, queryItem{kind: itemPhrase, text: text, prefix: prefix})
+			i += end + 1
+		case r == '(':
+			items = append(items, queryItem{kind: itemOpen, text: "(", prefix: takePrefix()})
+		case r == ')':
+			flush()
+			items = append(items, queryItem{kind: itemClose, text: ")"})
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return items, nil
+}
+
+// nearDistance parses NEAR/n operator, isNear is false for any other item.
+func nearDistance(item queryItem) (distance int, isNear bool, err error) {
+	if item.kind != itemWord {
+		return 0, false, nil
+	}
+	value, found := strings.CutPrefix(item.text, nearPrefix)
+	if !found {
+		return 0, false, nil
+	}
+	distance, err = strconv.Atoi(value)
+	if err != nil || distance < 1 {
+		return 0, false, fmt.Errorf("%w: bad distance in %s", ErrBadArguments, item.text)
+	}
+	return distance, true, nil
+}
+
+// normalizer turns parsed query into stems with the words service.
+// Fuzzy terms, or all terms when fuzzy is set, are expanded with similar
+// terms of the vocabulary.
+type normalizer struct {
+	words      Words
+	vocabulary Vocabulary
+	fuzzy      bool
+	tokens     map[string][]Token
+	seen       map[string]bool
+	stems      []string
+	weights    map[string]float64
+	surfaces   map[string]bool
+	used       []Token
+}
+
+func newNormalizer(words Words, vocabulary Vocabulary, fuzzy bool) *normalizer {
+	return &normalizer{
+		words:      words,
+		vocabulary: vocabulary,
+		fuzzy:      fuzzy,
+		tokens:     make(map[string][]Token),
+		seen:       make(map[string]bool),
+		weights:    make(map[string]float64),
+		surfaces:   make(map[string]bool),
+	}
+}
+
+// addTokens remembers not stop word tokens of the query once per surface form
+func (n *normalizer) addTokens(tokens []Token) {
+	for _, t := range tokens {
+		if !t.StopWord && !n.surfaces[t.Surface] {
+			n.surfaces[t.Surface] = true
+			n.used = append(n.used, t)
+		}
+	}
+}
+
+// prefetch normalizes all words, phrases and proximity operands of the query
+// with one call to the words service. Texts are joined by new lines and
+// tokens are split back by the number of words in every text.
+func (n *normalizer) prefetch(ctx context.Context, raw rawNode) error {
+	var texts []string
+	collectTexts(raw, func(text string) {
+		if _, ok := n.tokens[text]; !ok {
+			n.tokens[text] = nil
+			texts = append(texts, text)
+		}
+	})
+	if len(texts) == 0 {
+		return nil
+	}
+	tokens, err := n.words.NormDetailed(ctx, strings.Join(texts, "\n"))
+	if err != nil {
+		return err
+	}
+	offset := 0
+	for _, text := range texts {
+		end := offset + countWords(text)
+		var own []Token
+		for len(tokens) > 0 && tokens[0].Position < end {
+			token := tokens[0]
+			tokens = tokens[1:]
+			token.Position -= offset
+			own = append(own, token)
+		}
+		n.tokens[text] = own
+		offset = end
+	}
+	return nil
+}
+
+// collectTexts calls add for every text of the query to be normalized
+func collectTexts(raw rawNode, add func(string)) {
+	switch raw := raw.(type) {
+	case rawTerm:
+		add(raw.text)
+	case rawPhrase:
+		add(raw.text)
+	case rawNear:
+		add(raw.left)
+		add(raw.right)
+	case rawBool:
+		for _, clause := range raw.clauses {
+			collectTexts(clause.node, add)
+		}
+	case rawOr:
+		for _, node := range raw.nodes {
+			collectTexts(node, add)
+		}
+	}
+}
+
+// countWords counts words of text the way the words service splits it:
+// a word is a run of letters and digits.
+func countWords(text string) int {
+	return len(strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
+
+func (n *normalizer) tokenize(ctx context.Context, text string) ([]Token, error) {
+	if tokens, ok := n.tokens[text]; ok {
+		return tokens, nil
+	}
+	tokens, err := n.words.NormDetailed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	n.tokens[text] = tokens
+	return tokens, nil
+}
+
+func (n *normalizer) addStems(node Node) {
+	var stems []string
+	switch node := node.(type) {
+	case TermNode:
+		stems = []string{node.Stem}
+	case PhraseNode:
+		stems = node.Stems
+	case NearNode:
+		stems = []string{node.Left, node.Right}
+	}
+	for _, stem := range stems {
+		// exact stem outranks the same stem found as fuzzy expansion
+		delete(n.weights, stem)
+		if !n.seen[stem] {
+			n.seen[stem] = true
+			n.stems = append(n.stems, stem)
+		}
+	}
+}
+
+// addSimilar adds a fuzzy expansion stem with the given weight
+func (n *normalizer) addSimilar(stem string, weight float64) {
+	if !n.seen[stem] {
+		n.seen[stem] = true
+		n.stems = append(n.stems, stem)
+		n.weights[stem] = weight
+		return
+	}
+	if w, ok := n.weights[stem]; ok && weight > w {
+		n.weights[stem] = weight
+	}
+}
+
+// expand turns a term into any of the term and similar vocabulary terms.
+// Excluded terms are never expanded.
+func (n *normalizer) expand(term TermNode) Node {
+	n.addStems(term)
+	similar := n.vocabulary.SimilarTerms(term.Stem, maxFuzzyDistance(term.Stem))
+	or := OrNode{Nodes: []Node{term}}
+	for _, s := range similar {
+		if s.Term == term.Stem {
+			continue
+		}
+		or.Nodes = append(or.Nodes, TermNode{Field: term.Field, Stem: s.Term})
+		n.addSimilar(s.Term, math.Pow(fuzzyWeight, float64(s.Distance)))
+	}
+	if len(or.Nodes) == 1 {
+		return term
+	}
+	return or
+}
+
+// maxFuzzyDistance bounds edit distance by stem length, short stems are not expanded
+func maxFuzzyDistance(stem string) int {
+	switch n := utf8.RuneCountInString(stem); {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	case n < 9:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// normalize returns nil node for expressions consisting of stop words only.
+// Stems of excluded expressions are not used for lookup and ranking.
+func (n *normalizer) normalize(ctx context.Context, raw rawNode, excluded bool) (Node, error) {
+	var node Node
+	switch raw := raw.(type) {
+	case rawTerm:
+		tokens, err := n.tokenize(ctx, raw.text)
+		if err != nil {
+			return nil, err
+		}
+		if !excluded {
+			n.addTokens(tokens)
+		}
+		return n.terms(raw.field, tokens, raw.fuzzy, excluded), nil
+
+	case rawPhrase:
+		tokens, err := n.tokenize(ctx, raw.text)
+		if err != nil {
+			return nil, err
+		}
+		node = phraseNode(raw.field, tokens)
+		if !excluded {
+			n.addTokens(tokens)
+		}
+
+	case rawNear:
+		left, err := n.operand(ctx, raw.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := n.operand(ctx, raw.right)
+		if err != nil {
+			return nil, err
+		}
+		node = NearNode{Field: raw.field, Left: left, Right: right, Distance: raw.distance}
+
+	case rawBool:
+		var b BoolNode
+		for _, clause := range raw.clauses {
+			child, err := n.normalize(ctx, clause.node, excluded || clause.occur == occurMustNot)
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				continue
+			}
+			switch clause.occur {
+			case occurMust:
+				b.Must = append(b.Must, child)
+			case occurMustNot:
+				b.MustNot = append(b.MustNot, child)
+			default:
+				b.Should = append(b.Should, child)
+			}
+		}
+		if len(b.Must)+len(b.Should)+len(b.MustNot) == 0 {
+			return nil, nil
+		}
+		return b, nil
+
+	case rawOr:
+		var or OrNode
+		for _, raw := range raw.nodes {
+			child, err := n.normalize(ctx, raw, excluded)
+			if err != nil {
+				return nil, err
+			}
+			if child != nil {
+				or.Nodes = append(or.Nodes, child)
+			}
+		}
+		switch len(or.Nodes) {
+		case 0:
+			return nil, nil
+		case 1:
+			return or.Nodes[0], nil
+		}
+		return or, nil
+
+	default:
+		return nil, fmt.Errorf("unknown query node %T", raw)
+	}
+
+	if node != nil && !excluded {
+		n.addStems(node)
+	}
+	return node, nil
+}
+
+// terms builds any of the distinct not stop word stems of an unquoted word,
+// which the words service may split into several stems.
+// Only quoted text requires its stems to be adjacent.
+// Excluded terms are not expanded and not used for lookup.
+func (n *normalizer) terms(field string, tokens []Token, fuzzy, excluded bool) Node {
+	var b BoolNode
+	seen := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if t.StopWord || seen[t.Stem] {
+			continue
+		}
+		seen[t.Stem] = true
+		term := TermNode{Field: field, Stem: t.Stem}
+		if (fuzzy || n.fuzzy) && !excluded {
+			b.Should = append(b.Should, n.expand(term))
+			continue
+		}
+		if !excluded {
+			n.addStems(term)
+		}
+		b.Should = append(b.Should, term)
+	}
+	switch len(b.Should) {
+	case 0:
+		return nil
+	case 1:
+		return b.Should[0]
+	}
+	return b
+}
+
+// operand normalizes a single word of a proximity operator.
+func (n *normalizer) operand(ctx context.Context, word string) (string, error) {
+	tokens, err := n.tokenize(ctx, word)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tokens {
+		if !t.StopWord {
+			return t.Stem, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q cannot be used with NEAR", ErrBadArguments, word)
+}
+
+// phraseNode builds a phrase of not stop word tokens,
+// a single stem becomes a term and no stems give nil.
+func phraseNode(field string, tokens []Token) Node {
+	phrase := PhraseNode{Field: field}
+	var first int
+	for _, t := range tokens {
+		if t.StopWord {
+			continue
+		}
+		if len(phrase.Stems) == 0 {
+			first = t.Position
+		}
+		phrase.Stems = append(phrase.Stems, t.Stem)
+		phrase.Offsets = append(phrase.Offsets, t.Position-first)
+	}
+	switch len(phrase.Stems) {
+	case 0:
+		return nil
+	case 1:
+		return TermNode{Field: field, Stem: phrase.Stems[0]}
+	}
+	return phrase
+}
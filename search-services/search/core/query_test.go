@@ -0,0 +1,169 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPhraseNode_Match(t *testing.T) {
+	d := Document{Positions: NewPositions([]Term{
+		{Field: "title", Term: "lord", Positions: []int{0}},
+		{Field: "title", Term: "ring", Positions: []int{3}},
+		{Field: "alt", Term: "ring", Positions: []int{1}},
+	})}
+
+	if !(PhraseNode{Stems: []string{"lord", "ring"}, Offsets: []int{0, 3}}).Match(d) {
+		t.Fatalf("expected phrase with gap to match")
+	}
+	if (PhraseNode{Stems: []string{"lord", "ring"}, Offsets: []int{0, 1}}).Match(d) {
+		t.Fatalf("expected adjacent phrase not to match")
+	}
+	if (PhraseNode{Stems: []string{"ring", "lord"}, Offsets: []int{0, 3}}).Match(d) {
+		t.Fatalf("expected reversed phrase not to match")
+	}
+	if (PhraseNode{Field: "alt", Stems: []string{"lord", "ring"}, Offsets: []int{0, 3}}).Match(d) {
+		t.Fatalf("expected phrase not to match in other field")
+	}
+}
+
+func TestNearNode_Match(t *testing.T) {
+	d := Document{Positions: NewPositions([]Term{
+		{Field: "title", Term: "cat", Positions: []int{10}},
+		{Field: "title", Term: "dog", Positions: []int{2, 13}},
+		{Field: "alt", Term: "mouse", Positions: []int{10}},
+	})}
+
+	if !(NearNode{Left: "cat", Right: "dog", Distance: 3}).Match(d) {
+		t.Fatalf("expected cat and dog to be near")
+	}
+	if !(NearNode{Left: "dog", Right: "cat", Distance: 3}).Match(d) {
+		t.Fatalf("expected NEAR to be symmetric")
+	}
+	if (NearNode{Left: "cat", Right: "dog", Distance: 2}).Match(d) {
+		t.Fatalf("expected cat and dog not to be within 2")
+	}
+	if (NearNode{Left: "cat", Right: "mouse", Distance: 5}).Match(d) {
+		t.Fatalf("expected terms in different fields not to match")
+	}
+}
+
+func TestBoolNode_Match(t *testing.T) {
+	d := Document{TF: map[string]int{"a": 1, "b": 1}}
+
+	cases := []struct {
+		node  BoolNode
+		match bool
+	}{
+		{BoolNode{Should: []Node{TermNode{Stem: "a"}, TermNode{Stem: "x"}}}, true},
+		{BoolNode{Should: []Node{TermNode{Stem: "x"}}}, false},
+		{BoolNode{Must: []Node{TermNode{Stem: "a"}}, Should: []Node{TermNode{Stem: "x"}}}, true},
+		{BoolNode{Must: []Node{TermNode{Stem: "a"}, TermNode{Stem: "x"}}}, false},
+		{BoolNode{Should: []Node{TermNode{Stem: "a"}}, MustNot: []Node{TermNode{Stem: "b"}}}, false},
+		{BoolNode{MustNot: []Node{TermNode{Stem: "x"}}}, false},
+	}
+	for i, c := range cases {
+		if got := c.node.Match(d); got != c.match {
+			t.Fatalf("case %d: expected %v, got %v", i, c.match, got)
+		}
+	}
+}
+
+func TestNewDocument_Forms(t *testing.T) {
+	d := NewDocument(Comics{Terms: []Term{
+		{Field: "title", Term: "cat", Surface: "cat", TF: 1},
+		{Field: "alt", Term: "cat", Surface: "cats", TF: 2},
+		{Field: "alt", Term: "dog", TF: 1},
+	}})
+	if len(d.Forms) != 1 || d.Forms["cat"] != "cats" {
+		t.Fatalf("unexpected forms: %v", d.Forms)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	node, err := parseQuery(`+title:physics -"binary tree" (cat NEAR/2 dog OR alt:joke) NEAR`)
+	if err != nil {
+		t.Fatalf("parseQuery returned error: %v", err)
+	}
+	seq, ok := node.(rawBool)
+	if !ok || len(seq.clauses) != 4 {
+		t.Fatalf("unexpected query: %#v", node)
+	}
+	if seq.clauses[0] != (rawClause{occur: occurMust, node: rawTerm{field: "title", text: "physics"}}) {
+		t.Fatalf("unexpected first clause: %#v", seq.clauses[0])
+	}
+	if seq.clauses[1] != (rawClause{occur: occurMustNot, node: rawPhrase{text: "binary tree"}}) {
+		t.Fatalf("unexpected second clause: %#v", seq.clauses[1])
+	}
+	group, ok := seq.clauses[2].node.(rawOr)
+	if !ok || len(group.nodes) != 2 {
+		t.Fatalf("unexpected group: %#v", seq.clauses[2])
+	}
+	near := group.nodes[0].(rawBool).clauses[0]
+	if near != (rawClause{occur: occurMust, node: rawNear{left: "cat", right: "dog", distance: 2}}) {
+		t.Fatalf("unexpected near: %#v", near)
+	}
+	if seq.clauses[3].node != (rawTerm{text: "NEAR"}) {
+		t.Fatalf("expected NEAR without distance to be a word: %#v", seq.clauses[3])
+	}
+}
+
+func TestParseQuery_UnknownFieldIsText(t *testing.T) {
+	node, err := parseQuery(`http://xkcd.com`)
+	if err != nil {
+		t.Fatalf("parseQuery returned error: %v", err)
+	}
+	if node.(rawBool).clauses[0].node != (rawTerm{text: "http://xkcd.com"}) {
+		t.Fatalf("unexpected query: %#v", node)
+	}
+}
+
+func TestParseQuery_Fuzzy(t *testing.T) {
+	node, err := parseQuery(`recursoin~ +title:physcis~ a~b`)
+	if err != nil {
+		t.Fatalf("parseQuery returned error: %v", err)
+	}
+	seq := node.(rawBool)
+	if seq.clauses[0].node != (rawTerm{text: "recursoin", fuzzy: true}) {
+		t.Fatalf("unexpected first clause: %#v", seq.clauses[0])
+	}
+	if seq.clauses[1] != (rawClause{occur: occurMust, node: rawTerm{field: "title", text: "physcis", fuzzy: true}}) {
+		t.Fatalf("unexpected second clause: %#v", seq.clauses[1])
+	}
+	if seq.clauses[2].node != (rawTerm{text: "a~b"}) {
+		t.Fatalf("expected ~ inside a word to be text: %#v", seq.clauses[2])
+	}
+}
+
+func TestMaxFuzzyDistance(t *testing.T) {
+	for stem, want := range map[string]int{"ab": 0, "cat": 1, "galaxi": 2, "recursoin": 3, "шрёдинге": 2} {
+		if got := maxFuzzyDistance(stem); got != want {
+			t.Fatalf("%q: expected %d, got %d", stem, want, got)
+		}
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, phrase := range []string{
+		`"unclosed`,
+		`""`,
+		`(linux`,
+		`linux)`,
+		`OR linux`,
+		`linux OR`,
+		`()`,
+		`NEAR/2 tree`,
+		`tree NEAR/x binary`,
+		`tree NEAR/0 binary`,
+		`tree NEAR/2`,
+		`tree NEAR/2 -binary`,
+		`title:(linux)`,
+		`foo:"binary tree"`,
+		`((((((((((((((((((linux))))))))))))))))))`,
+		`linux ~`,
+		`cat~ NEAR/2 dog`,
+	} {
+		if _, err := parseQuery(phrase); !errors.Is(err, ErrBadArguments) {
+			t.Fatalf("expected ErrBadArguments for %q, got %v", phrase, err)
+		}
+	}
+}
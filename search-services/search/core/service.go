@@ -1,11 +1,14 @@
 package core
 
 import (
-	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
-	"maps"
 	"slices"
+	"strings"
+	"time"
+	"unicode"
 )
 
 type Service struct {
@@ -13,80 +16,308 @@ type Service struct {
 	db        Storager
 	initiator Initiator
 	words     Words
+	scorer    BM25
+	cache     *QueryCache
 }
 
 func NewService(
-	log *slog.Logger, db Storager, words Words, initiator Initiator,
+	log *slog.Logger, db Storager, words Words, initiator Initiator, scorer BM25, cache *QueryCache,
 ) (*Service, error) {
 	return &Service{
 		log:       log,
 		db:        db,
 		words:     words,
 		initiator: initiator,
+		scorer:    scorer,
+		cache:     cache,
 	}, nil
 }
 
-func (s *Service) Search(ctx context.Context, phrase string, limit int) ([]Comics, error) {
+func (s *Service) Search(ctx context.Context, req SearchRequest) (SearchResult, error) {
+	query, offset, err := s.prepare(ctx, req)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	comics, total, err := s.cachedSearch(ctx, modeDB, query, req.Limit, offset, func() ([]Comics, int, error) {
+		return s.searchDB(ctx, req.Phrase, query, req.Limit, offset)
+	})
+	if err != nil {
+		return SearchResult{}, err
+	}
+	return s.result(req, offset, query, comics, total), nil
+}
 
-	keywords, err := s.words.Norm(ctx, phrase)
+// prepare validates the request and builds its query and the offset of the page
+func (s *Service) prepare(ctx context.Context, req SearchRequest) (Query, int, error) {
+	offset, err := pageOffset(req)
 	if err != nil {
-		s.log.Error("failed to find keywords", "error", err)
-		return nil, err
+		return Query{}, 0, err
+	}
+	if err := req.Filter.validate(); err != nil {
+		return Query{}, 0, err
+	}
+	query, err := s.buildQuery(ctx, req.Phrase, req.Fuzzy)
+	if err != nil {
+		s.log.Error("failed to build query", "error", err)
+		return Query{}, 0, err
+	}
+	query.Filter = req.Filter
+	return query, offset, nil
+}
+
+// matchBatch is the number of found comics checked against the query at once
+const matchBatch = 500
+
+// searchDB ranks comics by the query in the DB and returns the page after offset
+// and the number of all matches. Only the comics of the page are fetched. When the
+// query needs fields or positions, found comics are checked with the terms of
+// the query stems only.
+func (s *Service) searchDB(ctx context.Context, phrase string, query Query, limit, offset int) ([]Comics, int, error) {
+	s.log.Info("normalized query", "phrase", phrase, "query", query)
+	if len(query.Words) == 0 {
+		return []Comics{}, 0, nil
+	}
+
+	// collection size comes from the index, document frequencies from the DB
+	ranked, err := s.db.Search(ctx, query, s.scorer, s.initiator.CorpusStats())
+	if err != nil {
+		s.log.Error("failed to search in DB", "error", err, "words", query.Words)
+		return nil, 0, err
+	}
+	s.log.Info("relevant comics", "count", len(ranked))
+	if len(ranked) == 0 {
+		return []Comics{}, 0, nil
+	}
+
+	if anyWord(query.Root) {
+		total := len(ranked)
+		comics, err := s.hydrate(ctx, pageOf(ranked, limit, offset))
+		if err != nil {
+			return nil, 0, err
+		}
+		return comics, total, nil
 	}
-	s.log.Info("normalized query", "phrase", phrase, "keywords", keywords)
 
-	// comics ID -> number of findings
-	scores := map[int]int{}
-	for _, keyword := range keywords {
-		IDs, err := s.db.Search(ctx, keyword)
+	matched, err := s.match(ctx, query, ranked)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(matched)
+	comics, err := s.hydrate(ctx, pageOf(matched, limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+	s.log.Debug("returning comics", "count", len(comics), "total", total)
+	return comics, total, nil
+}
+
+// match keeps the ranked comics matching the query, comics missing in the DB are skipped
+func (s *Service) match(ctx context.Context, query Query, ranked []Ranked) ([]Ranked, error) {
+	stems := queryStems(query.Root)
+	var matched []Ranked
+	for batch := range slices.Chunk(ranked, matchBatch) {
+		ids := make([]int, len(batch))
+		for i, r := range batch {
+			ids[i] = r.ID
+		}
+		found, err := s.db.GetComicsTerms(ctx, stems, ids...)
 		if err != nil {
-			s.log.Error("failed to search keyword in DB", "error", err, "keyword", keyword)
+			s.log.Error("failed to fetch comics terms", "error", err)
 			return nil, err
 		}
-		s.log.Info("found IDs for keyword", "keyword", keyword, "count", len(IDs), "IDs", IDs)
-		for _, ID := range IDs {
-			scores[ID]++
+		docs := make(map[int]Document, len(found))
+		for _, c := range found {
+			docs[c.ID] = NewDocument(c)
+		}
+		for _, r := range batch {
+			if doc, ok := docs[r.ID]; ok && query.Match(doc) {
+				matched = append(matched, r)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// hydrate fetches ranked comics keeping their order and scores,
+// comics missing in the DB are skipped
+func (s *Service) hydrate(ctx context.Context, ranked []Ranked) ([]Comics, error) {
+	if len(ranked) == 0 {
+		return []Comics{}, nil
+	}
+	ids := make([]int, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.ID
+	}
+	found, err := s.db.GetComicsByIDs(ctx, ids...)
+	if err != nil {
+		s.log.Error("failed to fetch comics", "error", err)
+		return nil, err
+	}
+
+	byID := make(map[int]Comics, len(found))
+	for _, c := range found {
+		byID[c.ID] = c
+	}
+	comics := make([]Comics, 0, len(ranked))
+	for _, r := range ranked {
+		if c, ok := byID[r.ID]; ok {
+			c.Score = r.Score
+			comics = append(comics, c)
 		}
 	}
-	s.log.Info("relevant comics", "count", len(scores), "scores", scores)
+	return comics, nil
+}
 
-	// sort by number of findings
-	sorted := slices.SortedFunc(maps.Keys(scores), func(a, b int) int {
-		return cmp.Compare(scores[b], scores[a]) // desc
+func (s *Service) IndexSearch(ctx context.Context, req SearchRequest) (SearchResult, error) {
+	query, offset, err := s.prepare(ctx, req)
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	comics, total, err := s.cachedSearch(ctx, modeIndex, query, req.Limit, offset, func() ([]Comics, int, error) {
+		return s.initiator.GetIndexedComics(ctx, query, req.Limit, offset)
 	})
+	if err != nil {
+		return SearchResult{}, err
+	}
+	return s.result(req, offset, query, comics, total), nil
+}
 
-	// limit results
-	if len(sorted) < limit {
-		limit = len(sorted)
+// FullTextSearch is a baseline for the ranking of other searches, the phrase is
+// parsed and ranked by the DB. Fuzzy search is not supported.
+func (s *Service) FullTextSearch(ctx context.Context, req SearchRequest) (SearchResult, error) {
+	offset, err := pageOffset(req)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if err := req.Filter.validate(); err != nil {
+		return SearchResult{}, err
+	}
+	if req.Fuzzy {
+		return SearchResult{}, fmt.Errorf("%w: fuzzy full-text search", ErrBadArguments)
 	}
-	sorted = sorted[:limit]
 
-	// fetch comics
-	result := make([]Comics, 0, len(sorted))
-	for _, ID := range sorted {
-		comics, err := s.db.Get(ctx, ID)
-		if err != nil {
-			s.log.Error("failed to fetch comics", "id", ID, "error", err)
-			return nil, err
+	comics, total, err := s.db.FullTextSearch(ctx, req.Phrase, req.Filter, req.Limit, offset)
+	if err != nil {
+		s.log.Error("failed to search full text", "error", err)
+		return SearchResult{}, err
+	}
+	res := SearchResult{Comics: comics, Total: total}
+	if next := offset + len(comics); next < total && len(comics) > 0 {
+		res.NextPageToken = pageToken(req, next)
+	}
+	return res, nil
+}
+
+// cachedSearch serves the page of the query from the cache of ranked IDs
+// or searches it and caches the result
+func (s *Service) cachedSearch(
+	ctx context.Context, mode string, query Query, limit, offset int, search func() ([]Comics, int, error),
+) ([]Comics, int, error) {
+	key := cacheKey(mode, query, limit, offset)
+	page, generation, ok := s.cache.get(key)
+	if ok {
+		if comics, ok := s.loadPage(ctx, page); ok {
+			s.log.Debug("query cache hit", "mode", mode, "query", query, "count", len(comics))
+			return comics, page.total, nil
 		}
-		result = append(result, comics)
 	}
-	s.log.Debug("returning comics", "count", len(result))
 
-	return result, nil
+	comics, total, err := search()
+	if err != nil {
+		return nil, 0, err
+	}
+	s.cache.put(key, generation, comics, total)
+	return comics, total, nil
 }
 
-func (s *Service) IndexSearch(ctx context.Context, phrase string, limit int) ([]Comics, error) {
+// loadPage fetches comics of the cached page in the ranked order.
+// A page with comics missing in the DB is stale and searched again.
+func (s *Service) loadPage(ctx context.Context, page cachedPage) ([]Comics, bool) {
+	comics, err := s.hydrate(ctx, page.ranked)
+	if err != nil || len(comics) != len(page.ranked) {
+		return nil, false
+	}
+	return comics, true
+}
 
-	words, err := s.words.Norm(ctx, phrase)
-	if err != nil {
-		s.log.Error("failed to find keywords", "error", err)
-		return nil, err
+// Invalidate drops cached search results, the comics or the index have changed
+func (s *Service) Invalidate() {
+	stats := s.cache.Stats()
+	s.cache.Invalidate()
+	s.log.Info("query cache invalidated", "hits", stats.Hits, "misses", stats.Misses, "size", stats.Size)
+}
+
+// LogCacheStats logs the query cache counters every period until ctx is done.
+// Nothing is logged without the cache or with non-positive period.
+func (s *Service) LogCacheStats(ctx context.Context, period time.Duration) {
+	if s.cache == nil || period <= 0 {
+		return
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			stats := s.cache.Stats()
+			s.log.Info("query cache stats", "hits", stats.Hits, "misses", stats.Misses, "size", stats.Size)
+		case <-ctx.Done():
+			return
+		}
 	}
+}
 
-	comics, err := s.initiator.GetIndexedComics(ctx, words, limit)
+// result adds snippets and the next page token to found comics
+// or spelling suggestions when nothing is found
+func (s *Service) result(req SearchRequest, offset int, query Query, comics []Comics, total int) SearchResult {
+	if total > 0 {
+		addSnippets(query, comics)
+		res := SearchResult{Comics: comics, Total: total}
+		if next := offset + len(comics); next < total && len(comics) > 0 {
+			res.NextPageToken = pageToken(req, next)
+		}
+		return res
+	}
+	suggestions := s.suggest(req.Phrase, query)
+	s.log.Info("nothing found", "phrase", req.Phrase, "suggestions", suggestions)
+	return SearchResult{Comics: []Comics{}, Suggestions: suggestions}
+}
+
+func (s *Service) GetComic(ctx context.Context, id int) (Comics, error) {
+	comics, err := s.db.Get(ctx, id)
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, ErrNotFound) {
+			s.log.Error("failed to fetch comics", "id", id, "error", err)
+		}
+		return Comics{}, err
 	}
 	return comics, nil
 }
+
+// Suggest completes the prefix with indexed words and comics titles
+func (s *Service) Suggest(_ context.Context, prefix string, limit int) ([]Completion, error) {
+	prefix = strings.ToLower(strings.TrimLeftFunc(prefix, unicode.IsSpace))
+	if prefix == "" {
+		return nil, fmt.Errorf("%w: empty prefix", ErrBadArguments)
+	}
+	return s.initiator.Complete(prefix, limit), nil
+}
+
+// buildQuery parses phrase and normalizes all its parts with the words service.
+// Fuzzy terms are expanded with the vocabulary of the index.
+func (s *Service) buildQuery(ctx context.Context, phrase string, fuzzy bool) (Query, error) {
+	raw, err := parseQuery(phrase)
+	if err != nil {
+		return Query{}, err
+	}
+
+	n := newNormalizer(s.words, s.initiator, fuzzy)
+	if err := n.prefetch(ctx, raw); err != nil {
+		return Query{}, err
+	}
+	root, err := n.normalize(ctx, raw, false)
+	if err != nil {
+		return Query{}, err
+	}
+	return Query{Root: root, Words: n.stems, Weights: n.weights, tokens: n.used}, nil
+}
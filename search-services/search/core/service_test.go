@@ -1,82 +1,229 @@
 package core
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"io"
 	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"unicode"
 )
 
 type fakeStorager struct {
 	searchResults map[string][]int
 	comics        map[int]Comics
+	filter        *Filter
+	searches      *int
+	fetched       *[][]int
 
 	searchErr error
 	getErrID  int
 	getErr    error
 }
 
-func (f fakeStorager) Search(ctx context.Context, keyword string) ([]int, error) {
+// Search ranks comics of searchResults like the DB does: document frequencies
+// are counted over the found comics, collection is estimated by them without the index
+func (f fakeStorager) Search(ctx context.Context, query Query, scorer BM25, stats CorpusStats) ([]Ranked, error) {
 	if f.searchErr != nil {
 		return nil, f.searchErr
 	}
-	return f.searchResults[keyword], nil
+	if f.filter != nil {
+		*f.filter = query.Filter
+	}
+	if f.searches != nil {
+		*f.searches++
+	}
+
+	found := map[int]bool{}
+	stats.DocFreq = map[string]int{}
+	for _, word := range query.Words {
+		stats.DocFreq[word] = len(f.searchResults[word])
+		for _, id := range f.searchResults[word] {
+			found[id] = true
+		}
+	}
+	if stats.Docs < len(found) {
+		stats.Docs, stats.TotalLength = len(found), 0
+		for id := range found {
+			_, length := f.comics[id].Frequencies()
+			stats.TotalLength += length
+		}
+	}
+
+	ranked := make([]Ranked, 0, len(found))
+	exact := make(map[int]bool, len(found))
+	for id := range found {
+		doc := NewDocument(f.comics[id])
+		ranked = append(ranked, Ranked{ID: id, Score: query.Score(scorer, stats, doc)})
+		exact[id] = query.Exact(doc)
+	}
+	slices.SortFunc(ranked, func(a, b Ranked) int {
+		return cmp.Or(-compareBool(exact[a.ID], exact[b.ID]), cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+	return ranked, nil
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
 }
 
 func (f fakeStorager) Get(ctx context.Context, id int) (Comics, error) {
 	if f.getErrID != 0 && id == f.getErrID {
 		return Comics{}, f.getErr
 	}
-	return f.comics[id], nil
+	comics, ok := f.comics[id]
+	if !ok {
+		return Comics{}, ErrNotFound
+	}
+	return comics, nil
 }
 
 func (f fakeStorager) GetAllComics(ctx context.Context) ([]Comics, error) {
 	return nil, nil
 }
 
-func (f fakeStorager) GetComicsByIDs(ctx context.Context, ids ...int) ([]Comics, error) {
+func (f fakeStorager) IDs(ctx context.Context) ([]int, error) {
 	return nil, nil
 }
 
+func (f fakeStorager) GetComicsByIDs(ctx context.Context, ids ...int) ([]Comics, error) {
+	if f.fetched != nil {
+		*f.fetched = append(*f.fetched, ids)
+	}
+	var result []Comics
+	for _, id := range ids {
+		if comics, ok := f.comics[id]; ok {
+			result = append(result, comics)
+		}
+	}
+	return result, nil
+}
+
+// GetComicsTerms keeps only words and terms of the stems like the DB does
+func (f fakeStorager) GetComicsTerms(ctx context.Context, stems []string, ids ...int) ([]Comics, error) {
+	var result []Comics
+	for _, id := range ids {
+		c, ok := f.comics[id]
+		if !ok {
+			continue
+		}
+		found := Comics{ID: id}
+		for _, w := range c.Words {
+			if slices.Contains(stems, w) {
+				found.Words = append(found.Words, w)
+			}
+		}
+		for _, t := range c.Terms {
+			if slices.Contains(stems, t.Term) {
+				found.Terms = append(found.Terms, t)
+			}
+		}
+		result = append(result, found)
+	}
+	return result, nil
+}
+
+func (f fakeStorager) FullTextSearch(ctx context.Context, phrase string, filter Filter, limit, offset int) ([]Comics, int, error) {
+	if f.searchErr != nil {
+		return nil, 0, f.searchErr
+	}
+	if f.filter != nil {
+		*f.filter = filter
+	}
+	ids := f.searchResults[phrase]
+	total := len(ids)
+	comics := []Comics{}
+	for _, id := range ids[min(offset, total):min(offset+limit, total)] {
+		comics = append(comics, f.comics[id])
+	}
+	return comics, total, nil
+}
+
 type fakeWords struct {
-	words []string
 	err   error
+	calls *int
 }
 
-func (f fakeWords) Norm(ctx context.Context, phrase string) ([]string, error) {
-	return f.words, f.err
+// NormDetailed treats every word as its own stem, a word is a run of letters
+// and digits, "the" and "of" are stop words
+func (f fakeWords) NormDetailed(ctx context.Context, phrase string) ([]Token, error) {
+	if f.calls != nil {
+		*f.calls++
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	var tokens []Token
+	words := strings.FieldsFunc(phrase, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		tokens = append(tokens, Token{Surface: w, Stem: w, Position: i, StopWord: w == "the" || w == "of"})
+	}
+	return tokens, nil
 }
 
 type fakeInitiator struct {
 	indexedComics []Comics
+	stats         CorpusStats
 	err           error
+	similar       map[string][]SimilarTerm
+	completions   []Completion
+	prefix        *string
 }
 
-func (f fakeInitiator) GetIndexedComics(ctx context.Context, words []string, limit int) ([]Comics, error) {
+func (f fakeInitiator) GetIndexedComics(ctx context.Context, query Query, limit, offset int) ([]Comics, int, error) {
 	if f.err != nil {
-		return nil, f.err
-	}
-	if len(f.indexedComics) < limit {
-		limit = len(f.indexedComics)
+		return nil, 0, f.err
 	}
-	return f.indexedComics[:limit], nil
+	total := len(f.indexedComics)
+	return f.indexedComics[min(offset, total):min(offset+limit, total)], total, nil
 }
 
 func (f fakeInitiator) IndexComics(ctx context.Context) error {
 	return nil
 }
 
+func (f fakeInitiator) UpdateIndex(ctx context.Context, changed, removed []int) error {
+	return nil
+}
+
 func (f fakeInitiator) ClearIndex(ctx context.Context) error {
 	return nil
 }
 
+func (f fakeInitiator) CorpusStats() CorpusStats {
+	return f.stats
+}
+
+func (f fakeInitiator) SimilarTerms(term string, maxDistance int) []SimilarTerm {
+	return f.similar[term]
+}
+
+func (f fakeInitiator) Complete(prefix string, limit int) []Completion {
+	if f.prefix != nil {
+		*f.prefix = prefix
+	}
+	return f.completions[:min(limit, len(f.completions))]
+}
+
 func newTestService(t *testing.T, db Storager, w Words, init Initiator) *Service {
 	t.Helper()
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	s, err := NewService(logger, db, w, init)
+	s, err := NewService(logger, db, w, init, BM25{K1: 1.2, B: 0.75}, nil)
 	if err != nil {
 		t.Fatalf("NewService returned error: %v", err)
 	}
@@ -92,29 +239,54 @@ func TestService_Search_Success(t *testing.T) {
 			"cpu":   {2, 3},
 		},
 		comics: map[int]Comics{
-			1: {ID: 1, URL: "url1"},
-			2: {ID: 2, URL: "url2"},
-			3: {ID: 3, URL: "url3"},
+			1: {ID: 1, URL: "url1", Words: []string{"linux", "kernel"}},
+			2: {ID: 2, URL: "url2", Words: []string{"linux", "cpu"}},
+			3: {ID: 3, URL: "url3", Words: []string{"cpu", "fan"}},
 		},
 	}
 
-	words := fakeWords{
-		words: []string{"linux", "cpu"},
-	}
+	words := fakeWords{}
 
 	s := newTestService(t, db, words, fakeInitiator{})
 
-	result, err := s.Search(ctx, "linux cpu", 2)
+	result, err := s.Search(ctx, SearchRequest{Phrase: "linux cpu", Limit: 2})
 	if err != nil {
 		t.Fatalf("Search returned error: %v", err)
 	}
 
-	if len(result) != 2 {
-		t.Fatalf("expected 2 comics, got %d", len(result))
+	if len(result.Comics) != 2 {
+		t.Fatalf("expected 2 comics, got %d", len(result.Comics))
 	}
 
-	if result[0].ID != 2 {
-		t.Fatalf("expected first result to have ID=2, got %d", result[0].ID)
+	if result.Comics[0].ID != 2 {
+		t.Fatalf("expected first result to have ID=2, got %d", result.Comics[0].ID)
+	}
+	if result.Comics[0].Score <= result.Comics[1].Score {
+		t.Fatalf("expected descending scores, got %v and %v", result.Comics[0].Score, result.Comics[1].Score)
+	}
+}
+
+func TestService_Search_RareTermWins(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{
+			"comic": {1, 2, 3},
+			"tux":   {3},
+		},
+		comics: map[int]Comics{
+			1: {ID: 1, URL: "url1", Words: []string{"comic", "a"}},
+			2: {ID: 2, URL: "url2", Words: []string{"comic", "b"}},
+			3: {ID: 3, URL: "url3", Words: []string{"tux", "c"}},
+		},
+	}
+	init := fakeInitiator{stats: CorpusStats{Docs: 10, TotalLength: 20}}
+	s := newTestService(t, db, fakeWords{}, init)
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "comic tux", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(result.Comics) != 3 || result.Comics[0].ID != 3 {
+		t.Fatalf("expected comics with rare term first, got %#v", result)
 	}
 }
 
@@ -128,11 +300,11 @@ func TestService_Search_WordsError(t *testing.T) {
 
 	s := newTestService(t, db, words, fakeInitiator{})
 
-	result, err := s.Search(ctx, "phrase", 10)
+	result, err := s.Search(ctx, SearchRequest{Phrase: "phrase", Limit: 10})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
-	if result != nil {
+	if result.Comics != nil {
 		t.Fatalf("expected nil result on error, got %#v", result)
 	}
 }
@@ -140,9 +312,7 @@ func TestService_Search_WordsError(t *testing.T) {
 func TestService_IndexSearch_Success(t *testing.T) {
 	ctx := context.Background()
 
-	words := fakeWords{
-		words: []string{"linux"},
-	}
+	words := fakeWords{}
 
 	init := fakeInitiator{
 		indexedComics: []Comics{
@@ -153,15 +323,375 @@ func TestService_IndexSearch_Success(t *testing.T) {
 
 	s := newTestService(t, fakeStorager{}, words, init)
 
-	result, err := s.IndexSearch(ctx, "linux", 1)
+	result, err := s.IndexSearch(ctx, SearchRequest{Phrase: "linux", Limit: 1})
 	if err != nil {
 		t.Fatalf("IndexSearch returned error: %v", err)
 	}
 
-	if len(result) != 1 {
-		t.Fatalf("expected 1 comics, got %d", len(result))
+	if len(result.Comics) != 1 {
+		t.Fatalf("expected 1 comics, got %d", len(result.Comics))
+	}
+	if result.Comics[0].ID != 1 {
+		t.Fatalf("expected comics ID=1, got %d", result.Comics[0].ID)
+	}
+}
+
+func TestService_GetComic(t *testing.T) {
+	db := fakeStorager{
+		comics: map[int]Comics{
+			1: {ID: 1, URL: "url1", Title: "title"},
+		},
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	comics, err := s.GetComic(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetComic returned error: %v", err)
+	}
+	if comics.Title != "title" {
+		t.Fatalf("unexpected comics: %#v", comics)
 	}
-	if result[0].ID != 1 {
-		t.Fatalf("expected comics ID=1, got %d", result[0].ID)
+
+	if _, err := s.GetComic(context.Background(), 2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestService_Search_Phrase(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{
+			"binary": {1, 2},
+			"tree":   {1, 2},
+		},
+		comics: map[int]Comics{
+			1: {ID: 1, URL: "url1", Terms: []Term{
+				{Field: "title", Term: "binary", TF: 1, Positions: []int{3}},
+				{Field: "title", Term: "tree", TF: 1, Positions: []int{4}},
+			}},
+			2: {ID: 2, URL: "url2", Terms: []Term{
+				{Field: "title", Term: "tree", TF: 1, Positions: []int{0}},
+				{Field: "title", Term: "binary", TF: 1, Positions: []int{5}},
+			}},
+		},
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: `"binary tree"`, Limit: 10})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(result.Comics) != 1 || result.Comics[0].ID != 1 {
+		t.Fatalf("expected only comics 1, got %#v", result)
+	}
+
+	result, err = s.Search(context.Background(), SearchRequest{Phrase: `tree NEAR/5 binary`, Limit: 10})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(result.Comics) != 2 {
+		t.Fatalf("expected both comics within distance, got %#v", result)
+	}
+}
+
+func TestService_Search_BadQuery(t *testing.T) {
+	s := newTestService(t, fakeStorager{}, fakeWords{}, fakeInitiator{})
+
+	for _, phrase := range []string{`"unclosed`, `NEAR/2 tree`, `tree NEAR/x binary`, `tree NEAR/2`} {
+		if _, err := s.Search(context.Background(), SearchRequest{Phrase: phrase, Limit: 10}); !errors.Is(err, ErrBadArguments) {
+			t.Fatalf("expected ErrBadArguments for %q, got %v", phrase, err)
+		}
+	}
+}
+
+func TestService_BuildQuery(t *testing.T) {
+	s := newTestService(t, fakeStorager{}, fakeWords{}, fakeInitiator{})
+
+	query, err := s.buildQuery(context.Background(), `linux "lord of the rings" cat NEAR/3 dog -windows`, false)
+	if err != nil {
+		t.Fatalf("buildQuery returned error: %v", err)
+	}
+	if !slices.Equal(query.Words, []string{"linux", "lord", "rings", "cat", "dog"}) {
+		t.Fatalf("unexpected words: %v", query.Words)
+	}
+
+	root, ok := query.Root.(BoolNode)
+	if !ok {
+		t.Fatalf("unexpected root: %#v", query.Root)
+	}
+	if len(root.Should) != 1 || root.Should[0] != (TermNode{Stem: "linux"}) {
+		t.Fatalf("unexpected optional nodes: %#v", root.Should)
+	}
+	if len(root.Must) != 2 {
+		t.Fatalf("expected phrase and NEAR to be required: %#v", root.Must)
+	}
+	if phrase, ok := root.Must[0].(PhraseNode); !ok || !slices.Equal(phrase.Offsets, []int{0, 3}) {
+		t.Fatalf("unexpected phrase: %#v", root.Must[0])
+	}
+	if root.Must[1] != (NearNode{Left: "cat", Right: "dog", Distance: 3}) {
+		t.Fatalf("unexpected near: %#v", root.Must[1])
+	}
+	if len(root.MustNot) != 1 || root.MustNot[0] != (TermNode{Stem: "windows"}) {
+		t.Fatalf("unexpected excluded nodes: %#v", root.MustNot)
+	}
+}
+
+func TestService_BuildQuery_OneCall(t *testing.T) {
+	var calls int
+	s := newTestService(t, fakeStorager{}, fakeWords{calls: &calls}, fakeInitiator{})
+
+	query, err := s.buildQuery(context.Background(), `linux "the lord of rings" cat NEAR/3 dog (linux OR unix) -windows`, false)
+	if err != nil {
+		t.Fatalf("buildQuery returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected query to be normalized in one call, got %d", calls)
+	}
+	if !slices.Equal(query.Words, []string{"linux", "lord", "rings", "cat", "dog", "unix"}) {
+		t.Fatalf("unexpected words: %v", query.Words)
+	}
+
+	// phrase offsets are counted from the start of the phrase
+	root := query.Root.(BoolNode)
+	if phrase, ok := root.Must[0].(PhraseNode); !ok || !slices.Equal(phrase.Offsets, []int{0, 2}) {
+		t.Fatalf("unexpected phrase: %#v", root.Must[0])
+	}
+}
+
+func TestService_BuildQuery_SplitWord(t *testing.T) {
+	s := newTestService(t, fakeStorager{}, fakeWords{}, fakeInitiator{})
+
+	query, err := s.buildQuery(context.Background(), `linux+the+cpu+linux -"lord+rings"`, false)
+	if err != nil {
+		t.Fatalf("buildQuery returned error: %v", err)
+	}
+	if !slices.Equal(query.Words, []string{"linux", "cpu"}) {
+		t.Fatalf("unexpected words: %v", query.Words)
+	}
+	root := query.Root.(BoolNode)
+	want := BoolNode{Should: []Node{TermNode{Stem: "linux"}, TermNode{Stem: "cpu"}}}
+	if len(root.Should) != 1 || !reflect.DeepEqual(root.Should[0], want) {
+		t.Fatalf("expected unquoted word to be any of its stems: %#v", root.Should)
+	}
+	if _, ok := root.MustNot[0].(PhraseNode); !ok {
+		t.Fatalf("expected quoted text to stay a phrase: %#v", root.MustNot[0])
+	}
+	if !anyWord(root.Should[0]) {
+		t.Fatalf("expected split word to match comics with any stem")
+	}
+}
+
+func TestService_BuildQuery_Fuzzy(t *testing.T) {
+	init := fakeInitiator{similar: map[string][]SimilarTerm{
+		"recursoin": {{Term: "recurs", Distance: 3}, {Term: "recursion", Distance: 2}},
+		"linux":     {{Term: "linux", Distance: 0}, {Term: "linus", Distance: 1}},
+		"windows":   {{Term: "window", Distance: 1}},
+	}}
+	s := newTestService(t, fakeStorager{}, fakeWords{}, init)
+
+	query, err := s.buildQuery(context.Background(), `recursoin~ linux -windows~`, false)
+	if err != nil {
+		t.Fatalf("buildQuery returned error: %v", err)
+	}
+	if !slices.Equal(query.Words, []string{"recursoin", "recurs", "recursion", "linux"}) {
+		t.Fatalf("unexpected words: %v", query.Words)
+	}
+	if query.Weight("recursoin") != 1 || query.Weight("recurs") != 0.125 || query.Weight("recursion") != 0.25 {
+		t.Fatalf("unexpected weights: %v", query.Weights)
+	}
+	root := query.Root.(BoolNode)
+	if or, ok := root.Should[0].(OrNode); !ok || len(or.Nodes) != 3 {
+		t.Fatalf("expected fuzzy term to be expanded: %#v", root.Should[0])
+	}
+	if root.Should[1] != (TermNode{Stem: "linux"}) {
+		t.Fatalf("expected term without ~ to stay exact: %#v", root.Should[1])
+	}
+	if root.MustNot[0] != (TermNode{Stem: "windows"}) {
+		t.Fatalf("expected excluded term not to be expanded: %#v", root.MustNot[0])
+	}
+
+	query, err = s.buildQuery(context.Background(), `linux`, true)
+	if err != nil {
+		t.Fatalf("buildQuery returned error: %v", err)
+	}
+	if !slices.Equal(query.Words, []string{"linux", "linus"}) || query.Weight("linux") != 1 || query.Weight("linus") != 0.5 {
+		t.Fatalf("expected every term to be expanded in fuzzy mode: %v %v", query.Words, query.Weights)
+	}
+}
+
+func TestService_Search_FuzzyRanksBelowExact(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{
+			"galaxi": {1},
+			"galxi":  {2},
+		},
+		comics: map[int]Comics{
+			1: {ID: 1, URL: "url1", Words: []string{"galaxi"}},
+			2: {ID: 2, URL: "url2", Words: []string{"galxi"}},
+		},
+	}
+	init := fakeInitiator{similar: map[string][]SimilarTerm{
+		"galaxi": {{Term: "galaxi", Distance: 0}, {Term: "galxi", Distance: 1}},
+	}}
+	s := newTestService(t, db, fakeWords{}, init)
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "galaxi", Limit: 10, Fuzzy: true})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(result.Comics) != 2 || result.Comics[0].ID != 1 || result.Comics[1].ID != 2 || result.Comics[1].Score >= result.Comics[0].Score {
+		t.Fatalf("expected fuzzy match to rank below exact one: %#v", result)
+	}
+}
+
+func TestService_Search_RareFuzzyRanksBelowExact(t *testing.T) {
+	// the fuzzy term is rarer, so its weighted BM25 is higher than of the exact one
+	db := fakeStorager{
+		searchResults: map[string][]int{
+			"galaxi": {1, 2, 3, 4},
+			"galxi":  {5},
+		},
+		comics: map[int]Comics{
+			1: {ID: 1, URL: "url1", Words: []string{"galaxi"}},
+			2: {ID: 2, URL: "url2", Words: []string{"galaxi"}},
+			3: {ID: 3, URL: "url3", Words: []string{"galaxi"}},
+			4: {ID: 4, URL: "url4", Words: []string{"galaxi"}},
+			5: {ID: 5, URL: "url5", Words: []string{"galxi"}},
+		},
+	}
+	init := fakeInitiator{similar: map[string][]SimilarTerm{
+		"galaxi": {{Term: "galaxi", Distance: 0}, {Term: "galxi", Distance: 1}},
+	}}
+	s := newTestService(t, db, fakeWords{}, init)
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "galaxi", Limit: 10, Fuzzy: true})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	last := result.Comics[len(result.Comics)-1]
+	if len(result.Comics) != 5 || last.ID != 5 || last.Score <= result.Comics[0].Score {
+		t.Fatalf("expected rare fuzzy match with the highest score to rank last: %#v", result)
+	}
+}
+
+func TestQuery_Exact(t *testing.T) {
+	query := Query{Words: []string{"galaxi", "galxi"}, Weights: map[string]float64{"galxi": 0.5}}
+	if !query.Exact(Document{TF: map[string]int{"galaxi": 1, "galxi": 2}}) {
+		t.Fatalf("expected document with the query word to be exact")
+	}
+	if query.Exact(Document{TF: map[string]int{"galxi": 2}}) {
+		t.Fatalf("expected document with the fuzzy expansion only not to be exact")
+	}
+}
+
+func TestService_Search_Boolean(t *testing.T) {
+	db := fakeStorager{
+		searchResults: map[string][]int{
+			"physics": {1, 2, 3},
+			"joke":    {2, 3},
+			"math":    {4},
+		},
+		comics: map[int]Comics{
+			1: {ID: 1, URL: "url1", Terms: []Term{
+				{Field: "title", Term: "physics", TF: 1, Positions: []int{0}},
+			}},
+			2: {ID: 2, URL: "url2", Terms: []Term{
+				{Field: "alt", Term: "physics", TF: 1, Positions: []int{0}},
+				{Field: "alt", Term: "joke", TF: 1, Positions: []int{1}},
+			}},
+			3: {ID: 3, URL: "url3", Terms: []Term{
+				{Field: "title", Term: "physics", TF: 1, Positions: []int{0}},
+				{Field: "alt", Term: "joke", TF: 1, Positions: []int{3}},
+			}},
+			4: {ID: 4, URL: "url4", Terms: []Term{
+				{Field: "title", Term: "math", TF: 1, Positions: []int{0}},
+			}},
+		},
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	cases := []struct {
+		phrase string
+		ids    []int
+	}{
+		{"title:physics alt:joke", []int{1, 2, 3}},
+		{"+title:physics +alt:joke", []int{3}},
+		{"physics -joke", []int{1}},
+		{"(+physics -title:physics) OR math", []int{2, 4}},
+	}
+	for _, c := range cases {
+		result, err := s.Search(context.Background(), SearchRequest{Phrase: c.phrase, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", c.phrase, err)
+		}
+		ids := make([]int, 0, len(result.Comics))
+		for _, r := range result.Comics {
+			ids = append(ids, r.ID)
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, c.ids) {
+			t.Fatalf("Search(%q) = %v, expected %v", c.phrase, ids, c.ids)
+		}
+	}
+}
+
+func TestService_Suggest(t *testing.T) {
+	var prefix string
+	init := fakeInitiator{
+		prefix:      &prefix,
+		completions: []Completion{{Text: "linux", Kind: CompletionWord, Frequency: 3}, {Text: "link", Kind: CompletionWord, Frequency: 1}},
+	}
+	s := newTestService(t, fakeStorager{}, fakeWords{}, init)
+
+	got, err := s.Suggest(context.Background(), "  LiN", 1)
+	if err != nil {
+		t.Fatalf("Suggest returned error: %v", err)
+	}
+	if prefix != "lin" {
+		t.Fatalf("expected normalized prefix %q, got %q", "lin", prefix)
+	}
+	if len(got) != 1 || got[0].Text != "linux" {
+		t.Fatalf("unexpected completions: %v", got)
+	}
+
+	if _, err := s.Suggest(context.Background(), " ", 10); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("expected ErrBadArguments for empty prefix, got %v", err)
+	}
+}
+
+func TestService_FullTextSearch(t *testing.T) {
+	var filter Filter
+	db := fakeStorager{
+		searchResults: map[string][]int{"linux kernel": {3, 1, 2}},
+		comics:        map[int]Comics{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}},
+		filter:        &filter,
+	}
+	s := newTestService(t, db, fakeWords{}, fakeInitiator{})
+
+	req := SearchRequest{Phrase: "linux kernel", Limit: 2, Filter: Filter{MaxID: 10}}
+	result, err := s.FullTextSearch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	if result.Total != 3 || len(result.Comics) != 2 || result.Comics[0].ID != 3 || filter != req.Filter {
+		t.Fatalf("unexpected result %#v with filter %+v", result, filter)
+	}
+
+	req.PageToken = result.NextPageToken
+	result, err = s.FullTextSearch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FullTextSearch returned error: %v", err)
+	}
+	if len(result.Comics) != 1 || result.Comics[0].ID != 2 || result.NextPageToken != "" {
+		t.Fatalf("expected the last page, got %#v", result)
+	}
+
+	for _, bad := range []SearchRequest{
+		{Phrase: "linux", Fuzzy: true},
+		{Phrase: "linux", Filter: Filter{MinID: 5, MaxID: 1}},
+		{Phrase: "linux", PageToken: "bad"},
+	} {
+		if _, err := s.FullTextSearch(context.Background(), bad); !errors.Is(err, ErrBadArguments) {
+			t.Fatalf("%+v: expected ErrBadArguments, got %v", bad, err)
+		}
 	}
 }
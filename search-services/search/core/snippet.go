@@ -0,0 +1,163 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetWords limits the number of words in a snippet of a long field
+	snippetWords = 24
+	// snippetContext is the number of words kept before the first match of a snippet
+	snippetContext = 4
+	ellipsis       = "…"
+)
+
+// addSnippets builds snippets of the title, alt text and transcript of the comics
+// with the query words highlighted. The words are found by positions of the comics
+// terms, so snippets are built without the words service. Comics without texts
+// or terms are left without snippets.
+func addSnippets(query Query, comics []Comics) {
+	stems := make(map[string]bool, len(query.Words))
+	for _, word := range query.Words {
+		stems[word] = true
+	}
+	if len(stems) == 0 {
+		return
+	}
+
+	for i := range comics {
+		fields := []struct {
+			name string
+			text string
+		}{
+			{FieldTitle, comics[i].Title},
+			{FieldAlt, comics[i].Alt},
+			{FieldTranscript, comics[i].Transcript},
+		}
+		for _, field := range fields {
+			if field.text == "" {
+				continue
+			}
+			matched := make(map[int]bool)
+			for _, term := range comics[i].Terms {
+				if term.Field != field.name || !stems[term.Term] {
+					continue
+				}
+				for _, p := range term.Positions {
+					matched[p] = true
+				}
+			}
+			if snippet, ok := buildSnippet(field.name, field.text, matched); ok {
+				comics[i].Snippets = append(comics[i].Snippets, snippet)
+			}
+		}
+	}
+}
+
+// wordSpans returns byte ranges of the words of the text. Words are runs of
+// letters and digits as the words service splits them, so the index of a span
+// is the position of the token.
+func wordSpans(text string) []Highlight {
+	var spans []Highlight
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			spans = append(spans, Highlight{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, Highlight{Start: start, End: len(text)})
+	}
+	return spans
+}
+
+// buildSnippet cuts the window of the text with the most matched words and
+// highlights them, matched are positions of the words. Positions past the end
+// of the text are ignored. Runs of white space are squeezed.
+func buildSnippet(field, text string, matched map[int]bool) (Snippet, bool) {
+	spans := wordSpans(text)
+	var positions []int
+	for i := range spans {
+		if matched[i] {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) == 0 {
+		return Snippet{}, false
+	}
+
+	from, to := snippetWindow(len(spans), positions)
+	var b strings.Builder
+	snippet := Snippet{Field: field}
+	if from > 0 {
+		b.WriteString(ellipsis + " ")
+	} else {
+		b.WriteString(strings.TrimLeft(squeeze(text[:spans[0].Start]), " "))
+	}
+	for i := from; i < to; i++ {
+		if i > from {
+			b.WriteString(squeeze(text[spans[i-1].End:spans[i].Start]))
+		}
+		start := b.Len()
+		b.WriteString(text[spans[i].Start:spans[i].End])
+		if matched[i] {
+			snippet.Highlights = append(snippet.Highlights, Highlight{Start: start, End: b.Len()})
+		}
+	}
+	if to < len(spans) {
+		b.WriteString(" " + ellipsis)
+	} else {
+		b.WriteString(strings.TrimRight(squeeze(text[spans[len(spans)-1].End:]), " "))
+	}
+	snippet.Text = b.String()
+	return snippet, true
+}
+
+// snippetWindow returns the range of snippetWords tokens with the most matches,
+// started a few words before a match. Of equal windows the first one wins.
+func snippetWindow(total int, matched []int) (from, to int) {
+	if total <= snippetWords {
+		return 0, total
+	}
+	best := -1
+	for _, m := range matched {
+		start := min(max(0, m-snippetContext), total-snippetWords)
+		count := 0
+		for _, other := range matched {
+			if other >= start && other < start+snippetWords {
+				count++
+			}
+		}
+		if count > best {
+			best, from = count, start
+		}
+	}
+	return from, from + snippetWords
+}
+
+// squeeze replaces every run of white space with a single space
+func squeeze(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// matchedWords returns positions of the words of text with the stems,
+// text is split like the words service does and stems are lower-cased words
+func matchedWords(text string, stems ...string) map[int]bool {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	matched := make(map[int]bool)
+	for i, w := range words {
+		if slices.Contains(stems, strings.ToLower(w)) {
+			matched[i] = true
+		}
+	}
+	return matched
+}
+
+// marked renders highlights of the snippet in brackets
+func marked(s Snippet) string {
+	var b strings.Builder
+	pos := 0
+	for _, h := range s.Highlights {
+		b.WriteString(s.Text[pos:h.Start] + "[" + s.Text[h.Start:h.End] + "]")
+		pos = h.End
+	}
+	b.WriteString(s.Text[pos:])
+	return b.String()
+}
+
+func TestBuildSnippet(t *testing.T) {
+	var long []string
+	for i := range 60 {
+		long = append(long, fmt.Sprintf("w%d", i))
+	}
+	long[40], long[42] = "Linux", "kernel"
+
+	cases := []struct {
+		name, text, want string
+	}{
+		{"whole short text", "  Linux User\n\nat   Best Buy. ", "[Linux] User at Best Buy."},
+		{"surface forms", "LINUX, linux; the Kernel!", "[LINUX], [linux]; the [Kernel]!"},
+		{"window of long text", strings.Join(long, " "),
+			"… w36 w37 w38 w39 [Linux] w41 [kernel] w43 w44 w45 w46 w47 w48 w49 w50 w51 w52 w53 w54 w55 w56 w57 w58 w59"},
+		{"unicode", "ёжик и linux", "ёжик и [linux]"},
+	}
+	for _, c := range cases {
+		snippet, ok := buildSnippet(FieldAlt, c.text, matchedWords(c.text, "linux", "kernel"))
+		if !ok {
+			t.Fatalf("%s: expected snippet", c.name)
+		}
+		if got := marked(snippet); got != c.want {
+			t.Fatalf("%s: expected %q, got %q", c.name, c.want, got)
+		}
+		if snippet.Field != FieldAlt {
+			t.Fatalf("%s: unexpected field %q", c.name, snippet.Field)
+		}
+	}
+
+	if _, ok := buildSnippet(FieldAlt, "nothing here", matchedWords("nothing here", "linux")); ok {
+		t.Fatalf("expected no snippet without matches")
+	}
+	// positions of stale terms past the end of the text are skipped
+	snippet, ok := buildSnippet(FieldAlt, "linux kernel", map[int]bool{1: true, 7: true})
+	if !ok || marked(snippet) != "linux [kernel]" {
+		t.Fatalf("expected positions past the end to be ignored, got %q", marked(snippet))
+	}
+	if _, ok := buildSnippet(FieldAlt, "linux", map[int]bool{3: true}); ok {
+		t.Fatalf("expected no snippet without matches in the text")
+	}
+}
+
+func TestSnippetWindow(t *testing.T) {
+	cases := []struct {
+		total    int
+		matched  []int
+		from, to int
+	}{
+		{10, []int{3}, 0, 10},
+		{100, []int{2}, 0, snippetWords},
+		{100, []int{98}, 100 - snippetWords, 100},
+		{100, []int{10, 50, 55, 60}, 46, 46 + snippetWords},
+	}
+	for _, c := range cases {
+		from, to := snippetWindow(c.total, c.matched)
+		if from != c.from || to != c.to {
+			t.Fatalf("snippetWindow(%d, %v): expected [%d, %d), got [%d, %d)", c.total, c.matched, c.from, c.to, from, to)
+		}
+	}
+}
+
+func TestService_IndexSearch_Snippets(t *testing.T) {
+	init := fakeInitiator{indexedComics: []Comics{
+		{ID: 1, Title: "linux user", Alt: "no match", Transcript: "the linux kernel", Terms: []Term{
+			{Field: FieldTitle, Term: "linux", Positions: []int{0}},
+			{Field: FieldTitle, Term: "user", Positions: []int{1}},
+			{Field: FieldTranscript, Term: "linux", Positions: []int{1}},
+			{Field: FieldTranscript, Term: "kernel", Positions: []int{2}},
+		}},
+		{ID: 2},
+		// comics without terms are left without snippets
+		{ID: 3, Title: "linux"},
+	}}
+	s := newTestService(t, fakeStorager{}, fakeWords{}, init)
+
+	result, err := s.IndexSearch(context.Background(), SearchRequest{Phrase: "linux", Limit: 10})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	want := []Snippet{
+		{Field: FieldTitle, Text: "linux user", Highlights: []Highlight{{0, 5}}},
+		{Field: FieldTranscript, Text: "the linux kernel", Highlights: []Highlight{{4, 9}}},
+	}
+	got := result.Comics[0].Snippets
+	if !slices.EqualFunc(got, want, func(a, b Snippet) bool {
+		return a.Field == b.Field && a.Text == b.Text && slices.Equal(a.Highlights, b.Highlights)
+	}) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if result.Comics[1].Snippets != nil || result.Comics[2].Snippets != nil {
+		t.Fatalf("expected no snippets without texts or terms, got %v", result.Comics[1:])
+	}
+}
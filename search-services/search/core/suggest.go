@@ -0,0 +1,97 @@
+package core
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSuggestions limits the number of corrected phrases
+const maxSuggestions = 3
+
+// correction is a misspelled word of the phrase and its replacements, the best first
+type correction struct {
+	word  string
+	forms []string
+}
+
+// suggest replaces words of the phrase that are missing in the vocabulary with
+// similar indexed terms. Closer terms are preferred, then the more frequent ones.
+func (s *Service) suggest(phrase string, query Query) []string {
+	var corrections []correction
+	for _, token := range query.tokens {
+		similar := slices.Clone(s.initiator.SimilarTerms(token.Stem, maxFuzzyDistance(token.Stem)))
+		known := slices.ContainsFunc(similar, func(t SimilarTerm) bool { return t.Distance == 0 })
+		if known || len(similar) == 0 {
+			continue
+		}
+		slices.SortFunc(similar, func(a, b SimilarTerm) int {
+			return cmp.Or(
+				cmp.Compare(a.Distance, b.Distance),
+				cmp.Compare(b.Frequency, a.Frequency),
+				cmp.Compare(a.Term, b.Term),
+			)
+		})
+
+		c := correction{word: token.Surface}
+		for _, t := range similar {
+			// comics indexed without surface forms give only stems
+			form := cmp.Or(t.Form, t.Term)
+			if !slices.Contains(c.forms, form) {
+				c.forms = append(c.forms, form)
+			}
+			if len(c.forms) == maxSuggestions {
+				break
+			}
+		}
+		corrections = append(corrections, c)
+	}
+	if len(corrections) == 0 {
+		return nil
+	}
+
+	// the i-th suggestion takes the i-th replacement of every word where there is one
+	var suggestions []string
+	for i := range maxSuggestions {
+		suggestion := phrase
+		for _, c := range corrections {
+			suggestion = replaceWord(suggestion, c.word, c.forms[min(i, len(c.forms)-1)])
+		}
+		if !slices.Contains(suggestions, suggestion) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// replaceWord replaces whole word occurrences of word in text,
+// so operators, fields and other words of the query are kept as is
+func replaceWord(text, word, replacement string) string {
+	if word == "" {
+		return text
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(text, word)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		b.WriteString(text[:i])
+		if isWordRune(before) || isWordRune(after) {
+			b.WriteString(word)
+		} else {
+			b.WriteString(replacement)
+		}
+		text = text[end:]
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
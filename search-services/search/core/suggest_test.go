@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestReplaceWord(t *testing.T) {
+	cases := []struct {
+		text, word, replacement, want string
+	}{
+		{"recursoin", "recursoin", "recursion", "recursion"},
+		{`+title:physcis "physcis joke" physcist`, "physcis", "physics", `+title:physics "physics joke" physcist`},
+		{"cat (cats OR cat)", "cat", "dog", "dog (cats OR dog)"},
+		{"ёжик ёж", "ёж", "еж", "ёжик еж"},
+		{"linux", "", "x", "linux"},
+	}
+	for _, c := range cases {
+		if got := replaceWord(c.text, c.word, c.replacement); got != c.want {
+			t.Fatalf("replaceWord(%q, %q, %q): expected %q, got %q", c.text, c.word, c.replacement, c.want, got)
+		}
+	}
+}
+
+func TestService_Search_Suggestions(t *testing.T) {
+	init := fakeInitiator{similar: map[string][]SimilarTerm{
+		"linux": {{Term: "linux", Form: "linux", Distance: 0, Frequency: 10}},
+		"recursoin": {
+			{Term: "recurs", Form: "recursion", Distance: 3, Frequency: 20},
+			{Term: "recurso", Form: "recurso", Distance: 2, Frequency: 1},
+			{Term: "recursoi", Distance: 1, Frequency: 2},
+		},
+		"windowz": {{Term: "window", Form: "windows", Distance: 1, Frequency: 5}},
+	}}
+	s := newTestService(t, fakeStorager{}, fakeWords{}, init)
+
+	result, err := s.Search(context.Background(), SearchRequest{Phrase: "+linux recursoin -windowz", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(result.Comics) != 0 || result.Comics == nil {
+		t.Fatalf("expected empty comics, got %#v", result.Comics)
+	}
+	want := []string{
+		"+linux recursoi -windowz",
+		"+linux recurso -windowz",
+		"+linux recursion -windowz",
+	}
+	if !slices.Equal(result.Suggestions, want) {
+		t.Fatalf("expected %q, got %q", want, result.Suggestions)
+	}
+}
+
+func TestService_IndexSearch_SuggestionsOnlyWhenNothingFound(t *testing.T) {
+	init := fakeInitiator{
+		similar: map[string][]SimilarTerm{
+			"galxy": {{Term: "galaxy", Form: "galaxy", Distance: 1, Frequency: 3}},
+		},
+	}
+	s := newTestService(t, fakeStorager{}, fakeWords{}, init)
+
+	result, err := s.IndexSearch(context.Background(), SearchRequest{Phrase: "galxy", Limit: 10})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	if !slices.Equal(result.Suggestions, []string{"galaxy"}) {
+		t.Fatalf("unexpected suggestions: %q", result.Suggestions)
+	}
+
+	init.indexedComics = []Comics{{ID: 1}}
+	s = newTestService(t, fakeStorager{}, fakeWords{}, init)
+	result, err = s.IndexSearch(context.Background(), SearchRequest{Phrase: "galxy", Limit: 10})
+	if err != nil {
+		t.Fatalf("IndexSearch returned error: %v", err)
+	}
+	if len(result.Comics) != 1 || result.Suggestions != nil {
+		t.Fatalf("expected comics without suggestions, got %#v", result)
+	}
+}
@@ -55,22 +55,29 @@ func run(cfg config.Config, log *slog.Logger) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	scorer := core.BM25{K1: cfg.BM25K1, B: cfg.BM25B}
+
 	// indexer initiator
-	initiator := initiator.NewInitiator(log, storage, cfg.IndexTTL)
-	go initiator.Start(ctx)
+	initiator := initiator.NewInitiator(log, storage, cfg.IndexTTL, scorer, cfg.SnapshotPath, cfg.SnapshotPeriod)
+	// снимок загружается до чтения событий, чтобы воспроизвести события после него
+	warm := initiator.LoadSnapshot()
 
 	// service
-	search, err := core.NewService(log, storage, wordsClient, initiator)
+	cache := core.NewQueryCache(cfg.CacheSize, cfg.CacheTTL)
+	search, err := core.NewService(log, storage, wordsClient, initiator, scorer, cache)
 	if err != nil {
 		return fmt.Errorf("failed to create search service: %v", err)
 	}
+	go search.LogCacheStats(ctx, cfg.CacheStatsPeriod)
 
 	// nats listener
-	listener, err := nats.NewListener(cfg.BrokerAddress, cfg.Topic, log, initiator)
+	listener, err := nats.NewListener(cfg.BrokerAddress, cfg.Topic, cfg.Stream, cfg.Durable, log, initiator, search, initiator)
 	if err != nil {
 		return fmt.Errorf("failed to create nats listener: %v", err)
 	}
-	go listener.Listen(ctx)
+	// без событий после снимка изменения комиксов не восстановить, индекс строится по БД
+	go initiator.Start(ctx, warm && !listener.Expired())
+	listener.Listen(ctx)
 
 	// grpc server
 	grpcListener, err := net.Listen("tcp", cfg.Address)
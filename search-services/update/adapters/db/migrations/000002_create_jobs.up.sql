@@ -0,0 +1,11 @@
+CREATE TABLE jobs (
+    id BIGSERIAL PRIMARY KEY,
+    status TEXT NOT NULL,
+    triggered_by TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    fetched INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    stored INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE comics
+    DROP COLUMN title,
+    DROP COLUMN safe_title,
+    DROP COLUMN alt,
+    DROP COLUMN transcript,
+    DROP COLUMN link,
+    DROP COLUMN news,
+    DROP COLUMN published;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE comics
+    ADD COLUMN title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN safe_title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN alt TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transcript TEXT NOT NULL DEFAULT '',
+    ADD COLUMN link TEXT NOT NULL DEFAULT '',
+    ADD COLUMN news TEXT NOT NULL DEFAULT '',
+    ADD COLUMN published DATE;
\ No newline at end of file
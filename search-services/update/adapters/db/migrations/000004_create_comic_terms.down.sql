@@ -0,0 +1 @@
+DROP TABLE comic_terms;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE comic_terms (
+    comic_id int NOT NULL REFERENCES comics (id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    term TEXT NOT NULL,
+    tf int NOT NULL,
+    positions int[] NOT NULL,
+    PRIMARY KEY (comic_id, field, term)
+);
+
+CREATE INDEX comic_terms_term_idx ON comic_terms (term);
@@ -0,0 +1 @@
+ALTER TABLE comic_terms DROP COLUMN surface;
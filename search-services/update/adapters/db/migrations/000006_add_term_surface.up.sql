@@ -0,0 +1 @@
+ALTER TABLE comic_terms ADD COLUMN surface TEXT NOT NULL DEFAULT '';
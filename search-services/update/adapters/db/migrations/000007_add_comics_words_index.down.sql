@@ -0,0 +1 @@
+DROP INDEX comics_words_idx;
@@ -0,0 +1 @@
+CREATE INDEX comics_words_idx ON comics USING GIN (words);
@@ -0,0 +1,2 @@
+DROP INDEX comics_fts_idx;
+ALTER TABLE comics DROP COLUMN fts;
@@ -0,0 +1,7 @@
+ALTER TABLE comics ADD COLUMN fts tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', alt), 'B') ||
+    setweight(to_tsvector('english', transcript), 'C')
+) STORED;
+
+CREATE INDEX comics_fts_idx ON comics USING GIN (fts);
@@ -3,10 +3,16 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"yadro.com/course/events"
 	"yadro.com/course/update/core"
 )
 
@@ -40,14 +46,61 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-func (db *DB) Add(ctx context.Context, comics core.Comics) error {
-	_, err := db.conn.ExecContext(
+// Add stores comics together with its terms and the event in one statement,
+// so comics never appears in DB without its terms and is never left unannounced.
+// Stale comics fetched again replace the stored ones.
+func (db *DB) Add(ctx context.Context, comics core.Comics, event core.Event) error {
+	payload, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	var published sql.NullTime
+	if !comics.Published.IsZero() {
+		published = sql.NullTime{Time: comics.Published, Valid: true}
+	}
+
+	n := len(comics.Terms)
+	fields, terms, surfaces := make([]string, n), make([]string, n), make([]string, n)
+	tfs, positions := make([]int64, n), make([]string, n)
+	for i, t := range comics.Terms {
+		fields[i], terms[i], surfaces[i], tfs[i] = t.Field, t.Term, t.Surface, int64(t.TF)
+		positions[i] = joinPositions(t.Positions)
+	}
+
+	_, err = db.conn.ExecContext(
 		ctx,
-		"INSERT INTO comics (id, url, words) VALUES ($1, $2, $3)",
-		comics.ID, comics.URL, comics.Words)
+		`WITH comic AS (
+			INSERT INTO comics (id, url, words, title, safe_title, alt, transcript, link, news, published)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (id) DO UPDATE SET url = EXCLUDED.url, words = EXCLUDED.words,
+				title = EXCLUDED.title, safe_title = EXCLUDED.safe_title, alt = EXCLUDED.alt,
+				transcript = EXCLUDED.transcript, link = EXCLUDED.link, news = EXCLUDED.news,
+				published = EXCLUDED.published
+			RETURNING id
+		), terms AS (
+			INSERT INTO comic_terms (comic_id, field, term, tf, positions, surface)
+			SELECT comic.id, t.field, t.term, t.tf, string_to_array(t.positions, ',')::int[], t.surface
+			FROM comic, unnest($11::text[], $12::text[], $13::int[], $14::text[], $15::text[])
+				AS t(field, term, tf, positions, surface)
+			ON CONFLICT (comic_id, field, term) DO UPDATE SET tf = EXCLUDED.tf,
+				positions = EXCLUDED.positions, surface = EXCLUDED.surface
+		)
+		INSERT INTO outbox (payload) SELECT $16::jsonb FROM comic`,
+		comics.ID, comics.URL, comics.Words, comics.Title, comics.SafeTitle,
+		comics.Alt, comics.Transcript, comics.Link, comics.News, published,
+		fields, terms, tfs, positions, surfaces, payload)
 	return err
 }
 
+func joinPositions(positions []int) string {
+	parts := make([]string, len(positions))
+	for i, p := range positions {
+		parts[i] = strconv.Itoa(p)
+	}
+	return strings.Join(parts, ",")
+}
+
 func (db *DB) Stats(ctx context.Context) (core.DBStats, error) {
 	var stats core.DBStats
 
@@ -81,7 +134,137 @@ func (db *DB) IDs(ctx context.Context) ([]int, error) {
 	return ids, nil
 }
 
-func (db *DB) Drop(ctx context.Context) error {
-	_, err := db.conn.ExecContext(ctx, "TRUNCATE comics")
+// StaleIDs returns comics stored before their metadata and terms were kept,
+// they have no publication date or no terms. The placeholder of the missing
+// comics 404 has no URL and never gets the date, so it is not stale.
+func (db *DB) StaleIDs(ctx context.Context) ([]int, error) {
+	var ids []int
+	err := db.conn.SelectContext(ctx, &ids, `SELECT id FROM comics WHERE url <> '' AND (published IS NULL
+		OR NOT EXISTS (SELECT 1 FROM comic_terms WHERE comic_id = comics.id))`)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Drop removes all comics and stores the event in one statement.
+// Terms are removed by cascade.
+func (db *DB) Drop(ctx context.Context, event core.Event) error {
+	payload, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.ExecContext(
+		ctx,
+		`WITH dropped AS (DELETE FROM comics)
+		INSERT INTO outbox (payload) VALUES ($1::jsonb)`,
+		payload)
 	return err
 }
+
+type outboxEvent struct {
+	ID      int64  `db:"id"`
+	Payload []byte `db:"payload"`
+}
+
+// PendingEvents returns not yet published events in the order they were stored
+func (db *DB) PendingEvents(ctx context.Context, limit int) ([]core.Event, error) {
+	var rows []outboxEvent
+	err := db.conn.SelectContext(ctx, &rows, "SELECT id, payload FROM outbox ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]core.Event, 0, len(rows))
+	for _, row := range rows {
+		msg, err := events.Unmarshal(row.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("outbox event %d: %w", row.ID, err)
+		}
+		res = append(res, core.Event{
+			Sequence: row.ID,
+			Type:     core.EventType(msg.Type),
+			JobID:    msg.JobID,
+			Added:    msg.Added,
+			Changed:  msg.Changed,
+			Removed:  msg.Removed,
+		})
+	}
+	return res, nil
+}
+
+func (db *DB) DeleteEvents(ctx context.Context, sequences ...int64) error {
+	_, err := db.conn.ExecContext(ctx, "DELETE FROM outbox WHERE id = ANY($1)", sequences)
+	return err
+}
+
+// encodeEvent builds outbox payload, its sequence is assigned by outbox
+func encodeEvent(event core.Event) (string, error) {
+	data, err := events.Marshal(events.Message{
+		Type:    string(event.Type),
+		JobID:   event.JobID,
+		Added:   event.Added,
+		Changed: event.Changed,
+		Removed: event.Removed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event: %v", err)
+	}
+	return string(data), nil
+}
+
+type Job struct {
+	ID          int64        `db:"id"`
+	Status      string       `db:"status"`
+	TriggeredBy string       `db:"triggered_by"`
+	StartedAt   time.Time    `db:"started_at"`
+	FinishedAt  sql.NullTime `db:"finished_at"`
+	Fetched     int          `db:"fetched"`
+	Failed      int          `db:"failed"`
+	Stored      int          `db:"stored"`
+	Error       string       `db:"error"`
+}
+
+func (db *DB) CreateJob(ctx context.Context, job core.Job) (int64, error) {
+	var id int64
+	err := db.conn.GetContext(
+		ctx, &id,
+		"INSERT INTO jobs (status, triggered_by, started_at) VALUES ($1, $2, $3) RETURNING id",
+		job.Status, job.TriggeredBy, job.StartedAt)
+	return id, err
+}
+
+func (db *DB) FinishJob(ctx context.Context, job core.Job) error {
+	_, err := db.conn.ExecContext(
+		ctx,
+		`UPDATE jobs SET status = $2, finished_at = $3, fetched = $4, failed = $5, stored = $6, error = $7
+		WHERE id = $1`,
+		job.ID, job.Status, job.FinishedAt, job.Fetched, job.Failed, job.Stored, job.Error)
+	return err
+}
+
+func (db *DB) GetJob(ctx context.Context, id int64) (core.Job, error) {
+	var job Job
+	err := db.conn.GetContext(
+		ctx, &job,
+		`SELECT id, status, triggered_by, started_at, finished_at, fetched, failed, stored, error
+		FROM jobs WHERE id = $1`,
+		id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Job{}, core.ErrNotFound
+		}
+		return core.Job{}, err
+	}
+	return core.Job{
+		ID:          job.ID,
+		Status:      core.JobStatus(job.Status),
+		TriggeredBy: job.TriggeredBy,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt.Time,
+		Fetched:     job.Fetched,
+		Failed:      job.Failed,
+		Stored:      job.Stored,
+		Error:       job.Error,
+	}, nil
+}
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"slices"
 	"testing"
 
+	"yadro.com/course/events"
 	"yadro.com/course/update/core"
 )
 
@@ -18,9 +20,11 @@ type fakeSQLXDB struct {
 	getCallCount int
 	getResults   []interface{}
 	selectResult interface{}
+	execArgs     []interface{}
 }
 
 func (f *fakeSQLXDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	f.execArgs = args
 	return nil, f.execErr
 }
 
@@ -33,8 +37,12 @@ func (f *fakeSQLXDB) GetContext(ctx context.Context, dest interface{}, query str
 		f.getCallCount++
 		if d, ok := dest.(*int); ok {
 			*d = result.(int)
+		} else if d, ok := dest.(*int64); ok {
+			*d = result.(int64)
 		} else if d, ok := dest.(*core.DBStats); ok {
 			*d = result.(core.DBStats)
+		} else if d, ok := dest.(*Job); ok {
+			*d = result.(Job)
 		}
 	}
 	return nil
@@ -47,6 +55,8 @@ func (f *fakeSQLXDB) SelectContext(ctx context.Context, dest interface{}, query
 	if f.selectResult != nil {
 		if d, ok := dest.(*[]int); ok {
 			*d = f.selectResult.([]int)
+		} else if d, ok := dest.(*[]outboxEvent); ok {
+			*d = f.selectResult.([]outboxEvent)
 		}
 	}
 	return nil
@@ -67,12 +77,55 @@ func TestDB_Add_Success(t *testing.T) {
 		ID:    1,
 		URL:   "http://example.com",
 		Words: []string{"test"},
-	})
+	}, core.Event{Type: core.EventTypeUpdating, Added: []int{1}})
 	if err != nil {
 		t.Fatalf("Add returned error: %v", err)
 	}
 }
 
+func TestDB_Add_Terms(t *testing.T) {
+	fakeConn := &fakeSQLXDB{}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	err := db.Add(context.Background(), core.Comics{
+		ID:    1,
+		URL:   "http://example.com",
+		Words: []string{"cat", "dog"},
+		Terms: []core.Term{
+			{Field: core.FieldTitle, Term: "cat", Surface: "cats", TF: 2, Positions: []int{0, 3}},
+			{Field: core.FieldAlt, Term: "dog", Surface: "dog", TF: 1, Positions: []int{5}},
+		},
+	}, core.Event{Type: core.EventTypeUpdating, JobID: 7, Added: []int{1}})
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+
+	args := fakeConn.execArgs
+	if len(args) != 16 {
+		t.Fatalf("expected 16 args, got %d", len(args))
+	}
+	fields, tfs, positions := args[10].([]string), args[12].([]int64), args[13].([]string)
+	if fields[0] != core.FieldTitle || fields[1] != core.FieldAlt {
+		t.Fatalf("unexpected fields: %#v", fields)
+	}
+	if tfs[0] != 2 || positions[0] != "0,3" || positions[1] != "5" {
+		t.Fatalf("unexpected term stats: %#v %#v", tfs, positions)
+	}
+	if surfaces := args[14].([]string); !slices.Equal(surfaces, []string{"cats", "dog"}) {
+		t.Fatalf("unexpected surfaces: %#v", surfaces)
+	}
+	msg, err := events.Unmarshal([]byte(args[15].(string)))
+	if err != nil {
+		t.Fatalf("failed to decode outbox payload: %v", err)
+	}
+	if msg.Type != "update" || msg.JobID != 7 || !slices.Equal(msg.Added, []int{1}) {
+		t.Fatalf("unexpected outbox payload: %#v", msg)
+	}
+}
+
 func TestDB_Add_Error(t *testing.T) {
 	fakeConn := &fakeSQLXDB{execErr: errors.New("db error")}
 	db := &DB{
@@ -80,7 +133,7 @@ func TestDB_Add_Error(t *testing.T) {
 		conn: fakeConn,
 	}
 
-	err := db.Add(context.Background(), core.Comics{ID: 1})
+	err := db.Add(context.Background(), core.Comics{ID: 1}, core.Event{})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
@@ -157,6 +210,19 @@ func TestDB_IDs_NoRows(t *testing.T) {
 	}
 }
 
+func TestDB_StaleIDs(t *testing.T) {
+	db := &DB{log: slog.Default(), conn: &fakeSQLXDB{selectResult: []int{7}}}
+	ids, err := db.StaleIDs(context.Background())
+	if err != nil || !slices.Equal(ids, []int{7}) {
+		t.Fatalf("expected stale ids [7], got %v, %v", ids, err)
+	}
+
+	db = &DB{log: slog.Default(), conn: &fakeSQLXDB{selectErr: errors.New("boom")}}
+	if _, err := db.StaleIDs(context.Background()); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestDB_Drop_Success(t *testing.T) {
 	fakeConn := &fakeSQLXDB{}
 	db := &DB{
@@ -164,10 +230,56 @@ func TestDB_Drop_Success(t *testing.T) {
 		conn: fakeConn,
 	}
 
-	err := db.Drop(context.Background())
+	err := db.Drop(context.Background(), core.Event{Type: core.EventTypeDropped})
 	if err != nil {
 		t.Fatalf("Drop returned error: %v", err)
 	}
+	msg, err := events.Unmarshal([]byte(fakeConn.execArgs[0].(string)))
+	if err != nil || msg.Type != "drop" {
+		t.Fatalf("unexpected outbox payload: %#v, %v", msg, err)
+	}
+}
+
+func TestDB_PendingEvents(t *testing.T) {
+	fakeConn := &fakeSQLXDB{selectResult: []outboxEvent{
+		{ID: 3, Payload: []byte(`{"version":1,"type":"update","job_id":2,"added":[5]}`)},
+		{ID: 4, Payload: []byte(`{"version":1,"type":"drop"}`)},
+	}}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	res, err := db.PendingEvents(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("PendingEvents returned error: %v", err)
+	}
+	if len(res) != 2 || res[0].Sequence != 3 || res[0].JobID != 2 || !slices.Equal(res[0].Added, []int{5}) {
+		t.Fatalf("unexpected events: %#v", res)
+	}
+	if res[1].Sequence != 4 || res[1].Type != core.EventTypeDropped {
+		t.Fatalf("unexpected events: %#v", res)
+	}
+
+	fakeConn.selectResult = []outboxEvent{{ID: 5, Payload: []byte(`{"version":9}`)}}
+	if _, err := db.PendingEvents(context.Background(), 10); err == nil {
+		t.Fatalf("expected error for unsupported payload, got nil")
+	}
+}
+
+func TestDB_DeleteEvents(t *testing.T) {
+	fakeConn := &fakeSQLXDB{}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	if err := db.DeleteEvents(context.Background(), 3, 4); err != nil {
+		t.Fatalf("DeleteEvents returned error: %v", err)
+	}
+	if ids := fakeConn.execArgs[0].([]int64); !slices.Equal(ids, []int64{3, 4}) {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
 }
 
 func TestDB_Close(t *testing.T) {
@@ -183,6 +295,71 @@ func TestDB_Close(t *testing.T) {
 	}
 }
 
+func TestDB_CreateJob(t *testing.T) {
+	fakeConn := &fakeSQLXDB{getResults: []interface{}{int64(7)}}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	id, err := db.CreateJob(context.Background(), core.Job{Status: core.JobStatusRunning})
+	if err != nil {
+		t.Fatalf("CreateJob returned error: %v", err)
+	}
+	if id != 7 {
+		t.Fatalf("expected id=7, got %d", id)
+	}
+}
+
+func TestDB_FinishJob_Error(t *testing.T) {
+	fakeConn := &fakeSQLXDB{execErr: errors.New("db error")}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	if err := db.FinishJob(context.Background(), core.Job{ID: 1}); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestDB_GetJob_Success(t *testing.T) {
+	fakeConn := &fakeSQLXDB{getResults: []interface{}{Job{
+		ID:          3,
+		Status:      "done",
+		TriggeredBy: "admin",
+		Stored:      10,
+	}}}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	job, err := db.GetJob(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("GetJob returned error: %v", err)
+	}
+	if job.ID != 3 || job.Status != core.JobStatusDone || job.TriggeredBy != "admin" || job.Stored != 10 {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+	if !job.FinishedAt.IsZero() {
+		t.Fatalf("expected zero finish time, got %v", job.FinishedAt)
+	}
+}
+
+func TestDB_GetJob_NotFound(t *testing.T) {
+	fakeConn := &fakeSQLXDB{getErr: sql.ErrNoRows}
+	db := &DB{
+		log:  slog.Default(),
+		conn: fakeConn,
+	}
+
+	_, err := db.GetJob(context.Background(), 3)
+	if !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestDB_Migrate_RequiresRealDB(t *testing.T) {
 	fakeConn := &fakeSQLXDB{}
 	db := &DB{
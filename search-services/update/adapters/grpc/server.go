@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	updatepb "yadro.com/course/proto/update"
 	"yadro.com/course/update/core"
 )
@@ -25,22 +28,98 @@ func (s *Server) Ping(_ context.Context, _ *emptypb.Empty) (*emptypb.Empty, erro
 }
 
 func (s *Server) Status(ctx context.Context, _ *emptypb.Empty) (*updatepb.StatusReply, error) {
-	st := s.service.Status(ctx)
-	switch st {
+	reply := &updatepb.StatusReply{}
+	switch s.service.Status(ctx) {
 	case core.StatusRunning:
-		return &updatepb.StatusReply{Status: updatepb.Status_STATUS_RUNNING}, nil
+		reply.Status = updatepb.Status_STATUS_RUNNING
 	case core.StatusIdle:
-		return &updatepb.StatusReply{Status: updatepb.Status_STATUS_IDLE}, nil
+		reply.Status = updatepb.Status_STATUS_IDLE
+	default:
+		return nil, status.Error(codes.Internal, "unknown status from service")
 	}
-	return nil, status.Error(codes.Internal, "unknown status from service")
+	if next := s.service.NextUpdate(ctx); !next.IsZero() {
+		reply.NextUpdate = timestamppb.New(next)
+	}
+	return reply, nil
+}
+
+func (s *Server) Update(ctx context.Context, req *updatepb.UpdateRequest) (*updatepb.Job, error) {
+	job, err := s.service.Update(ctx, req.TriggeredBy)
+	if err != nil {
+		if errors.Is(err, core.ErrAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, "update already runs")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toJob(job), nil
+}
+
+func (s *Server) GetJob(ctx context.Context, req *updatepb.JobRequest) (*updatepb.Job, error) {
+	job, err := s.service.Job(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "job is not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toJob(job), nil
+}
+
+func (s *Server) CancelJob(ctx context.Context, req *updatepb.JobRequest) (*emptypb.Empty, error) {
+	if err := s.service.CancelJob(ctx, req.Id); err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "job is not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toJob(job core.Job) *updatepb.Job {
+	reply := &updatepb.Job{
+		Id:          job.ID,
+		TriggeredBy: job.TriggeredBy,
+		StartedAt:   timestamppb.New(job.StartedAt),
+		Fetched:     int64(job.Fetched),
+		Failed:      int64(job.Failed),
+		Stored:      int64(job.Stored),
+		Error:       job.Error,
+	}
+	if !job.FinishedAt.IsZero() {
+		reply.FinishedAt = timestamppb.New(job.FinishedAt)
+	}
+	switch job.Status {
+	case core.JobStatusRunning:
+		reply.Status = updatepb.JobStatus_JOB_STATUS_RUNNING
+	case core.JobStatusDone:
+		reply.Status = updatepb.JobStatus_JOB_STATUS_DONE
+	case core.JobStatusFailed:
+		reply.Status = updatepb.JobStatus_JOB_STATUS_FAILED
+	case core.JobStatusCanceled:
+		reply.Status = updatepb.JobStatus_JOB_STATUS_CANCELED
+	}
+	return reply
 }
 
-func (s *Server) Update(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
-	err := s.service.Update(ctx)
-	if errors.Is(err, core.ErrAlreadyExists) {
-		return nil, status.Error(codes.AlreadyExists, "update already runs")
+func (s *Server) WatchUpdate(_ *emptypb.Empty, stream grpc.ServerStreamingServer[updatepb.UpdateProgress]) error {
+	for progress := range s.service.Watch(stream.Context()) {
+		reply := &updatepb.UpdateProgress{
+			Status:     updatepb.Status_STATUS_IDLE,
+			Total:      int64(progress.Total),
+			Fetched:    int64(progress.Fetched),
+			Failed:     int64(progress.Failed),
+			Normalized: int64(progress.Normalized),
+			Stored:     int64(progress.Stored),
+			Eta:        durationpb.New(progress.ETA),
+		}
+		if progress.Status == core.StatusRunning {
+			reply.Status = updatepb.Status_STATUS_RUNNING
+		}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
 	}
-	return nil, err
+	return nil
 }
 
 func (s *Server) Stats(ctx context.Context, _ *emptypb.Empty) (*updatepb.StatsReply, error) {
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -14,14 +16,29 @@ import (
 
 type fakeUpdater struct {
 	status    core.ServiceStatus
+	next      time.Time
+	progress  []core.Progress
+	job       core.Job
+	jobErr    error
 	stats     core.ServiceStats
 	statsErr  error
 	updateErr error
 	dropErr   error
 }
 
-func (f fakeUpdater) Update(ctx context.Context) error {
-	return f.updateErr
+func (f fakeUpdater) Update(ctx context.Context, triggeredBy string) (core.Job, error) {
+	if f.updateErr != nil {
+		return core.Job{}, f.updateErr
+	}
+	return core.Job{ID: 1, Status: core.JobStatusRunning, TriggeredBy: triggeredBy, StartedAt: time.Now()}, nil
+}
+
+func (f fakeUpdater) Job(ctx context.Context, id int64) (core.Job, error) {
+	return f.job, f.jobErr
+}
+
+func (f fakeUpdater) CancelJob(ctx context.Context, id int64) error {
+	return f.jobErr
 }
 
 func (f fakeUpdater) Stats(ctx context.Context) (core.ServiceStats, error) {
@@ -32,6 +49,19 @@ func (f fakeUpdater) Status(ctx context.Context) core.ServiceStatus {
 	return f.status
 }
 
+func (f fakeUpdater) NextUpdate(ctx context.Context) time.Time {
+	return f.next
+}
+
+func (f fakeUpdater) Watch(ctx context.Context) <-chan core.Progress {
+	ch := make(chan core.Progress, len(f.progress))
+	for _, p := range f.progress {
+		ch <- p
+	}
+	close(ch)
+	return ch
+}
+
 func (f fakeUpdater) Drop(ctx context.Context) error {
 	return f.dropErr
 }
@@ -66,17 +96,80 @@ func TestServer_Status_Idle(t *testing.T) {
 	}
 }
 
+func TestServer_Status_NextUpdate(t *testing.T) {
+	next := time.Now().Add(time.Hour)
+	s := NewServer(fakeUpdater{status: core.StatusIdle, next: next})
+	reply, err := s.Status(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if !reply.NextUpdate.AsTime().Equal(next) {
+		t.Fatalf("expected next update %v, got %v", next, reply.NextUpdate.AsTime())
+	}
+
+	s = NewServer(fakeUpdater{status: core.StatusIdle})
+	reply, err = s.Status(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if reply.NextUpdate != nil {
+		t.Fatalf("expected no next update, got %v", reply.NextUpdate)
+	}
+}
+
 func TestServer_Update_Success(t *testing.T) {
 	s := NewServer(fakeUpdater{})
-	_, err := s.Update(context.Background(), &emptypb.Empty{})
+	job, err := s.Update(context.Background(), &updatepb.UpdateRequest{TriggeredBy: "admin"})
 	if err != nil {
 		t.Fatalf("Update returned error: %v", err)
 	}
+	if job.Id != 1 || job.Status != updatepb.JobStatus_JOB_STATUS_RUNNING || job.TriggeredBy != "admin" {
+		t.Fatalf("unexpected job: %v", job)
+	}
+	if job.FinishedAt != nil {
+		t.Fatalf("expected no finish time for running job")
+	}
+}
+
+func TestServer_GetJob(t *testing.T) {
+	finished := time.Now()
+	s := NewServer(fakeUpdater{job: core.Job{
+		ID: 2, Status: core.JobStatusCanceled, FinishedAt: finished, Stored: 5, Error: "context canceled",
+	}})
+	job, err := s.GetJob(context.Background(), &updatepb.JobRequest{Id: 2})
+	if err != nil {
+		t.Fatalf("GetJob returned error: %v", err)
+	}
+	if job.Status != updatepb.JobStatus_JOB_STATUS_CANCELED || job.Stored != 5 || job.Error == "" {
+		t.Fatalf("unexpected job: %v", job)
+	}
+	if !job.FinishedAt.AsTime().Equal(finished) {
+		t.Fatalf("expected finish time %v, got %v", finished, job.FinishedAt.AsTime())
+	}
+}
+
+func TestServer_Jobs_NotFound(t *testing.T) {
+	s := NewServer(fakeUpdater{jobErr: core.ErrNotFound})
+	_, err := s.GetJob(context.Background(), &updatepb.JobRequest{Id: 2})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	_, err = s.CancelJob(context.Background(), &updatepb.JobRequest{Id: 2})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestServer_CancelJob_Success(t *testing.T) {
+	s := NewServer(fakeUpdater{})
+	if _, err := s.CancelJob(context.Background(), &updatepb.JobRequest{Id: 2}); err != nil {
+		t.Fatalf("CancelJob returned error: %v", err)
+	}
 }
 
 func TestServer_Update_AlreadyExists(t *testing.T) {
 	s := NewServer(fakeUpdater{updateErr: core.ErrAlreadyExists})
-	_, err := s.Update(context.Background(), &emptypb.Empty{})
+	_, err := s.Update(context.Background(), &updatepb.UpdateRequest{})
 	if status.Code(err) != codes.AlreadyExists {
 		t.Fatalf("expected AlreadyExists, got %v", err)
 	}
@@ -125,3 +218,50 @@ func TestServer_Drop_Error(t *testing.T) {
 		t.Fatalf("expected Internal, got %v", err)
 	}
 }
+
+type fakeProgressStream struct {
+	grpc.ServerStreamingServer[updatepb.UpdateProgress]
+	sent    []*updatepb.UpdateProgress
+	sendErr error
+}
+
+func (f *fakeProgressStream) Context() context.Context {
+	return context.Background()
+}
+
+func (f *fakeProgressStream) Send(p *updatepb.UpdateProgress) error {
+	f.sent = append(f.sent, p)
+	return f.sendErr
+}
+
+func TestServer_WatchUpdate(t *testing.T) {
+	s := NewServer(fakeUpdater{progress: []core.Progress{
+		{Status: core.StatusRunning, Total: 10, Fetched: 3, Stored: 2, ETA: time.Minute},
+		{Status: core.StatusIdle, Total: 10, Fetched: 10, Stored: 10},
+	}})
+	stream := &fakeProgressStream{}
+	if err := s.WatchUpdate(&emptypb.Empty{}, stream); err != nil {
+		t.Fatalf("WatchUpdate returned error: %v", err)
+	}
+	if len(stream.sent) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(stream.sent))
+	}
+	first := stream.sent[0]
+	if first.Status != updatepb.Status_STATUS_RUNNING || first.Total != 10 || first.Stored != 2 {
+		t.Fatalf("unexpected progress: %v", first)
+	}
+	if first.Eta.AsDuration() != time.Minute {
+		t.Fatalf("expected eta 1m, got %v", first.Eta.AsDuration())
+	}
+	if stream.sent[1].Status != updatepb.Status_STATUS_IDLE {
+		t.Fatalf("expected idle status, got %v", stream.sent[1].Status)
+	}
+}
+
+func TestServer_WatchUpdate_SendError(t *testing.T) {
+	s := NewServer(fakeUpdater{progress: []core.Progress{{Status: core.StatusIdle}}})
+	stream := &fakeProgressStream{sendErr: errors.New("gone")}
+	if err := s.WatchUpdate(&emptypb.Empty{}, stream); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
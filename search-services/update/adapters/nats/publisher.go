@@ -4,39 +4,77 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"yadro.com/course/events"
 	"yadro.com/course/update/core"
 )
 
 const topic = "xkcd.db.updated"
 
+// setupTimeout ограничивает создание потока при старте
+const setupTimeout = 10 * time.Second
+
 type natsConn interface {
-	Publish(subj string, data []byte) error
 	Drain() error
 }
 
+type jetStream interface {
+	Publish(ctx context.Context, subject string, payload []byte, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
 type Notificator struct {
 	nc  natsConn
+	js  jetStream
 	log *slog.Logger
 }
 
-func NewNotificator(address string, log *slog.Logger) (*Notificator, error) {
+func NewNotificator(address, stream string, log *slog.Logger) (*Notificator, error) {
 	nc, err := nats.Connect(address)
 	if err != nil {
 		return nil, err
 	}
-	return &Notificator{nc: nc, log: log}, nil
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	if _, err := js.CreateOrUpdateStream(ctx, events.StreamConfig(stream, topic)); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to create stream %q: %v", stream, err)
+	}
+	return &Notificator{nc: nc, js: js, log: log}, nil
 }
 
-func (n *Notificator) Publish(ctx context.Context, event core.EventType) error {
-	err := n.nc.Publish(topic, []byte(event))
+// Publish сохраняет событие в потоке JetStream. Номер события в outbox используется
+// как идентификатор сообщения, поэтому повторная публикация отбрасывается потоком.
+func (n *Notificator) Publish(ctx context.Context, event core.Event) error {
+	data, err := events.Marshal(events.Message{
+		Sequence: event.Sequence,
+		Type:     string(event.Type),
+		JobID:    event.JobID,
+		Added:    event.Added,
+		Changed:  event.Changed,
+		Removed:  event.Removed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+
+	ack, err := n.js.Publish(ctx, topic, data, jetstream.WithMsgID(strconv.FormatInt(event.Sequence, 10)))
 	if err != nil {
 		n.log.Error("failed to publish message", "topic", topic, "error", err)
 		return fmt.Errorf("failed to publish message: %v", err)
 	}
 
-	n.log.Info("message published", "topic", topic)
+	n.log.Info("message published", "topic", topic, "type", event.Type, "sequence", event.Sequence,
+		"job", event.JobID, "stream_sequence", ack.Sequence, "duplicate", ack.Duplicate)
 	return nil
 }
 
@@ -4,71 +4,86 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go/jetstream"
+	"yadro.com/course/events"
 	"yadro.com/course/update/core"
 )
 
 type fakeNATSConn struct {
+	drainErr error
+}
+
+func (f *fakeNATSConn) Drain() error {
+	return f.drainErr
+}
+
+type fakeJetStream struct {
 	publishErr error
-	drainErr   error
 	published  []struct {
 		subject string
 		data    []byte
 	}
 }
 
-func (f *fakeNATSConn) Publish(subj string, data []byte) error {
+func (f *fakeJetStream) Publish(ctx context.Context, subj string, data []byte, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if f.publishErr != nil {
+		return nil, f.publishErr
+	}
 	f.published = append(f.published, struct {
 		subject string
 		data    []byte
 	}{subj, data})
-	return f.publishErr
-}
-
-func (f *fakeNATSConn) Drain() error {
-	return f.drainErr
+	return &jetstream.PubAck{Sequence: uint64(len(f.published))}, nil
 }
 
 func TestNotificator_Publish_Success(t *testing.T) {
-	fakeConn := &fakeNATSConn{}
+	fakeJS := &fakeJetStream{}
 	n := &Notificator{
-		nc:  fakeConn,
+		nc:  &fakeNATSConn{},
+		js:  fakeJS,
 		log: slog.Default(),
 	}
 
-	err := n.Publish(context.Background(), core.EventTypeUpdating)
+	err := n.Publish(context.Background(), core.Event{Sequence: 9, Type: core.EventTypeUpdating, JobID: 4, Added: []int{1, 2}})
 	if err != nil {
 		t.Fatalf("Publish returned error: %v", err)
 	}
-	if len(fakeConn.published) != 1 {
-		t.Fatalf("expected 1 publish, got %d", len(fakeConn.published))
+	if len(fakeJS.published) != 1 {
+		t.Fatalf("expected 1 publish, got %d", len(fakeJS.published))
+	}
+	if fakeJS.published[0].subject != topic {
+		t.Fatalf("expected subject %q, got %q", topic, fakeJS.published[0].subject)
 	}
-	if fakeConn.published[0].subject != topic {
-		t.Fatalf("expected subject %q, got %q", topic, fakeConn.published[0].subject)
+	msg, err := events.Unmarshal(fakeJS.published[0].data)
+	if err != nil {
+		t.Fatalf("failed to decode published data %q: %v", fakeJS.published[0].data, err)
 	}
-	if string(fakeConn.published[0].data) != string(core.EventTypeUpdating) {
-		t.Fatalf("expected data %q, got %q", core.EventTypeUpdating, string(fakeConn.published[0].data))
+	if msg.Type != string(core.EventTypeUpdating) || msg.JobID != 4 || msg.Sequence != 9 || !slices.Equal(msg.Added, []int{1, 2}) {
+		t.Fatalf("unexpected message: %#v", msg)
 	}
 }
 
 func TestNotificator_Publish_Error(t *testing.T) {
-	fakeConn := &fakeNATSConn{publishErr: errors.New("nats error")}
 	n := &Notificator{
-		nc:  fakeConn,
+		nc:  &fakeNATSConn{},
+		js:  &fakeJetStream{publishErr: errors.New("nats error")},
 		log: slog.Default(),
 	}
 
-	err := n.Publish(context.Background(), core.EventTypeDropped)
+	err := n.Publish(context.Background(), core.Event{Type: core.EventTypeDropped})
 	if err == nil {
 		t.Fatalf("expected error, got nil")
 	}
 }
 
 func TestNotificator_Close(t *testing.T) {
-	fakeConn := &fakeNATSConn{}
 	n := &Notificator{
-		nc:  fakeConn,
+		nc:  &fakeNATSConn{},
 		log: slog.Default(),
 	}
 
@@ -77,3 +92,55 @@ func TestNotificator_Close(t *testing.T) {
 		t.Fatalf("Close returned error: %v", err)
 	}
 }
+
+func runJetStream(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create nats server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server is not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func TestNotificator_JetStream(t *testing.T) {
+	s := runJetStream(t)
+
+	n, err := NewNotificator(s.ClientURL(), "TEST_EVENTS", slog.Default())
+	if err != nil {
+		t.Fatalf("NewNotificator returned error: %v", err)
+	}
+	defer n.Close()
+
+	ctx := context.Background()
+	event := core.Event{Sequence: 1, Type: core.EventTypeUpdating, Added: []int{1}}
+	// the second publish of the same outbox event is a duplicate
+	for range 2 {
+		if err := n.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish returned error: %v", err)
+		}
+	}
+
+	stream, err := n.js.(jetstream.JetStream).Stream(ctx, "TEST_EVENTS")
+	if err != nil {
+		t.Fatalf("stream is not created: %v", err)
+	}
+	msg, err := stream.GetLastMsgForSubject(ctx, topic)
+	if err != nil {
+		t.Fatalf("failed to get message: %v", err)
+	}
+	if msg.Sequence != 1 {
+		t.Fatalf("expected duplicate to be dropped, last sequence is %d", msg.Sequence)
+	}
+}
@@ -40,11 +40,11 @@ func (c Client) Ping(ctx context.Context) error {
 	return err
 }
 
-func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
+func (c Client) NormDetailed(ctx context.Context, phrase string) ([]core.Token, error) {
 	request := &wordspb.WordsRequest{
 		Phrase: phrase,
 	}
-	reply, err := c.client.Norm(ctx, request)
+	reply, err := c.client.NormDetailed(ctx, request)
 	if err != nil {
 		if status.Code(err) == codes.ResourceExhausted {
 			return nil, core.ErrBadArguments
@@ -52,5 +52,14 @@ func (c Client) Norm(ctx context.Context, phrase string) ([]string, error) {
 		return nil, err
 	}
 
-	return reply.GetWords(), nil
+	tokens := make([]core.Token, 0, len(reply.GetTokens()))
+	for _, t := range reply.GetTokens() {
+		tokens = append(tokens, core.Token{
+			Surface:  t.Surface,
+			Stem:     t.Stem,
+			Position: int(t.Position),
+			StopWord: t.StopWord,
+		})
+	}
+	return tokens, nil
 }
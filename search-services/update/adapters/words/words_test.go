@@ -15,9 +15,10 @@ import (
 )
 
 type fakeWordsClient struct {
-	pingErr error
-	normRep *wordspb.WordsReply
-	normErr error
+	pingErr   error
+	normRep   *wordspb.WordsReply
+	tokensRep *wordspb.TokensReply
+	normErr   error
 }
 
 func (f fakeWordsClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
@@ -28,6 +29,10 @@ func (f fakeWordsClient) Norm(ctx context.Context, in *wordspb.WordsRequest, opt
 	return f.normRep, f.normErr
 }
 
+func (f fakeWordsClient) NormDetailed(ctx context.Context, in *wordspb.WordsRequest, opts ...grpc.CallOption) (*wordspb.TokensReply, error) {
+	return f.tokensRep, f.normErr
+}
+
 func newWordsTestClient(f fakeWordsClient) *Client {
 	logger := slog.Default()
 	return &Client{
@@ -38,26 +43,29 @@ func newWordsTestClient(f fakeWordsClient) *Client {
 
 var _ wordspb.WordsClient = fakeWordsClient{}
 
-func TestClient_Norm_Success(t *testing.T) {
+func TestClient_NormDetailed_Success(t *testing.T) {
 	c := newWordsTestClient(fakeWordsClient{
-		normRep: &wordspb.WordsReply{Words: []string{"a", "b"}},
+		tokensRep: &wordspb.TokensReply{Tokens: []*wordspb.Token{
+			{Surface: "The", Stem: "the", Position: 0, StopWord: true},
+			{Surface: "apples", Stem: "appl", Position: 1},
+		}},
 	})
 
-	res, err := c.Norm(context.Background(), "phrase")
+	res, err := c.NormDetailed(context.Background(), "phrase")
 	if err != nil {
-		t.Fatalf("Norm returned error: %v", err)
+		t.Fatalf("NormDetailed returned error: %v", err)
 	}
-	if len(res) != 2 || res[0] != "a" {
+	if len(res) != 2 || !res[0].StopWord || res[1].Stem != "appl" || res[1].Position != 1 {
 		t.Fatalf("unexpected result: %#v", res)
 	}
 }
 
-func TestClient_Norm_BadArguments(t *testing.T) {
+func TestClient_NormDetailed_BadArguments(t *testing.T) {
 	c := newWordsTestClient(fakeWordsClient{
 		normErr: status.Error(codes.ResourceExhausted, "too long"),
 	})
 
-	_, err := c.Norm(context.Background(), "phrase")
+	_, err := c.NormDetailed(context.Background(), "phrase")
 	if !errors.Is(err, core.ErrBadArguments) {
 		t.Fatalf("expected ErrBadArguments, got %v", err)
 	}
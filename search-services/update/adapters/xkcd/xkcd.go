@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"yadro.com/course/update/core"
@@ -67,17 +67,50 @@ func (c Client) get(ctx context.Context, url string) (core.XKCDInfo, error) {
 		SafeTitle  string `json:"safe_title"`
 		Transcript string `json:"transcript"`
 		Alt        string `json:"alt"`
+		Link       string `json:"link"`
+		News       string `json:"news"`
+		Year       string `json:"year"`
+		Month      string `json:"month"`
+		Day        string `json:"day"`
 	}{}
 	if err = json.NewDecoder(resp.Body).Decode(&info); err != nil {
 		return core.XKCDInfo{}, fmt.Errorf("failed to decode comics: %v", err)
 	}
 
+	published, err := publicationDate(info.Year, info.Month, info.Day)
+	if err != nil {
+		c.log.Error("failed to parse publication date", "id", info.ID, "error", err)
+	}
+
 	return core.XKCDInfo{
-		ID:  info.ID,
-		URL: info.URL,
-		Description: strings.Join([]string{
-			info.Title, info.SafeTitle, info.Transcript, info.Alt},
-			" ",
-		),
+		ID:         info.ID,
+		URL:        info.URL,
+		Title:      info.Title,
+		SafeTitle:  info.SafeTitle,
+		Alt:        info.Alt,
+		Transcript: info.Transcript,
+		Link:       info.Link,
+		News:       info.News,
+		Published:  published,
 	}, nil
 }
+
+// publicationDate builds date from xkcd year/month/day strings.
+// Zero time is returned when date is absent.
+func publicationDate(year, month, day string) (time.Time, error) {
+	if year == "" && month == "" && day == "" {
+		return time.Time{}, nil
+	}
+	var y, m, d int
+	var err error
+	if y, err = strconv.Atoi(year); err != nil {
+		return time.Time{}, fmt.Errorf("bad year %q: %v", year, err)
+	}
+	if m, err = strconv.Atoi(month); err != nil {
+		return time.Time{}, fmt.Errorf("bad month %q: %v", month, err)
+	}
+	if d, err = strconv.Atoi(day); err != nil {
+		return time.Time{}, fmt.Errorf("bad day %q: %v", day, err)
+	}
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC), nil
+}
@@ -32,6 +32,11 @@ func TestClient_Get_Success(t *testing.T) {
 			"safe_title": "st",
 			"transcript": "tr",
 			"alt":        "alt",
+			"link":       "http://example.com",
+			"news":       "n",
+			"year":       "2006",
+			"month":      "1",
+			"day":        "2",
 		}
 		_ = json.NewEncoder(w).Encode(resp)
 	}))
@@ -46,8 +51,23 @@ func TestClient_Get_Success(t *testing.T) {
 	if info.ID != 42 || info.URL == "" {
 		t.Fatalf("unexpected info: %#v", info)
 	}
-	if info.Description == "" {
-		t.Fatalf("expected non-empty description")
+	if info.Title != "t" || info.SafeTitle != "st" || info.Transcript != "tr" || info.Alt != "alt" {
+		t.Fatalf("unexpected text fields: %#v", info)
+	}
+	if info.Link != "http://example.com" || info.News != "n" {
+		t.Fatalf("unexpected link/news: %#v", info)
+	}
+	if want := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC); !info.Published.Equal(want) {
+		t.Fatalf("expected published %v, got %v", want, info.Published)
+	}
+}
+
+func TestPublicationDate(t *testing.T) {
+	if d, err := publicationDate("", "", ""); err != nil || !d.IsZero() {
+		t.Fatalf("expected zero date without error, got %v, %v", d, err)
+	}
+	if _, err := publicationDate("2006", "x", "1"); err == nil {
+		t.Fatalf("expected error for bad month")
 	}
 }
 
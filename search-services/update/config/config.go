@@ -15,14 +15,16 @@ type XKCD struct {
 }
 
 type Config struct {
-	LogLevel      string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
-	Address       string `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"localhost:80"`
-	XKCD          XKCD   `yaml:"xkcd"`
-	DBAddress     string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
-	WordsAddress  string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
+	LogLevel     string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
+	Address      string `yaml:"update_address" env:"UPDATE_ADDRESS" env-default:"localhost:80"`
+	XKCD         XKCD   `yaml:"xkcd"`
+	DBAddress    string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:82"`
+	WordsAddress string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"localhost:81"`
 
-	BrokerAddress string `yaml:"broker_address" env:"BROKER_ADDRESS" env-default:"nats://nats:4222"`
-	Topic         string `yaml:"topic" env:"TOPIC" env-default:"xkcd.db.updated"`
+	BrokerAddress string        `yaml:"broker_address" env:"BROKER_ADDRESS" env-default:"nats://nats:4222"`
+	Topic         string        `yaml:"topic" env:"TOPIC" env-default:"xkcd.db.updated"`
+	Stream        string        `yaml:"stream" env:"STREAM" env-default:"XKCD_EVENTS"`
+	OutboxPeriod  time.Duration `yaml:"outbox_period" env:"OUTBOX_PERIOD" env-default:"1s"`
 }
 
 func MustLoad(configPath string) Config {
@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 type ServiceStatus string
 
 const (
@@ -14,6 +16,17 @@ const (
 	EventTypeDropped  EventType = "drop"
 )
 
+// Event describes changes of comics storage made by a job.
+// Sequence is the position of the event in the outbox.
+type Event struct {
+	Sequence int64
+	Type     EventType
+	JobID    int64
+	Added    []int
+	Changed  []int
+	Removed  []int
+}
+
 type DBStats struct {
 	WordsTotal    int
 	WordsUnique   int
@@ -25,14 +38,84 @@ type ServiceStats struct {
 	ComicsTotal int
 }
 
+// Fields of comics that are normalized and indexed separately
+const (
+	FieldTitle      = "title"
+	FieldSafeTitle  = "safe_title"
+	FieldTranscript = "transcript"
+	FieldAlt        = "alt"
+)
+
+type Token struct {
+	Surface  string
+	Stem     string
+	Position int
+	StopWord bool
+}
+
+// Term is a stem occurrence statistics within one field of comics.
+// Surface is the most frequent lower-cased form of the stem in the field.
+type Term struct {
+	Field     string
+	Term      string
+	Surface   string
+	TF        int
+	Positions []int
+}
+
 type Comics struct {
-	ID    int
-	URL   string
-	Words []string
+	ID         int
+	URL        string
+	Words      []string
+	Terms      []Term
+	Title      string
+	SafeTitle  string
+	Alt        string
+	Transcript string
+	Link       string
+	News       string
+	Published  time.Time
 }
 
 type XKCDInfo struct {
-	ID          int
-	URL         string
-	Description string
+	ID         int
+	URL        string
+	Title      string
+	SafeTitle  string
+	Alt        string
+	Transcript string
+	Link       string
+	News       string
+	Published  time.Time
+}
+
+type Progress struct {
+	Status     ServiceStatus
+	Total      int
+	Fetched    int
+	Failed     int
+	Normalized int
+	Stored     int
+	ETA        time.Duration
+}
+
+type JobStatus string
+
+const (
+	JobStatusRunning  JobStatus = "running"
+	JobStatusDone     JobStatus = "done"
+	JobStatusFailed   JobStatus = "failed"
+	JobStatusCanceled JobStatus = "canceled"
+)
+
+type Job struct {
+	ID          int64
+	Status      JobStatus
+	TriggeredBy string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Fetched     int
+	Failed      int
+	Stored      int
+	Error       string
 }
@@ -2,24 +2,43 @@ package core
 
 import (
 	"context"
+	"time"
 )
 
 type Notificator interface {
-	Publish(context.Context, EventType) error
+	Publish(context.Context, Event) error
 }
 
 type Updater interface {
-	Update(context.Context) error
+	Update(ctx context.Context, triggeredBy string) (Job, error)
+	Job(ctx context.Context, id int64) (Job, error)
+	CancelJob(ctx context.Context, id int64) error
 	Stats(context.Context) (ServiceStats, error)
 	Status(context.Context) ServiceStatus
+	NextUpdate(context.Context) time.Time
+	Watch(context.Context) <-chan Progress
 	Drop(context.Context) error
 }
 
+// DB stores every change together with the event describing it,
+// so the event is never lost or published for a change that did not happen.
 type DB interface {
-	Add(context.Context, Comics) error
+	Add(context.Context, Comics, Event) error
 	Stats(context.Context) (DBStats, error)
-	Drop(context.Context) error
+	Drop(context.Context, Event) error
 	IDs(context.Context) ([]int, error)
+	// StaleIDs returns stored comics that lack data kept by newer versions,
+	// they are fetched again and replaced by Add
+	StaleIDs(context.Context) ([]int, error)
+	CreateJob(context.Context, Job) (int64, error)
+	FinishJob(context.Context, Job) error
+	GetJob(ctx context.Context, id int64) (Job, error)
+}
+
+// Outbox holds stored events until they are delivered to the broker
+type Outbox interface {
+	PendingEvents(ctx context.Context, limit int) ([]Event, error)
+	DeleteEvents(ctx context.Context, sequences ...int64) error
 }
 
 type XKCD interface {
@@ -28,5 +47,5 @@ type XKCD interface {
 }
 
 type Words interface {
-	Norm(ctx context.Context, phrase string) ([]string, error)
+	NormDetailed(ctx context.Context, phrase string) ([]Token, error)
 }
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// progressTracker keeps counters of the current update and
+// fans them out to watchers. Watchers always get the latest snapshot,
+// intermediate ones are dropped for slow readers.
+type progressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+	started  time.Time
+	watchers map[chan Progress]struct{}
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{
+		progress: Progress{Status: StatusIdle},
+		watchers: make(map[chan Progress]struct{}),
+	}
+}
+
+func (t *progressTracker) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started = time.Now()
+	t.progress = Progress{Status: StatusRunning}
+	t.notify()
+}
+
+func (t *progressTracker) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.Status = StatusIdle
+	t.progress.ETA = 0
+	t.notify()
+}
+
+func (t *progressTracker) update(change func(*Progress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change(&t.progress)
+
+	done := t.progress.Stored + t.progress.Failed
+	if done > 0 && done <= t.progress.Total {
+		perComics := time.Since(t.started) / time.Duration(done)
+		t.progress.ETA = perComics * time.Duration(t.progress.Total-done)
+	}
+	t.notify()
+}
+
+func (t *progressTracker) snapshot() Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.progress
+}
+
+// notify must be called with mu held.
+func (t *progressTracker) notify() {
+	for ch := range t.watchers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- t.progress
+	}
+}
+
+func (t *progressTracker) watch(ctx context.Context) <-chan Progress {
+	ch := make(chan Progress, 1)
+
+	t.mu.Lock()
+	ch <- t.progress
+	t.watchers[ch] = struct{}{}
+	t.mu.Unlock()
+
+	out := make(chan Progress)
+	go func() {
+		defer close(out)
+		defer func() {
+			t.mu.Lock()
+			delete(t.watchers, ch)
+			t.mu.Unlock()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case p := <-ch:
+				select {
+				case out <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// relayBatch limits the number of outbox events read at once
+const relayBatch = 100
+
+// Relay delivers events from the outbox to the broker.
+// An event is removed from the outbox only after it is published,
+// so events survive restarts of the service and outages of the broker.
+type Relay struct {
+	log         *slog.Logger
+	outbox      Outbox
+	notificator Notificator
+	period      time.Duration
+}
+
+func NewRelay(log *slog.Logger, outbox Outbox, notificator Notificator, period time.Duration) *Relay {
+	return &Relay{
+		log:         log,
+		outbox:      outbox,
+		notificator: notificator,
+		period:      period,
+	}
+}
+
+// Run publishes pending events every period until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.period)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Flush(ctx); err != nil {
+			r.log.Error("failed to relay events", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes all pending events and returns the number of published messages.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	var published int
+	for {
+		pending, err := r.outbox.PendingEvents(ctx, relayBatch)
+		if err != nil {
+			return published, err
+		}
+		if len(pending) == 0 {
+			return published, nil
+		}
+
+		for _, batch := range mergeEvents(pending) {
+			if err := r.notificator.Publish(ctx, batch.event); err != nil {
+				return published, err
+			}
+			// a failure here leads to the event being published again,
+			// subscribers apply events idempotently
+			if err := r.outbox.DeleteEvents(ctx, batch.sequences...); err != nil {
+				return published, err
+			}
+			published++
+		}
+	}
+}
+
+type eventBatch struct {
+	event     Event
+	sequences []int64
+}
+
+// mergeEvents joins consecutive update events of the same job into one message,
+// so a job that stored many comics does not flood subscribers.
+func mergeEvents(events []Event) []eventBatch {
+	var batches []eventBatch
+	for _, e := range events {
+		if n := len(batches); n > 0 {
+			last := &batches[n-1]
+			if e.Type == EventTypeUpdating && last.event.Type == EventTypeUpdating && e.JobID == last.event.JobID {
+				last.event.Sequence = e.Sequence
+				last.event.Added = append(last.event.Added, e.Added...)
+				last.event.Changed = append(last.event.Changed, e.Changed...)
+				last.event.Removed = append(last.event.Removed, e.Removed...)
+				last.sequences = append(last.sequences, e.Sequence)
+				continue
+			}
+		}
+		e.Added, e.Changed, e.Removed = slices.Clone(e.Added), slices.Clone(e.Changed), slices.Clone(e.Removed)
+		batches = append(batches, eventBatch{event: e, sequences: []int64{e.Sequence}})
+	}
+	return batches
+}
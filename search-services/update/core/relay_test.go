@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeNotificator struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+func (f *fakeNotificator) Publish(ctx context.Context, e Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, e)
+	return nil
+}
+
+func (f *fakeNotificator) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.events)
+}
+
+type fakeOutbox struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (f *fakeOutbox) PendingEvents(ctx context.Context, limit int) ([]Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.events[:min(limit, len(f.events))]), nil
+}
+
+func (f *fakeOutbox) DeleteEvents(ctx context.Context, sequences ...int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = slices.DeleteFunc(f.events, func(e Event) bool {
+		return slices.Contains(sequences, e.Sequence)
+	})
+	return nil
+}
+
+func (f *fakeOutbox) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.events)
+}
+
+func newTestRelay(outbox Outbox, n Notificator) *Relay {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRelay(logger, outbox, n, 10*time.Millisecond)
+}
+
+func TestRelay_Flush_MergesJobEvents(t *testing.T) {
+	outbox := &fakeOutbox{events: []Event{
+		{Sequence: 1, Type: EventTypeUpdating, JobID: 1, Added: []int{10}},
+		{Sequence: 2, Type: EventTypeUpdating, JobID: 1, Added: []int{11}},
+		{Sequence: 4, Type: EventTypeUpdating, JobID: 2, Added: []int{12}},
+		{Sequence: 5, Type: EventTypeDropped},
+		{Sequence: 6, Type: EventTypeUpdating, JobID: 2, Added: []int{13}},
+	}}
+	n := &fakeNotificator{}
+
+	published, err := newTestRelay(outbox, n).Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if published != 4 || outbox.pending() != 0 {
+		t.Fatalf("expected 4 messages and empty outbox, got %d and %d pending", published, outbox.pending())
+	}
+	first := n.events[0]
+	if first.Sequence != 2 || first.JobID != 1 || !slices.Equal(first.Added, []int{10, 11}) {
+		t.Fatalf("unexpected merged event: %#v", first)
+	}
+	// drop event separates updates of the same job
+	if n.events[2].Type != EventTypeDropped || !slices.Equal(n.events[3].Added, []int{13}) {
+		t.Fatalf("unexpected events order: %#v", n.events)
+	}
+}
+
+func TestRelay_Flush_KeepsEventsOnPublishError(t *testing.T) {
+	outbox := &fakeOutbox{events: []Event{{Sequence: 1, Type: EventTypeDropped}}}
+	n := &fakeNotificator{err: errors.New("broker down")}
+
+	if _, err := newTestRelay(outbox, n).Flush(context.Background()); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if outbox.pending() != 1 {
+		t.Fatalf("expected event to stay in outbox")
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	outbox := &fakeOutbox{events: []Event{{Sequence: 1, Type: EventTypeDropped}}}
+	n := &fakeNotificator{}
+	r := newTestRelay(outbox, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for n.count() < 1 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected event to be relayed")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("relay did not stop")
+	}
+}
@@ -2,11 +2,15 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode"
 )
 
 type Service struct {
@@ -17,13 +21,27 @@ type Service struct {
 	concurrency int
 	inProgress  atomic.Bool
 	lock        sync.Mutex
-	notificator Notificator
 	topic       string
+	nextUpdate  atomic.Int64
+	progress    *progressTracker
+	jobMu       sync.Mutex
+	running     *runningJob
 }
 
-func NewService(
-	log *slog.Logger, db DB, xkcd XKCD, words Words, concurrency int, topic string, notificator Notificator,
-) (*Service, error) {
+type runningJob struct {
+	id     int64
+	cancel context.CancelFunc
+	done   <-chan struct{}
+}
+
+// jitterDivisor bounds random delay added to each scheduled update
+// by a fraction of the check period.
+const jitterDivisor = 10
+
+// scheduler is recorded as the trigger of scheduled jobs.
+const scheduler = "scheduler"
+
+func NewService(log *slog.Logger, db DB, xkcd XKCD, words Words, concurrency int, topic string) (*Service, error) {
 	if concurrency < 1 {
 		return nil, fmt.Errorf("wrong concurrency specified: %d", concurrency)
 	}
@@ -33,20 +51,132 @@ func NewService(
 		xkcd:        xkcd,
 		words:       words,
 		concurrency: concurrency,
-		notificator: notificator,
 		topic:       topic,
+		progress:    newProgressTracker(),
 	}, nil
 }
 
-func (s *Service) Update(ctx context.Context) (err error) {
+// Update starts an update job in background and returns it right away.
+// The job outlives ctx and can be stopped with CancelJob.
+func (s *Service) Update(ctx context.Context, triggeredBy string) (Job, error) {
+	job, _, err := s.start(context.WithoutCancel(ctx), triggeredBy)
+	return job, err
+}
+
+func (s *Service) start(ctx context.Context, triggeredBy string) (Job, <-chan struct{}, error) {
 	if ok := s.lock.TryLock(); !ok {
 		s.log.Error("service already runs update")
-		return ErrAlreadyExists
+		return Job{}, nil, ErrAlreadyExists
 	}
-	defer s.lock.Unlock()
 
+	job := Job{
+		Status:      JobStatusRunning,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+	id, err := s.db.CreateJob(ctx, job)
+	if err != nil {
+		s.lock.Unlock()
+		s.log.Error("failed to create job", "error", err)
+		return Job{}, nil, fmt.Errorf("failed to create job: %v", err)
+	}
+	job.ID = id
 	s.inProgress.Store(true)
-	defer s.inProgress.Store(false)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.jobMu.Lock()
+	s.running = &runningJob{id: id, cancel: cancel, done: done}
+	s.jobMu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer s.lock.Unlock()
+		defer cancel()
+
+		err := s.update(ctx, job.ID)
+		s.finishJob(ctx, job, err)
+
+		s.jobMu.Lock()
+		s.running = nil
+		s.jobMu.Unlock()
+		s.inProgress.Store(false)
+	}()
+
+	return job, done, nil
+}
+
+func (s *Service) finishJob(ctx context.Context, job Job, err error) {
+	progress := s.progress.snapshot()
+	job.FinishedAt = time.Now()
+	job.Fetched = progress.Fetched
+	job.Failed = progress.Failed
+	job.Stored = progress.Stored
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		job.Status = JobStatusCanceled
+		job.Error = context.Canceled.Error()
+	case err != nil:
+		job.Status = JobStatusFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobStatusDone
+	}
+	// job must be recorded even if it was canceled
+	if err := s.db.FinishJob(context.WithoutCancel(ctx), job); err != nil {
+		s.log.Error("failed to finish job", "id", job.ID, "error", err)
+	}
+}
+
+func (s *Service) Job(ctx context.Context, id int64) (Job, error) {
+	job, err := s.db.GetJob(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+
+	s.jobMu.Lock()
+	running := s.running != nil && s.running.id == id
+	s.jobMu.Unlock()
+	if running {
+		progress := s.progress.snapshot()
+		job.Fetched = progress.Fetched
+		job.Failed = progress.Failed
+		job.Stored = progress.Stored
+	}
+	return job, nil
+}
+
+// CancelJob stops the job if it still runs.
+// Canceling a finished job is a no-op.
+func (s *Service) CancelJob(ctx context.Context, id int64) error {
+	s.jobMu.Lock()
+	if s.running != nil && s.running.id == id {
+		s.log.Info("canceling job", "id", id)
+		s.running.cancel()
+		s.jobMu.Unlock()
+		return nil
+	}
+	s.jobMu.Unlock()
+
+	_, err := s.db.GetJob(ctx, id)
+	return err
+}
+
+// Close cancels running job and waits for it to be recorded.
+func (s *Service) Close() error {
+	s.jobMu.Lock()
+	running := s.running
+	s.jobMu.Unlock()
+	if running != nil {
+		running.cancel()
+		<-running.done
+	}
+	return nil
+}
+
+func (s *Service) update(ctx context.Context, jobID int64) (err error) {
+	s.progress.start()
+	defer s.progress.finish()
 
 	s.log.Info("update started")
 	defer func(start time.Time) {
@@ -65,6 +195,19 @@ func (s *Service) Update(ctx context.Context) (err error) {
 		exists[id] = true
 	}
 
+	// comics stored by older versions are fetched again to fill in their data
+	stale, err := s.db.StaleIDs(ctx)
+	if err != nil {
+		s.log.Error("failed to get stale comics in DB", "error", err)
+		return fmt.Errorf("failed to get stale comics in DB: %v", err)
+	}
+	s.log.Debug("stale comics in DB", "count", len(stale))
+	refetch := make(map[int]bool, len(stale))
+	for _, id := range stale {
+		refetch[id] = true
+		delete(exists, id)
+	}
+
 	// get last comics ID
 	lastID, err := s.xkcd.LastID(ctx)
 	if err != nil {
@@ -73,28 +216,52 @@ func (s *Service) Update(ctx context.Context) (err error) {
 	}
 	s.log.Debug("last comics ID in XKCD", "id", lastID)
 
+	missing := lastID
+	for id := range exists {
+		if id >= 1 && id <= lastID {
+			missing--
+		}
+	}
+	s.progress.update(func(p *Progress) { p.Total = missing })
+
 	generator := generateIDs(ctx, 1, lastID, exists)
 	fetchers := s.getComics(ctx, generator)
 
 	var errorsFound bool
 	var added int
 	for info := range fetchers {
-		words, err := s.words.Norm(ctx, info.Description)
+		words, terms, err := s.normalize(ctx, info)
 		if err != nil {
 			errorsFound = true
 			s.log.Error("failed to normalize", "id", info.ID, "error", err)
+			s.progress.update(func(p *Progress) { p.Failed++ })
 			continue
 		}
+		s.progress.update(func(p *Progress) { p.Normalized++ })
+		event := Event{Type: EventTypeUpdating, JobID: jobID, Added: []int{info.ID}}
+		if refetch[info.ID] {
+			event.Added, event.Changed = nil, []int{info.ID}
+		}
 		err = s.db.Add(ctx, Comics{
-			ID:    info.ID,
-			URL:   info.URL,
-			Words: words,
-		})
+			ID:         info.ID,
+			URL:        info.URL,
+			Words:      words,
+			Terms:      terms,
+			Title:      info.Title,
+			SafeTitle:  info.SafeTitle,
+			Alt:        info.Alt,
+			Transcript: info.Transcript,
+			Link:       info.Link,
+			News:       info.News,
+			Published:  info.Published,
+		}, event)
 		if err != nil {
 			errorsFound = true
 			s.log.Error("failed to save comics", "id", info.ID, "error", err)
+			s.progress.update(func(p *Progress) { p.Failed++ })
 			continue
 		}
+		s.progress.update(func(p *Progress) { p.Stored++ })
 		added++
 	}
 	s.log.Debug("added new comics", "count", added)
@@ -102,14 +269,94 @@ func (s *Service) Update(ctx context.Context) (err error) {
 	if errorsFound {
 		return fmt.Errorf("failed to fetch/store some comics")
 	}
+	return nil
+}
 
-	err = s.notificator.Publish(ctx, EventTypeUpdating)
+// normalize builds per field term statistics of comics and
+// the list of unique stems over all fields. Fields are normalized in one call,
+// tokens of the joined text are split back by the number of words in each field.
+func (s *Service) normalize(ctx context.Context, info XKCDInfo) ([]string, []Term, error) {
+	fields := []struct {
+		name string
+		text string
+	}{
+		{FieldTitle, info.Title},
+		{FieldSafeTitle, info.SafeTitle},
+		{FieldTranscript, info.Transcript},
+		{FieldAlt, info.Alt},
+	}
+
+	texts := make([]string, len(fields))
+	for i, field := range fields {
+		texts[i] = field.text
+	}
+	tokens, err := s.words.NormDetailed(ctx, strings.Join(texts, "\n"))
 	if err != nil {
-		s.log.Error("failed to publish event", "error", err)
-		return fmt.Errorf("failed to publish event: %v", err)
+		return nil, nil, fmt.Errorf("failed to normalize fields: %w", err)
 	}
 
-	return nil
+	var words []string
+	var terms []Term
+	seen := make(map[string]bool)
+	offset := 0
+	for _, field := range fields {
+		end := offset + countWords(field.text)
+		var own []Token
+		for len(tokens) > 0 && tokens[0].Position < end {
+			token := tokens[0]
+			tokens = tokens[1:]
+			token.Position -= offset
+			own = append(own, token)
+		}
+		offset = end
+
+		for _, term := range fieldTerms(field.name, own) {
+			if !seen[term.Term] {
+				seen[term.Term] = true
+				words = append(words, term.Term)
+			}
+			terms = append(terms, term)
+		}
+	}
+	return words, terms, nil
+}
+
+// countWords counts words of text the way the words service splits it:
+// a word is a run of letters and digits.
+func countWords(text string) int {
+	return len(strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
+
+// fieldTerms groups tokens by stem in order of first occurrence, stop words are skipped.
+// Ties between surface forms are resolved in favour of the first one.
+func fieldTerms(field string, tokens []Token) []Term {
+	var terms []Term
+	index := make(map[string]int)
+	forms := make(map[string]map[string]int)
+	for _, token := range tokens {
+		if token.StopWord {
+			continue
+		}
+		i, ok := index[token.Stem]
+		if !ok {
+			i = len(terms)
+			index[token.Stem] = i
+			terms = append(terms, Term{Field: field, Term: token.Stem})
+			forms[token.Stem] = make(map[string]int)
+		}
+		terms[i].TF++
+		terms[i].Positions = append(terms[i].Positions, token.Position)
+
+		surface := strings.ToLower(token.Surface)
+		counts := forms[token.Stem]
+		counts[surface]++
+		if counts[surface] > counts[terms[i].Surface] {
+			terms[i].Surface = surface
+		}
+	}
+	return terms
 }
 
 func generateIDs(ctx context.Context, first, last int, exists map[int]bool) <-chan int {
@@ -144,15 +391,18 @@ func (s *Service) getComics(ctx context.Context, in <-chan int) <-chan XKCDInfo
 			for id := range in {
 				if id == 404 {
 					// special case
-					out <- XKCDInfo{ID: id, Description: "404 Not found"}
+					s.progress.update(func(p *Progress) { p.Fetched++ })
+					out <- XKCDInfo{ID: id, Title: "404 Not found"}
 					continue
 				}
 				info, err := s.xkcd.Get(ctx, id)
 				if err != nil {
 					s.log.Error("failed to get comics", "id", id, "error", err)
+					s.progress.update(func(p *Progress) { p.Failed++ })
 					continue
 				}
 				s.log.Debug("fetched", "id", id)
+				s.progress.update(func(p *Progress) { p.Fetched++ })
 				out <- info
 			}
 		}()
@@ -189,15 +439,63 @@ func (s *Service) Status(ctx context.Context) ServiceStatus {
 	return StatusIdle
 }
 
+// Watch streams progress of updates until ctx is done.
+// The current state is sent first.
+func (s *Service) Watch(ctx context.Context) <-chan Progress {
+	return s.progress.watch(ctx)
+}
+
+func (s *Service) NextUpdate(ctx context.Context) time.Time {
+	next := s.nextUpdate.Load()
+	if next == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, next)
+}
+
+// Schedule runs update every period until ctx is done.
+// Ticks are skipped while another update holds the lock.
+func (s *Service) Schedule(ctx context.Context, period time.Duration) {
+	if period <= 0 {
+		s.log.Error("scheduled updates are disabled", "period", period)
+		return
+	}
+	defer s.nextUpdate.Store(0)
+
+	for {
+		delay := period + rand.N(period/jitterDivisor+1)
+		s.nextUpdate.Store(time.Now().Add(delay).UnixNano())
+		s.log.Debug("next scheduled update", "in", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.log.Info("stopping update scheduler")
+			return
+		case <-timer.C:
+		}
+
+		job, done, err := s.start(ctx, scheduler)
+		switch {
+		case errors.Is(err, ErrAlreadyExists):
+			s.log.Info("skipping scheduled update, another one is running")
+		case err != nil:
+			s.log.Error("scheduled update failed", "error", err)
+		default:
+			s.log.Debug("scheduled update started", "job", job.ID)
+			<-done
+		}
+	}
+}
+
+// Drop removes all comics. The drop event is stored together with the change
+// and is published later by Relay.
 func (s *Service) Drop(ctx context.Context) error {
-	err := s.db.Drop(ctx)
+	err := s.db.Drop(ctx, Event{Type: EventTypeDropped})
 	if err != nil {
 		s.log.Error("failed to drop db entries", "error", err)
+		return fmt.Errorf("failed to drop db entries: %v", err)
 	}
-	err = s.notificator.Publish(ctx, EventTypeDropped)
-	if err != nil {
-		s.log.Error("failed to publish event", "error", err)
-		return fmt.Errorf("failed to publish event: %v", err)
-	}
-	return err
+	return nil
 }
@@ -5,40 +5,95 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"time"
+	"unicode"
 )
 
 type fakeDB struct {
 	ids    []int
 	idsErr error
+	stale  []int
 
 	added  []Comics
+	events []Event
 	addErr error
 
 	stats    DBStats
 	statsErr error
 
 	dropErr error
+
+	mu       sync.Mutex
+	jobs     map[int64]Job
+	jobErr   error
+	finished []Job
 }
 
-func (f *fakeDB) Add(ctx context.Context, c Comics) error {
+func (f *fakeDB) Add(ctx context.Context, c Comics, e Event) error {
+	if f.addErr != nil {
+		return f.addErr
+	}
 	f.added = append(f.added, c)
-	return f.addErr
+	f.events = append(f.events, e)
+	return nil
 }
 
 func (f *fakeDB) Stats(ctx context.Context) (DBStats, error) {
 	return f.stats, f.statsErr
 }
 
-func (f *fakeDB) Drop(ctx context.Context) error {
-	return f.dropErr
+func (f *fakeDB) Drop(ctx context.Context, e Event) error {
+	if f.dropErr != nil {
+		return f.dropErr
+	}
+	f.events = append(f.events, e)
+	return nil
 }
 
 func (f *fakeDB) IDs(ctx context.Context) ([]int, error) {
 	return f.ids, f.idsErr
 }
 
+func (f *fakeDB) StaleIDs(ctx context.Context) ([]int, error) {
+	return f.stale, nil
+}
+
+func (f *fakeDB) CreateJob(ctx context.Context, job Job) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.jobErr != nil {
+		return 0, f.jobErr
+	}
+	if f.jobs == nil {
+		f.jobs = make(map[int64]Job)
+	}
+	job.ID = int64(len(f.jobs) + 1)
+	f.jobs[job.ID] = job
+	return job.ID, nil
+}
+
+func (f *fakeDB) FinishJob(ctx context.Context, job Job) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobs[job.ID] = job
+	f.finished = append(f.finished, job)
+	return nil
+}
+
+func (f *fakeDB) GetJob(ctx context.Context, id int64) (Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, ok := f.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return job, nil
+}
+
 type fakeXKCD struct {
 	lastID  int
 	lastErr error
@@ -58,32 +113,49 @@ func (f fakeXKCD) LastID(ctx context.Context) (int, error) {
 	return f.lastID, f.lastErr
 }
 
+// fakeWords splits phrase into words like the words service, stems are lower-cased words
 type fakeWords struct {
-	words []string
 	err   error
+	calls *int
 }
 
-func (f fakeWords) Norm(ctx context.Context, phrase string) ([]string, error) {
-	return f.words, f.err
+func (f fakeWords) NormDetailed(ctx context.Context, phrase string) ([]Token, error) {
+	if f.calls != nil {
+		*f.calls++
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	words := strings.FieldsFunc(phrase, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]Token, 0, len(words))
+	for i, w := range words {
+		stem := strings.ToLower(w)
+		tokens = append(tokens, Token{Surface: w, Stem: stem, Position: i, StopWord: stem == "the"})
+	}
+	return tokens, nil
 }
 
-type fakeNotificator struct {
-	mu     sync.Mutex
-	events []EventType
-	err    error
+func waitJob(s *Service) {
+	s.jobMu.Lock()
+	running := s.running
+	s.jobMu.Unlock()
+	if running != nil {
+		<-running.done
+	}
 }
 
-func (f *fakeNotificator) Publish(ctx context.Context, e EventType) error {
+func (f *fakeDB) jobCount() int {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.events = append(f.events, e)
-	return f.err
+	return len(f.jobs)
 }
 
-func newTestService(t *testing.T, db DB, xkcd XKCD, words Words, n Notificator) *Service {
+func newTestService(t *testing.T, db DB, xkcd XKCD, words Words) *Service {
 	t.Helper()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	s, err := NewService(logger, db, xkcd, words, 2, "topic", n)
+	s, err := NewService(logger, db, xkcd, words, 2, "topic")
 	if err != nil {
 		t.Fatalf("NewService returned error: %v", err)
 	}
@@ -92,7 +164,7 @@ func newTestService(t *testing.T, db DB, xkcd XKCD, words Words, n Notificator)
 
 func TestNewService_WrongConcurrency(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	if _, err := NewService(logger, &fakeDB{}, fakeXKCD{}, fakeWords{}, 0, "t", &fakeNotificator{}); err == nil {
+	if _, err := NewService(logger, &fakeDB{}, fakeXKCD{}, fakeWords{}, 0, "t"); err == nil {
 		t.Fatalf("expected error for concurrency 0")
 	}
 }
@@ -104,40 +176,196 @@ func TestService_Update_Success(t *testing.T) {
 	x := fakeXKCD{
 		lastID: 3,
 		infos: map[int]XKCDInfo{
-			2: {ID: 2, URL: "u2", Description: "desc"},
-			3: {ID: 3, URL: "u3", Description: "desc"},
+			2: {ID: 2, URL: "u2", Transcript: "desc", Title: "t2", Alt: "a2"},
+			3: {ID: 3, URL: "u3", Transcript: "desc", Title: "t3", Alt: "a3"},
 		},
 	}
-	w := fakeWords{words: []string{"w1", "w2"}}
-	n := &fakeNotificator{}
+	w := fakeWords{}
 
-	s := newTestService(t, db, x, w, n)
+	s := newTestService(t, db, x, w)
 
-	err := s.Update(context.Background())
+	job, err := s.Update(context.Background(), "admin")
 	if err != nil {
 		t.Fatalf("Update returned error: %v", err)
 	}
-	if len(db.added) == 0 {
-		t.Fatalf("expected comics to be added")
+	if job.ID == 0 || job.Status != JobStatusRunning || job.TriggeredBy != "admin" {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+	waitJob(s)
+
+	if len(db.finished) != 1 || db.finished[0].Status != JobStatusDone || db.finished[0].Stored != 2 {
+		t.Fatalf("unexpected finished jobs: %#v", db.finished)
+	}
+	if len(db.added) != 2 {
+		t.Fatalf("expected 2 comics to be added, got %d", len(db.added))
+	}
+	for _, c := range db.added {
+		if c.Title != x.infos[c.ID].Title || c.Alt != x.infos[c.ID].Alt {
+			t.Fatalf("metadata is not stored for comics %d: %#v", c.ID, c)
+		}
+		if !slices.Equal(c.Words, []string{"t" + c.URL[1:], "desc", "a" + c.URL[1:]}) {
+			t.Fatalf("expected unique words for comics %d, got %#v", c.ID, c.Words)
+		}
+		// title, transcript and alt are split into terms separately
+		if len(c.Terms) != 3 || c.Terms[0].Field != FieldTitle || c.Terms[0].TF != 1 {
+			t.Fatalf("unexpected terms for comics %d: %#v", c.ID, c.Terms)
+		}
+	}
+	// every stored comics is accompanied by its event
+	for i, e := range db.events {
+		if e.Type != EventTypeUpdating || e.JobID != job.ID || !slices.Equal(e.Added, []int{db.added[i].ID}) {
+			t.Fatalf("unexpected event for comics %d: %#v", db.added[i].ID, e)
+		}
+	}
+}
+
+func TestService_Update_RefetchesStale(t *testing.T) {
+	published := time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := &fakeDB{ids: []int{1, 2}, stale: []int{1}}
+	x := fakeXKCD{
+		lastID: 3,
+		infos: map[int]XKCDInfo{
+			1: {ID: 1, URL: "u1", Title: "t1", Published: published},
+			3: {ID: 3, URL: "u3", Title: "t3", Published: published},
+		},
+	}
+	s := newTestService(t, db, x, fakeWords{})
+
+	if _, err := s.Update(context.Background(), "admin"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	waitJob(s)
+
+	// comics stored before metadata is fetched again and announced as changed
+	events := make(map[int]Event)
+	for i, c := range db.added {
+		if !c.Published.Equal(published) {
+			t.Fatalf("expected publication date of comics %d, got %v", c.ID, c.Published)
+		}
+		events[c.ID] = db.events[i]
 	}
-	if len(n.events) != 1 || n.events[0] != EventTypeUpdating {
-		t.Fatalf("expected updating event, got %#v", n.events)
+	if len(db.added) != 2 || !slices.Equal(events[1].Changed, []int{1}) || events[1].Added != nil ||
+		!slices.Equal(events[3].Added, []int{3}) {
+		t.Fatalf("expected stale comics 1 to be changed and 3 added, got %#v", events)
+	}
+	if p := s.progress.snapshot(); p.Total != 2 {
+		t.Fatalf("expected 2 comics to be fetched, got %#v", p)
 	}
 }
 
 func TestService_Update_LockAlreadyHeld(t *testing.T) {
 	db := &fakeDB{}
-	s := newTestService(t, db, fakeXKCD{}, fakeWords{}, &fakeNotificator{})
+	s := newTestService(t, db, fakeXKCD{}, fakeWords{})
 
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	err := s.Update(context.Background())
+	_, err := s.Update(context.Background(), "admin")
 	if !errors.Is(err, ErrAlreadyExists) {
 		t.Fatalf("expected ErrAlreadyExists, got %v", err)
 	}
 }
 
+func TestService_Update_CreateJobError(t *testing.T) {
+	s := newTestService(t, &fakeDB{jobErr: errors.New("db error")}, fakeXKCD{}, fakeWords{})
+
+	if _, err := s.Update(context.Background(), "admin"); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if !s.lock.TryLock() {
+		t.Fatalf("expected lock to be released")
+	}
+	s.lock.Unlock()
+}
+
+func TestService_Update_FailedJob(t *testing.T) {
+	db := &fakeDB{}
+	s := newTestService(t, db, fakeXKCD{lastErr: errors.New("xkcd down")}, fakeWords{})
+
+	if _, err := s.Update(context.Background(), "admin"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	waitJob(s)
+
+	if len(db.finished) != 1 || db.finished[0].Status != JobStatusFailed || db.finished[0].Error == "" {
+		t.Fatalf("expected failed job, got %#v", db.finished)
+	}
+}
+
+// blockingXKCD blocks LastID until ctx is done.
+type blockingXKCD struct {
+	fakeXKCD
+	started chan struct{}
+}
+
+func (b blockingXKCD) LastID(ctx context.Context) (int, error) {
+	close(b.started)
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestService_CancelJob(t *testing.T) {
+	db := &fakeDB{}
+	x := blockingXKCD{started: make(chan struct{})}
+	s := newTestService(t, db, x, fakeWords{})
+
+	job, err := s.Update(context.Background(), "admin")
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	<-x.started
+
+	if err := s.CancelJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("CancelJob returned error: %v", err)
+	}
+	waitJob(s)
+
+	got, err := s.Job(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("Job returned error: %v", err)
+	}
+	if got.Status != JobStatusCanceled || got.FinishedAt.IsZero() {
+		t.Fatalf("expected canceled job, got %#v", got)
+	}
+	if s.Status(context.Background()) != StatusIdle {
+		t.Fatalf("expected idle status after cancel")
+	}
+
+	// finished job cancel is a no-op
+	if err := s.CancelJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("CancelJob returned error for finished job: %v", err)
+	}
+}
+
+func TestService_CancelJob_NotFound(t *testing.T) {
+	s := newTestService(t, &fakeDB{}, fakeXKCD{}, fakeWords{})
+
+	if err := s.CancelJob(context.Background(), 42); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.Job(context.Background(), 42); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestService_Close_CancelsJob(t *testing.T) {
+	db := &fakeDB{}
+	x := blockingXKCD{started: make(chan struct{})}
+	s := newTestService(t, db, x, fakeWords{})
+
+	if _, err := s.Update(context.Background(), "admin"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	<-x.started
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if len(db.finished) != 1 || db.finished[0].Status != JobStatusCanceled {
+		t.Fatalf("expected canceled job recorded, got %#v", db.finished)
+	}
+}
+
 func TestService_Stats(t *testing.T) {
 	db := &fakeDB{
 		stats: DBStats{
@@ -148,7 +376,7 @@ func TestService_Stats(t *testing.T) {
 	}
 	x := fakeXKCD{lastID: 10}
 
-	s := newTestService(t, db, x, fakeWords{}, &fakeNotificator{})
+	s := newTestService(t, db, x, fakeWords{})
 
 	st, err := s.Stats(context.Background())
 	if err != nil {
@@ -160,7 +388,7 @@ func TestService_Stats(t *testing.T) {
 }
 
 func TestService_Status(t *testing.T) {
-	s := newTestService(t, &fakeDB{}, fakeXKCD{}, fakeWords{}, &fakeNotificator{})
+	s := newTestService(t, &fakeDB{}, fakeXKCD{}, fakeWords{})
 
 	if s.Status(context.Background()) != StatusIdle {
 		t.Fatalf("expected idle status")
@@ -173,13 +401,193 @@ func TestService_Status(t *testing.T) {
 
 func TestService_Drop(t *testing.T) {
 	db := &fakeDB{}
-	n := &fakeNotificator{}
-	s := newTestService(t, db, fakeXKCD{}, fakeWords{}, n)
+	s := newTestService(t, db, fakeXKCD{}, fakeWords{})
 
 	if err := s.Drop(context.Background()); err != nil {
 		t.Fatalf("Drop returned error: %v", err)
 	}
-	if len(n.events) != 1 || n.events[0] != EventTypeDropped {
-		t.Fatalf("expected dropped event, got %#v", n.events)
+	if len(db.events) != 1 || db.events[0].Type != EventTypeDropped {
+		t.Fatalf("expected dropped event, got %#v", db.events)
+	}
+
+	s = newTestService(t, &fakeDB{dropErr: errors.New("db error")}, fakeXKCD{}, fakeWords{})
+	if err := s.Drop(context.Background()); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestService_Schedule_RunsUpdates(t *testing.T) {
+	db := &fakeDB{}
+	s := newTestService(t, db, fakeXKCD{}, fakeWords{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Schedule(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for db.jobCount() < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected scheduled updates, got %d", db.jobCount())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if s.NextUpdate(ctx).IsZero() {
+		t.Fatalf("expected next update time while scheduled")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("scheduler did not stop")
+	}
+	if !s.NextUpdate(ctx).IsZero() {
+		t.Fatalf("expected no next update after stop")
+	}
+}
+
+func TestService_Schedule_SkipsWhenLocked(t *testing.T) {
+	db := &fakeDB{}
+	s := newTestService(t, db, fakeXKCD{}, fakeWords{})
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Schedule(ctx, 5*time.Millisecond)
+
+	if db.jobCount() != 0 {
+		t.Fatalf("expected no updates while locked, got %d", db.jobCount())
+	}
+}
+
+func TestService_Schedule_Disabled(t *testing.T) {
+	s := newTestService(t, &fakeDB{}, fakeXKCD{}, fakeWords{})
+	s.Schedule(context.Background(), 0)
+	if !s.NextUpdate(context.Background()).IsZero() {
+		t.Fatalf("expected no next update for disabled scheduler")
+	}
+}
+
+func TestService_Watch_Progress(t *testing.T) {
+	db := &fakeDB{ids: []int{1}}
+	x := fakeXKCD{
+		lastID: 3,
+		infos: map[int]XKCDInfo{
+			2: {ID: 2, URL: "u2", Transcript: "desc"},
+			3: {ID: 3, URL: "u3", Transcript: "desc"},
+		},
+	}
+	s := newTestService(t, db, x, fakeWords{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := s.Watch(ctx)
+
+	initial := <-updates
+	if initial.Status != StatusIdle {
+		t.Fatalf("expected idle initial progress, got %#v", initial)
+	}
+
+	if _, err := s.Update(context.Background(), "admin"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	waitJob(s)
+
+	var last Progress
+	for p := range updates {
+		last = p
+		if p.Status == StatusIdle {
+			break
+		}
+	}
+	want := Progress{Status: StatusIdle, Total: 2, Fetched: 2, Normalized: 2, Stored: 2}
+	if last != want {
+		t.Fatalf("expected final progress %#v, got %#v", want, last)
+	}
+}
+
+func TestService_Watch_StopsOnCancel(t *testing.T) {
+	s := newTestService(t, &fakeDB{}, fakeXKCD{}, fakeWords{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := s.Watch(ctx)
+	<-updates
+	cancel()
+
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Fatalf("expected closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("watch did not stop")
+	}
+}
+
+func TestFieldTerms(t *testing.T) {
+	tokens := []Token{
+		{Surface: "The", Stem: "the", Position: 0, StopWord: true},
+		{Surface: "cats", Stem: "cat", Position: 1},
+		{Surface: "and", Stem: "and", Position: 2, StopWord: true},
+		{Surface: "dogs", Stem: "dog", Position: 3},
+		{Surface: "Cat", Stem: "cat", Position: 4},
+		{Surface: "cat", Stem: "cat", Position: 5},
+	}
+
+	terms := fieldTerms(FieldAlt, tokens)
+	if len(terms) != 2 {
+		t.Fatalf("expected 2 terms, got %#v", terms)
+	}
+	cat := terms[0]
+	if cat.Field != FieldAlt || cat.Term != "cat" || cat.TF != 3 || !slices.Equal(cat.Positions, []int{1, 4, 5}) {
+		t.Fatalf("unexpected term: %#v", cat)
+	}
+	if cat.Surface != "cat" {
+		t.Fatalf("expected the most frequent lower-cased form, got %q", cat.Surface)
+	}
+	if terms[1].Term != "dog" || terms[1].Surface != "dogs" || terms[1].TF != 1 || !slices.Equal(terms[1].Positions, []int{3}) {
+		t.Fatalf("unexpected term: %#v", terms[1])
+	}
+}
+
+func TestService_Normalize_OneCall(t *testing.T) {
+	var calls int
+	s := newTestService(t, &fakeDB{}, fakeXKCD{}, fakeWords{calls: &calls})
+
+	info := XKCDInfo{Title: "Cat's dog", Transcript: "The dog, the cat", Alt: "cat cat"}
+	words, terms, err := s.normalize(context.Background(), info)
+	if err != nil {
+		t.Fatalf("normalize returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected fields to be normalized in one call, got %d", calls)
+	}
+	if !slices.Equal(words, []string{"cat", "s", "dog"}) {
+		t.Fatalf("unexpected words: %v", words)
+	}
+
+	// positions are counted from the start of each field
+	positions := make(map[string][]int)
+	for _, term := range terms {
+		positions[term.Field+":"+term.Term] = term.Positions
+	}
+	want := map[string][]int{
+		"title:cat": {0}, "title:s": {1}, "title:dog": {2},
+		"transcript:dog": {1}, "transcript:cat": {3},
+		"alt:cat": {0, 1},
+	}
+	if len(positions) != len(want) {
+		t.Fatalf("unexpected terms: %v", positions)
+	}
+	for key, p := range want {
+		if !slices.Equal(positions[key], p) {
+			t.Fatalf("%s: expected positions %v, got %v", key, p, positions[key])
+		}
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -57,12 +58,12 @@ func run(cfg config.Config, log *slog.Logger) error {
 		return fmt.Errorf("failed create Words client: %v", err)
 	}
 
-	notificator, err := nats.NewNotificator(cfg.BrokerAddress, log)
+	notificator, err := nats.NewNotificator(cfg.BrokerAddress, cfg.Stream, log)
 	if err != nil {
 		return fmt.Errorf("failed create Nats notificator: %v", err)
 	}
 
-	updater, err := core.NewService(log, storage, xkcdClient, wordsClient, cfg.XKCD.Concurrency, cfg.Topic, notificator)
+	updater, err := core.NewService(log, storage, xkcdClient, wordsClient, cfg.XKCD.Concurrency, cfg.Topic)
 	if err != nil {
 		return fmt.Errorf("failed create Update service: %v", err)
 	}
@@ -72,7 +73,7 @@ func run(cfg config.Config, log *slog.Logger) error {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 
-	defer closers.CloseOrLog(log, storage, wordsClient, notificator)
+	defer closers.CloseOrLog(log, updater, storage, wordsClient, notificator)
 
 	s := grpc.NewServer()
 	updatepb.RegisterUpdateServer(s, updategrpc.NewServer(updater))
@@ -81,6 +82,20 @@ func run(cfg config.Config, log *slog.Logger) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	// scheduled updates and delivery of stored events
+	relay := core.NewRelay(log, storage, notificator, cfg.OutboxPeriod)
+	var background sync.WaitGroup
+	background.Go(func() {
+		updater.Schedule(ctx, cfg.XKCD.CheckPeriod)
+	})
+	background.Go(func() {
+		relay.Run(ctx)
+	})
+	defer func() {
+		stop()
+		background.Wait()
+	}()
+
 	go func() {
 		<-ctx.Done()
 		log.Debug("shutting down server")
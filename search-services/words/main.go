@@ -35,6 +35,24 @@ func (s *server) Norm(_ context.Context, in *wordspb.WordsRequest) (*wordspb.Wor
 	return &wordspb.WordsReply{Words: normalizedWords}, nil
 }
 
+func (s *server) NormDetailed(_ context.Context, in *wordspb.WordsRequest) (*wordspb.TokensReply, error) {
+	if len(in.Phrase) > maxPhraseLen {
+		return nil, status.Errorf(codes.ResourceExhausted, "phrase is too long, max length is %d", maxPhraseLen)
+	}
+
+	tokens := words.NormDetailed(in.Phrase)
+	reply := &wordspb.TokensReply{Tokens: make([]*wordspb.Token, 0, len(tokens))}
+	for _, t := range tokens {
+		reply.Tokens = append(reply.Tokens, &wordspb.Token{
+			Surface:  t.Surface,
+			Stem:     t.Stem,
+			Position: int64(t.Position),
+			StopWord: t.StopWord,
+		})
+	}
+	return reply, nil
+}
+
 type Config struct {
 	Address string `yaml:"words_address" env:"WORDS_ADDRESS" env-default:"80"`
 }
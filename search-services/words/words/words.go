@@ -1,28 +1,49 @@
 package words
 
 import (
-	"maps"
-	"slices"
 	"strings"
 	"unicode"
 
 	"github.com/kljensen/snowball/english"
 )
 
-func Norm(phrase string) []string {
-	words := make(map[string]bool)
+type Token struct {
+	Surface  string
+	Stem     string
+	Position int
+	StopWord bool
+}
+
+// NormDetailed splits phrase into tokens keeping their order.
+// Position is the index of the token in the phrase, stop words included.
+func NormDetailed(phrase string) []Token {
 	splitted := strings.FieldsFunc(phrase, func(r rune) bool {
 		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
 	})
 
-	for _, w := range splitted {
-		w := strings.ToLower(w)
-		if english.IsStopWord(w) {
+	tokens := make([]Token, 0, len(splitted))
+	for i, w := range splitted {
+		lower := strings.ToLower(w)
+		tokens = append(tokens, Token{
+			Surface:  w,
+			Stem:     english.Stem(lower, false),
+			Position: i,
+			StopWord: english.IsStopWord(lower),
+		})
+	}
+	return tokens
+}
+
+// Norm returns unique stems of phrase without stop words.
+func Norm(phrase string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, token := range NormDetailed(phrase) {
+		if token.StopWord || seen[token.Stem] {
 			continue
 		}
-		words[english.Stem(w, false)] = true
+		seen[token.Stem] = true
+		words = append(words, token.Stem)
 	}
-
-	return slices.Collect(maps.Keys(words))
-
+	return words
 }
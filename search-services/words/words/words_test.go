@@ -22,3 +22,28 @@ func TestNorm_RemovesStopWordsAndNormalizes(t *testing.T) {
 		t.Fatalf("expected stemmed word \"appl\" in result: %#v", result)
 	}
 }
+
+func TestNorm_Deduplicates(t *testing.T) {
+	result := Norm("apple apples Apple")
+	if len(result) != 1 || result[0] != "appl" {
+		t.Fatalf("expected single stem, got %#v", result)
+	}
+}
+
+func TestNormDetailed_KeepsPositionsAndStopWords(t *testing.T) {
+	tokens := NormDetailed("The Apples, the pie")
+
+	if len(tokens) != 4 {
+		t.Fatalf("expected 4 tokens, got %#v", tokens)
+	}
+	if !tokens[0].StopWord || tokens[0].Surface != "The" {
+		t.Fatalf("expected first token to be stop word, got %#v", tokens[0])
+	}
+	apple := tokens[1]
+	if apple.Surface != "Apples" || apple.Stem != "appl" || apple.Position != 1 || apple.StopWord {
+		t.Fatalf("unexpected token: %#v", apple)
+	}
+	if tokens[3].Position != 3 || tokens[3].Stem != "pie" {
+		t.Fatalf("unexpected last token: %#v", tokens[3])
+	}
+}
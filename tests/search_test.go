@@ -61,7 +61,7 @@ func SearchLimit2(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode, "need OK status")
 	var comics ComicsReply
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&comics), "decode failed")
-	require.Equal(t, 2, comics.Total)
+	require.GreaterOrEqual(t, comics.Total, 2)
 	require.Equal(t, 2, len(comics.Comics))
 }
 
@@ -73,7 +73,7 @@ func SearchLimitDefault(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode, "need OK status")
 	var comics ComicsReply
 	require.NoError(t, json.NewDecoder(resp.Body).Decode(&comics), "decode failed")
-	require.Equal(t, 10, comics.Total)
+	require.GreaterOrEqual(t, comics.Total, 10)
 	require.Equal(t, 10, len(comics.Comics))
 }
 
@@ -24,6 +24,12 @@ type UpdateStatus struct {
 	Status string `json:"status"`
 }
 
+type UpdateJob struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
 func TestEmptyDB(t *testing.T) {
 	prepare(t)
 }
@@ -101,6 +107,7 @@ func prepare(t *testing.T) {
 }
 
 // this must not contain t because it runs in a waited goroutine
+// update waits for the started job to finish
 func update(token string) (int, error) {
 	req, err := http.NewRequest(http.MethodPost, address+"/api/db/update", nil)
 	if err != nil {
@@ -111,10 +118,43 @@ func update(token string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	resp.Body.Close()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	var job UpdateJob
+	if err = json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return 0, fmt.Errorf("could not decode: %v", err)
+	}
+	for job.Status == "running" {
+		time.Sleep(time.Second)
+		if job, err = getJob(job.ID); err != nil {
+			return 0, err
+		}
+	}
+	if job.Status != "done" {
+		return 0, fmt.Errorf("update job %d finished with %q: %s", job.ID, job.Status, job.Error)
+	}
 	return resp.StatusCode, nil
 }
 
+// this must not contain t because it runs in a waited goroutine
+func getJob(id int64) (UpdateJob, error) {
+	resp, err := client.Get(fmt.Sprintf("%s/api/db/jobs/%d", address, id))
+	if err != nil {
+		return UpdateJob{}, err
+	}
+	defer resp.Body.Close()
+	if http.StatusOK != resp.StatusCode {
+		return UpdateJob{}, fmt.Errorf("http status: %v", resp.Status)
+	}
+	var job UpdateJob
+	if err = json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return UpdateJob{}, fmt.Errorf("could not decode: %v", err)
+	}
+	return job, nil
+}
+
 // this must not contain t because it runs in a waited goroutine
 func status() (string, error) {
 	resp, err := client.Get(address + "/api/db/status")